@@ -0,0 +1,24 @@
+// Command openapi writes the OpenAPI spec of the HTTP gateway.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/zen-flo/telegram-service/internal/gateway"
+)
+
+func main() {
+	out := flag.String("out", "pkg/api/proto/telegram.openapi.json", "output file")
+	flag.Parse()
+
+	spec, err := gateway.OpenAPI()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.WriteFile(*out, spec, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
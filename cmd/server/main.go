@@ -15,7 +15,12 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	logger, err := zap.NewProduction()
+	// The level follows LOG_LEVEL, also on config reload.
+	level := zap.NewAtomicLevel()
+	logConfig := zap.NewProductionConfig()
+	logConfig.Level = level
+
+	logger, err := logConfig.Build()
 	if err != nil {
 		panic(err)
 	}
@@ -27,8 +32,9 @@ func main() {
 	if err != nil {
 		logger.Fatal("failed to load config", zap.Error(err))
 	}
+	level.SetLevel(cfg.LogLevel)
 
-	application, err := app.New(cfg, logger)
+	application, err := app.New(cfg, logger, level)
 	if err != nil {
 		logger.Fatal("failed to initialize app", zap.Error(err))
 	}
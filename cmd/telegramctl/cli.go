@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	api "github.com/zen-flo/telegram-service/pkg/api/proto"
+	"golang.org/x/term"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	jsonOutput = protojson.MarshalOptions{UseProtoNames: true, Multiline: true, Indent: "  "}
+	// jsonLine writes one message per line, for streams.
+	jsonLine = protojson.MarshalOptions{UseProtoNames: true}
+)
+
+// cli is the state of a command: the options and, once dialled, the
+// connection to the server.
+type cli struct {
+	in       *bufio.Reader
+	terminal *os.File // the input, when it is a terminal
+	out      io.Writer
+	opts     options
+
+	conn   *grpc.ClientConn
+	client api.TelegramServiceClient
+}
+
+// dial connects to the server of the resolved profile.
+func (c *cli) dial() (api.TelegramServiceClient, error) {
+	if c.client != nil {
+		return c.client, nil
+	}
+
+	p, err := c.opts.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	creds := insecure.NewCredentials()
+	if p.secure() {
+		cfg, err := p.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(cfg)
+	}
+
+	dialOpts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if p.APIKey != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(apiKey(p.APIKey)))
+	}
+
+	conn, err := grpc.NewClient(p.Server, dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", p.Server, err)
+	}
+
+	c.conn = conn
+	c.client = api.NewTelegramServiceClient(conn)
+	return c.client, nil
+}
+
+func (c *cli) close() {
+	if c.conn != nil {
+		_ = c.conn.Close()
+	}
+}
+
+func (c *cli) json() bool {
+	return c.opts.output == "json"
+}
+
+// printMessage writes m as indented JSON.
+func (c *cli) printMessage(m proto.Message) error {
+	data, err := jsonOutput.Marshal(m)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.out, "%s\n", data)
+	return err
+}
+
+// printValue writes v as indented JSON.
+func (c *cli) printValue(v any) error {
+	enc := json.NewEncoder(c.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// readPassword asks for a password on stderr. It is read without echo
+// from a terminal, otherwise as a line of the input.
+func (c *cli) readPassword(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+
+	if c.terminal != nil && c.in.Buffered() == 0 {
+		b, err := term.ReadPassword(int(c.terminal.Fd()))
+		fmt.Fprintln(os.Stderr)
+		return string(b), err
+	}
+
+	line, err := c.in.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		return "", fmt.Errorf("failed to read the password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (p profile) secure() bool {
+	return p.TLS || p.CAFile != "" || p.CertFile != ""
+}
+
+func (p profile) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: p.ServerName,
+	}
+
+	if p.CAFile != "" {
+		pem, err := os.ReadFile(p.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca file: %w", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("ca file contains no PEM certificates")
+		}
+	}
+
+	if p.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(p.CertFile, p.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// apiKey sends the key in the x-api-key header of every call.
+type apiKey string
+
+func (k apiKey) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"x-api-key": string(k)}, nil
+}
+
+// RequireTransportSecurity allows keys over plaintext, for servers on
+// localhost or behind a TLS-terminating proxy.
+func (k apiKey) RequireTransportSecurity() bool {
+	return false
+}
+
+// formatTime formats a unix time, or "-" when it is not set.
+func formatTime(unix int64) string {
+	if unix == 0 {
+		return "-"
+	}
+	return time.Unix(unix, 0).Local().Format(time.DateTime)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	api "github.com/zen-flo/telegram-service/pkg/api/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// exportPageSize is the number of messages export requests at once,
+// the most the server returns.
+const exportPageSize = 100
+
+func runHistory(ctx context.Context, c *cli, args []string) error {
+	fs := newFlagSet("history [-limit n] [-before id] <session> <peer>")
+	limit := fs.Int("limit", 20, "number of messages, up to 100")
+	before := fs.Int64("before", 0, "only messages older than this message id")
+	if err := parse(fs, args, 2, 2); err != nil {
+		return err
+	}
+	if *limit <= 0 || *limit > exportPageSize || *before < 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	client, err := c.dial()
+	if err != nil {
+		return err
+	}
+	resp, err := client.GetHistory(ctx, &api.GetHistoryRequest{
+		SessionId: proto.String(fs.Arg(0)),
+		Peer:      proto.String(fs.Arg(1)),
+		OffsetId:  proto.Int64(*before),
+		Limit:     proto.Int32(int32(*limit)),
+	})
+	if err != nil {
+		return err
+	}
+
+	if c.json() {
+		return c.printMessage(resp)
+	}
+
+	tw := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDATE\tFROM\tTEXT")
+	for _, m := range resp.GetMessages() {
+		from := m.GetFrom()
+		if m.GetOut() {
+			from = "me"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", m.GetMessageId(), formatTime(m.GetDate()), from, m.GetText())
+	}
+	return tw.Flush()
+}
+
+// runExport writes the history of a chat page by page, newest first,
+// as JSON Lines or CSV. Telegram rate limits are waited out.
+func runExport(ctx context.Context, c *cli, args []string) (err error) {
+	fs := newFlagSet("export [-format jsonl|csv] [-limit n] [-before id] <session> <peer>")
+	format := fs.String("format", "jsonl", "output format: jsonl or csv")
+	limit := fs.Int("limit", 0, "stop after this many messages, 0 exports all")
+	before := fs.Int64("before", 0, "only messages older than this message id")
+	if err := parse(fs, args, 2, 2); err != nil {
+		return err
+	}
+	if (*format != "jsonl" && *format != "csv") || *limit < 0 || *before < 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	client, err := c.dial()
+	if err != nil {
+		return err
+	}
+
+	var write func(*api.HistoryMessage) error
+	if *format == "csv" {
+		w := csv.NewWriter(c.out)
+		defer func() {
+			w.Flush()
+			if err == nil {
+				err = w.Error()
+			}
+		}()
+		if err := w.Write([]string{"message_id", "date", "from", "out", "text"}); err != nil {
+			return err
+		}
+		write = func(m *api.HistoryMessage) error {
+			return w.Write([]string{
+				strconv.FormatInt(m.GetMessageId(), 10),
+				time.Unix(m.GetDate(), 0).UTC().Format(time.RFC3339),
+				m.GetFrom(),
+				strconv.FormatBool(m.GetOut()),
+				m.GetText(),
+			})
+		}
+	} else {
+		write = func(m *api.HistoryMessage) error {
+			data, err := jsonLine.Marshal(m)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(c.out, "%s\n", data)
+			return err
+		}
+	}
+
+	offset, exported := *before, 0
+	for *limit == 0 || exported < *limit {
+		page := exportPageSize
+		if *limit > 0 {
+			page = min(page, *limit-exported)
+		}
+
+		var resp *api.GetHistoryResponse
+		resp, err = client.GetHistory(ctx, &api.GetHistoryRequest{
+			SessionId: proto.String(fs.Arg(0)),
+			Peer:      proto.String(fs.Arg(1)),
+			OffsetId:  proto.Int64(offset),
+			Limit:     proto.Int32(int32(page)),
+		})
+		if delay, ok := retryDelay(err); ok {
+			fmt.Fprintf(os.Stderr, "rate limited, waiting %s\n", delay)
+			if err := sleep(ctx, delay); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		messages := resp.GetMessages()
+		if len(messages) == 0 {
+			return nil
+		}
+		for _, m := range messages {
+			if err := write(m); err != nil {
+				return err
+			}
+		}
+		exported += len(messages)
+		offset = messages[len(messages)-1].GetMessageId()
+	}
+	return nil
+}
+
+// retryDelay returns how long the server asks to wait before retrying
+// a call rejected by a rate limit.
+func retryDelay(err error) (time.Duration, bool) {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.ResourceExhausted {
+		return 0, false
+	}
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok {
+			return info.GetRetryDelay().AsDuration(), true
+		}
+	}
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	api "github.com/zen-flo/telegram-service/pkg/api/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"rsc.io/qr"
+)
+
+// pollInterval is how often login checks whether the code was scanned.
+// A variable for tests.
+var pollInterval = 2 * time.Second
+
+// runLogin creates a session, shows its QR login code and waits until
+// it is scanned in Telegram (Settings > Devices > Link Desktop Device).
+// Accounts with two-step verification are asked for the password,
+// unless the server enters it from TG_2FA_PASSWORD.
+func runLogin(ctx context.Context, c *cli, args []string) error {
+	fs := newFlagSet("login [-label k=v]... [-timeout d] [-invert]")
+	labels := labelFlag{}
+	fs.Var(labels, "label", "label of the session, repeatable")
+	timeout := fs.Duration("timeout", 2*time.Minute, "how long to wait for the code to be scanned")
+	invert := fs.Bool("invert", false, "draw the QR code for terminals with a light background")
+	if err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	client, err := c.dial()
+	if err != nil {
+		return err
+	}
+	created, err := client.CreateSession(ctx, &api.CreateSessionRequest{Labels: labels})
+	if err != nil {
+		return err
+	}
+	id := created.GetSessionId()
+
+	if c.json() {
+		if err := c.printMessage(created); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintf(c.out, "session: %s\n\n", id)
+		if err := printQR(c.out, created.GetQrCode(), *invert); err != nil {
+			return err
+		}
+		fmt.Fprint(c.out, "\nScan the code in Telegram: Settings > Devices > Link Desktop Device.\n")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	st, err := waitForLogin(ctx, client, id, c.readPassword)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("the code of session %s was not scanned in %s, delete it and log in again", id, *timeout)
+		}
+		return err
+	}
+
+	if c.json() {
+		return c.printMessage(st)
+	}
+	fmt.Fprintf(c.out, "logged in, session %s is %s\n", id, stateName(st.GetState()))
+	return nil
+}
+
+// waitForLogin polls the status of the session until it is ready,
+// submitting the password from readPassword when the login needs one.
+func waitForLogin(
+	ctx context.Context,
+	client api.TelegramServiceClient,
+	id string,
+	readPassword func(prompt string) (string, error),
+) (*api.GetSessionStatusResponse, error) {
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+
+		st, err := client.GetSessionStatus(ctx, &api.GetSessionStatusRequest{SessionId: proto.String(id)})
+		switch {
+		case status.Code(err) == codes.NotFound:
+			return nil, fmt.Errorf("session %s expired before the code was scanned", id)
+		case ctx.Err() != nil:
+			return nil, ctx.Err()
+		case err != nil:
+			return nil, err
+		}
+
+		if st.GetPasswordRequired() {
+			if err := submitPassword(ctx, client, id, readPassword); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		switch st.GetState() {
+		case api.SessionState_SESSION_STATE_AUTHORIZED:
+			if st.GetReady() {
+				return st, nil
+			}
+		case api.SessionState_SESSION_STATE_LOGGED_OUT, api.SessionState_SESSION_STATE_FAILED:
+			return nil, fmt.Errorf("login failed, session %s is %s: %s", id, stateName(st.GetState()), st.GetLastError())
+		}
+	}
+}
+
+// submitPassword asks for the 2FA password until the server accepts
+// it.
+func submitPassword(
+	ctx context.Context,
+	client api.TelegramServiceClient,
+	id string,
+	readPassword func(prompt string) (string, error),
+) error {
+
+	for {
+		password, err := readPassword("2FA password: ")
+		if err != nil {
+			return err
+		}
+
+		_, err = client.SubmitPassword(ctx, &api.SubmitPasswordRequest{
+			SessionId: proto.String(id),
+			Password:  proto.String(password),
+		})
+		switch status.Code(err) {
+		case codes.OK:
+			return nil
+		case codes.InvalidArgument:
+			fmt.Fprintln(os.Stderr, "wrong password, try again")
+		case codes.FailedPrecondition:
+			// the login went on without it, the status tells how
+			return nil
+		default:
+			return err
+		}
+	}
+}
+
+// printQR draws text as a QR code of half-block characters, two rows
+// of modules per line. Light modules are drawn, so the code reads on
+// dark terminals unless inverted.
+func printQR(w io.Writer, text string, invert bool) error {
+	code, err := qr.Encode(text, qr.L)
+	if err != nil {
+		return fmt.Errorf("failed to encode qr code: %w", err)
+	}
+
+	const quiet = 2
+	light := func(x, y int) bool {
+		inside := x >= 0 && y >= 0 && x < code.Size && y < code.Size
+		return (inside && code.Black(x, y)) == invert
+	}
+
+	var b strings.Builder
+	for y := -quiet; y < code.Size+quiet; y += 2 {
+		for x := -quiet; x < code.Size+quiet; x++ {
+			switch top, bottom := light(x, y), light(x, y+1); {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
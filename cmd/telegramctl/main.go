@@ -0,0 +1,174 @@
+// Command telegramctl operates a telegram-service over gRPC.
+//
+//	telegramctl [-profile name] [-server addr] [-o text|json] <command> [flags] [args]
+//
+// Run telegramctl help for the commands.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"golang.org/x/term"
+	"google.golang.org/grpc/status"
+)
+
+// errUsage is returned for invalid command lines, after printing the
+// usage.
+var errUsage = errors.New("invalid usage")
+
+type command struct {
+	usage   string
+	summary string
+	run     func(ctx context.Context, c *cli, args []string) error
+}
+
+var commands = map[string]command{
+	"sessions": {"sessions <create|list|status|delete|resume> [flags]", "Manage sessions", runSessions},
+	"login":    {"login [-label k=v]... [-timeout d] [-invert]", "Create a session and log in with the QR code", runLogin},
+	"send":     {"send [-queue] [-idempotency-key k] <session> <peer> <text|->", "Send a message", runSend},
+	"tail":     {"tail [-label k=v]... [-from sender] [session]...", "Stream incoming messages", runTail},
+	"history":  {"history [-limit n] [-before id] <session> <peer>", "Show recent messages of a chat", runHistory},
+	"export":   {"export [-format jsonl|csv] [-limit n] [-before id] <session> <peer>", "Write the history of a chat", runExport},
+	"status":   {"status [-tenant t]", "Show session counts against the limits", runStatus},
+	"profiles": {"profiles <list|use|set> [flags]", "Manage the server profiles", runProfiles},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := run(ctx, os.Args[1:], os.Stdin, os.Stdout)
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+	case errors.Is(err, errUsage):
+		os.Exit(2)
+	default:
+		if st, ok := status.FromError(err); ok {
+			err = fmt.Errorf("%s: %s", st.Code(), st.Message())
+		}
+		fmt.Fprintln(os.Stderr, "telegramctl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, in io.Reader, out io.Writer) error {
+	fs := flag.NewFlagSet("telegramctl", flag.ContinueOnError)
+	fs.Usage = func() { usage(fs) }
+
+	var opts options
+	fs.StringVar(&opts.configFile, "config", "", "profiles file (default $TELEGRAMCTL_CONFIG or "+defaultConfigHint+")")
+	fs.StringVar(&opts.profile, "profile", "", "profile to use (default $TELEGRAMCTL_PROFILE or the current one)")
+	fs.StringVar(&opts.server, "server", "", "server address, overrides the profile")
+	fs.StringVar(&opts.apiKey, "api-key", "", "API key, overrides the profile and $TELEGRAMCTL_API_KEY")
+	fs.StringVar(&opts.output, "o", "text", "output format: text or json")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return errUsage
+	}
+	if opts.output != "text" && opts.output != "json" {
+		fmt.Fprintln(fs.Output(), "-o must be text or json")
+		return errUsage
+	}
+
+	name := fs.Arg(0)
+	if name == "" || name == "help" {
+		usage(fs)
+		if name == "" {
+			return errUsage
+		}
+		return nil
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(fs.Output(), "unknown command %q\n\n", name)
+		usage(fs)
+		return errUsage
+	}
+
+	c := &cli{in: bufio.NewReader(in), out: out, opts: opts}
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		c.terminal = f
+	}
+	defer c.close()
+
+	return cmd.run(ctx, c, fs.Args()[1:])
+}
+
+func usage(fs *flag.FlagSet) {
+	w := fs.Output()
+	fmt.Fprint(w, "Usage: telegramctl [flags] <command> [flags] [args]\n\nCommands:\n")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-10s %s\n", name, commands[name].summary)
+	}
+
+	fmt.Fprint(w, "\nFlags:\n")
+	fs.PrintDefaults()
+}
+
+// newFlagSet returns the flag set of a command, printing usage on
+// errors.
+func newFlagSet(usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(strings.Fields(usage)[0], flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: telegramctl %s\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses the flags of a command and checks the number of
+// positional arguments. maxArgs < 0 allows any number.
+func parse(fs *flag.FlagSet, args []string, minArgs, maxArgs int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	if n := fs.NArg(); n < minArgs || (maxArgs >= 0 && n > maxArgs) {
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// labelFlag collects repeated -label key=value flags.
+type labelFlag map[string]string
+
+func (l labelFlag) String() string {
+	pairs := make([]string, 0, len(l))
+	for k, v := range l {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (l labelFlag) Set(s string) error {
+	k, v, ok := strings.Cut(s, "=")
+	if !ok || k == "" {
+		return fmt.Errorf("label must look like key=value, got %q", s)
+	}
+	l[k] = v
+	return nil
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	api "github.com/zen-flo/telegram-service/pkg/api/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeService serves a chat of 250 messages with the IDs 1 to 250 and
+// a login that needs the password "right".
+type fakeService struct {
+	api.UnimplementedTelegramServiceServer
+
+	mu       sync.Mutex
+	apiKeys  []string
+	loggedIn bool
+}
+
+func (f *fakeService) GetHistory(ctx context.Context, req *api.GetHistoryRequest) (*api.GetHistoryResponse, error) {
+	f.mu.Lock()
+	md, _ := metadata.FromIncomingContext(ctx)
+	f.apiKeys = append(f.apiKeys, md.Get("x-api-key")...)
+	f.mu.Unlock()
+
+	const newest = 250
+
+	id := int64(newest)
+	if req.GetOffsetId() > 0 {
+		id = req.GetOffsetId() - 1
+	}
+
+	resp := &api.GetHistoryResponse{}
+	for ; id > 0 && len(resp.Messages) < int(req.GetLimit()); id-- {
+		resp.Messages = append(resp.Messages, &api.HistoryMessage{
+			MessageId: proto.Int64(id),
+			From:      proto.String("user:9"),
+			Text:      proto.String("hello, world"),
+			Date:      proto.Int64(1700000000 + id),
+			Out:       proto.Bool(id%2 == 0),
+		})
+	}
+	return resp, nil
+}
+
+func (f *fakeService) CreateSession(context.Context, *api.CreateSessionRequest) (*api.CreateSessionResponse, error) {
+	return &api.CreateSessionResponse{
+		SessionId: proto.String("s1"),
+		QrCode:    proto.String("tg://login?token=abc"),
+	}, nil
+}
+
+func (f *fakeService) GetSessionStatus(context.Context, *api.GetSessionStatusRequest) (*api.GetSessionStatusResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.loggedIn {
+		return &api.GetSessionStatusResponse{
+			State:            api.SessionState_SESSION_STATE_PENDING.Enum(),
+			PasswordRequired: proto.Bool(true),
+		}, nil
+	}
+	return &api.GetSessionStatusResponse{
+		State: api.SessionState_SESSION_STATE_AUTHORIZED.Enum(),
+		Ready: proto.Bool(true),
+	}, nil
+}
+
+func (f *fakeService) SubmitPassword(ctx context.Context, req *api.SubmitPasswordRequest) (*api.SubmitPasswordResponse, error) {
+	if req.GetPassword() != "right" {
+		return nil, status.Error(codes.InvalidArgument, "invalid password")
+	}
+
+	f.mu.Lock()
+	f.loggedIn = true
+	f.mu.Unlock()
+	return &api.SubmitPasswordResponse{}, nil
+}
+
+// serve starts the fake service and returns its address.
+func serve(t *testing.T, f *fakeService) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := grpc.NewServer()
+	api.RegisterTelegramServiceServer(srv, f)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	return lis.Addr().String()
+}
+
+// runCLI runs telegramctl with its own profiles file and stdin.
+func runCLI(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var out bytes.Buffer
+	args = append([]string{"-config", filepath.Join(t.TempDir(), "config.yaml")}, args...)
+	err := run(ctx, args, strings.NewReader(stdin), &out)
+	return out.String(), err
+}
+
+func TestRun_Usage(t *testing.T) {
+	for _, args := range [][]string{
+		{},
+		{"nope"},
+		{"-o", "yaml", "sessions", "list"},
+		{"send", "s1", "@durov"},
+		{"history", "s1"},
+		{"history", "-limit", "500", "s1", "@durov"},
+		{"export", "-format", "xml", "s1", "@durov"},
+		{"login", "-label", "nolabel"},
+	} {
+		if _, err := runCLI(t, "", args...); !errors.Is(err, errUsage) {
+			t.Errorf("%v: expected a usage error, got %v", args, err)
+		}
+	}
+
+	if _, err := runCLI(t, "", "help"); err != nil {
+		t.Fatalf("help: %v", err)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	t.Setenv("TELEGRAMCTL_PROFILE", "")
+	t.Setenv("TELEGRAMCTL_API_KEY", "")
+
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	ctl := func(args ...string) string {
+		t.Helper()
+
+		var out bytes.Buffer
+		if err := run(context.Background(), append([]string{"-config", config}, args...), strings.NewReader(""), &out); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+		return out.String()
+	}
+
+	ctl("profiles", "set", "-server", "localhost:50051", "-api-key", "k1", "local")
+	ctl("profiles", "set", "-server", "telegram.example.com:443", "-tls", "-api-key", "k2", "prod")
+
+	// the first profile becomes the current one
+	var entries []struct {
+		Name    string `json:"name"`
+		Current bool   `json:"current"`
+		Server  string `json:"server"`
+		APIKey  string `json:"api_key"`
+	}
+	if err := json.Unmarshal([]byte(ctl("-o", "json", "profiles", "list")), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Name != "local" || !entries[0].Current || entries[1].Current {
+		t.Fatalf("unexpected profiles %+v", entries)
+	}
+	if entries[0].APIKey != "" || entries[1].APIKey != "" {
+		t.Fatal("api keys must not be printed")
+	}
+
+	ctl("profiles", "use", "prod")
+
+	opts := options{configFile: config}
+	p, err := opts.resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Server != "telegram.example.com:443" || p.APIKey != "k2" || !p.secure() {
+		t.Fatalf("expected the current profile, got %+v", p)
+	}
+
+	// flags and the environment override the profile
+	t.Setenv("TELEGRAMCTL_PROFILE", "local")
+	t.Setenv("TELEGRAMCTL_API_KEY", "env")
+	if p, _ := opts.resolve(); p.Server != "localhost:50051" || p.APIKey != "env" {
+		t.Fatalf("expected the profile of the environment, got %+v", p)
+	}
+	opts.profile, opts.server, opts.apiKey = "prod", "other:50051", "flag"
+	if p, _ := opts.resolve(); p.Server != "other:50051" || p.APIKey != "flag" || !p.TLS {
+		t.Fatalf("expected the flags to win, got %+v", p)
+	}
+
+	opts.profile = "missing"
+	if _, err := opts.resolve(); err == nil {
+		t.Fatal("expected an error for an unknown profile")
+	}
+}
+
+func TestHistory_JSON(t *testing.T) {
+	f := &fakeService{}
+	addr := serve(t, f)
+
+	out, err := runCLI(t, "", "-server", addr, "-api-key", "k", "-o", "json", "history", "-limit", "3", "-before", "10", "s1", "@durov")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var resp struct {
+		Messages []struct {
+			MessageID string `json:"message_id"`
+			Out       bool   `json:"out"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal([]byte(out), &resp); err != nil {
+		t.Fatalf("invalid json %s: %v", out, err)
+	}
+	if len(resp.Messages) != 3 || resp.Messages[0].MessageID != "9" || resp.Messages[2].MessageID != "7" || !resp.Messages[1].Out {
+		t.Fatalf("unexpected history %s", out)
+	}
+
+	if len(f.apiKeys) != 1 || f.apiKeys[0] != "k" {
+		t.Fatalf("expected the api key to be sent, got %v", f.apiKeys)
+	}
+}
+
+func TestExport(t *testing.T) {
+	addr := serve(t, &fakeService{})
+
+	out, err := runCLI(t, "", "-server", addr, "export", "s1", "@durov")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 250 {
+		t.Fatalf("expected every message across pages, got %d lines", len(lines))
+	}
+	var last struct {
+		MessageID string `json:"message_id"`
+	}
+	if err := json.Unmarshal([]byte(lines[249]), &last); err != nil || last.MessageID != "1" {
+		t.Fatalf("expected the oldest message last, got %s", lines[249])
+	}
+
+	out, err = runCLI(t, "", "-server", addr, "export", "-format", "csv", "-limit", "120", "s1", "@durov")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 121 || records[0][0] != "message_id" || records[1][0] != "250" || records[1][4] != "hello, world" {
+		t.Fatalf("unexpected csv %v", records[:2])
+	}
+}
+
+func TestLogin_PromptsForPassword(t *testing.T) {
+	prev := pollInterval
+	pollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { pollInterval = prev })
+
+	addr := serve(t, &fakeService{})
+
+	out, err := runCLI(t, "wrong\nright\n", "-server", addr, "-o", "json", "login")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the created session, then the final status
+	dec := json.NewDecoder(strings.NewReader(out))
+	var created, st map[string]any
+	if err := dec.Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	if err := dec.Decode(&st); err != nil {
+		t.Fatal(err)
+	}
+	if created["session_id"] != "s1" || st["state"] != "SESSION_STATE_AUTHORIZED" || st["ready"] != true {
+		t.Fatalf("unexpected output %s", out)
+	}
+
+	addr = serve(t, &fakeService{})
+	if _, err := runCLI(t, "wrong\n", "-server", addr, "login"); err == nil {
+		t.Fatal("expected an error when the input ends without the right password")
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	api "github.com/zen-flo/telegram-service/pkg/api/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+func runSend(ctx context.Context, c *cli, args []string) error {
+	fs := newFlagSet("send [-queue] [-idempotency-key k] <session> <peer> <text|->")
+	queue := fs.Bool("queue", false, "enqueue the message and return the job instead of sending right away")
+	key := fs.String("idempotency-key", "", "repeating a key within the idempotency window sends only once")
+	if err := parse(fs, args, 3, -1); err != nil {
+		return err
+	}
+
+	session, peer := fs.Arg(0), fs.Arg(1)
+	text := strings.Join(fs.Args()[2:], " ")
+	if text == "-" {
+		b, err := io.ReadAll(c.in)
+		if err != nil {
+			return err
+		}
+		text = strings.TrimRight(string(b), "\n")
+	}
+	if text == "" {
+		return errors.New("the text is empty")
+	}
+
+	client, err := c.dial()
+	if err != nil {
+		return err
+	}
+
+	if *queue {
+		resp, err := client.EnqueueMessage(ctx, &api.EnqueueMessageRequest{
+			SessionId:      proto.String(session),
+			Peer:           proto.String(peer),
+			Text:           proto.String(text),
+			IdempotencyKey: optional(*key),
+		})
+		if err != nil {
+			return err
+		}
+		if c.json() {
+			return c.printMessage(resp)
+		}
+		fmt.Fprintf(c.out, "queued job %s\n", resp.GetJobId())
+		return nil
+	}
+
+	resp, err := client.SendMessage(ctx, &api.SendMessageRequest{
+		SessionId:      proto.String(session),
+		Peer:           proto.String(peer),
+		Text:           proto.String(text),
+		IdempotencyKey: optional(*key),
+	})
+	if err != nil {
+		return err
+	}
+	if c.json() {
+		return c.printMessage(resp)
+	}
+	fmt.Fprintf(c.out, "sent message %d to %s\n", resp.GetMessageId(), resp.GetPeer())
+	return nil
+}
+
+// runTail streams incoming messages until interrupted. A single session
+// without labels uses SubscribeMessages, otherwise SubscribeAll filters
+// by sessions and labels. Like the streams, only new messages are
+// delivered.
+func runTail(ctx context.Context, c *cli, args []string) error {
+	fs := newFlagSet("tail [-label k=v]... [-from sender] [session]...")
+	selector := labelFlag{}
+	fs.Var(selector, "label", "only sessions with this label, repeatable")
+	from := fs.String("from", "", "only messages from this sender")
+	if err := parse(fs, args, 0, -1); err != nil {
+		return err
+	}
+
+	client, err := c.dial()
+	if err != nil {
+		return err
+	}
+
+	var stream grpc.ServerStreamingClient[api.MessageUpdate]
+	if fs.NArg() == 1 && len(selector) == 0 {
+		stream, err = client.SubscribeMessages(ctx, &api.SubscribeMessagesRequest{
+			SessionId: proto.String(fs.Arg(0)),
+		})
+	} else {
+		stream, err = client.SubscribeAll(ctx, &api.SubscribeAllRequest{
+			SessionIds:    fs.Args(),
+			LabelSelector: selector,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) || ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if *from != "" && msg.GetFrom() != *from {
+			continue
+		}
+
+		if c.json() {
+			data, err := jsonLine.Marshal(msg)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "%s\n", data)
+			continue
+		}
+		fmt.Fprintf(c.out, "%s  %s  %s: %s\n",
+			formatTime(msg.GetTimestamp()),
+			msg.GetSessionId(),
+			msg.GetFrom(),
+			msg.GetText(),
+		)
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultConfigHint = "$XDG_CONFIG_HOME/telegramctl/config.yaml"
+
+// profile is how to reach and authenticate to a server.
+type profile struct {
+	Server string `yaml:"server" json:"server"`
+	APIKey string `yaml:"api_key,omitempty" json:"-"`
+
+	// TLS is implied by CAFile and CertFile. CertFile and KeyFile are
+	// the client certificate for mTLS.
+	TLS        bool   `yaml:"tls,omitempty" json:"tls,omitempty"`
+	CAFile     string `yaml:"ca_file,omitempty" json:"ca_file,omitempty"`
+	CertFile   string `yaml:"cert_file,omitempty" json:"cert_file,omitempty"`
+	KeyFile    string `yaml:"key_file,omitempty" json:"key_file,omitempty"`
+	ServerName string `yaml:"server_name,omitempty" json:"server_name,omitempty"`
+}
+
+// profiles is the profiles file.
+type profiles struct {
+	Current  string             `yaml:"current,omitempty"`
+	Profiles map[string]profile `yaml:"profiles"`
+}
+
+// options are the global flags.
+type options struct {
+	configFile string
+	profile    string
+	server     string
+	apiKey     string
+	output     string
+}
+
+func (o options) path() (string, error) {
+	if o.configFile != "" {
+		return o.configFile, nil
+	}
+	if path := os.Getenv("TELEGRAMCTL_CONFIG"); path != "" {
+		return path, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "telegramctl", "config.yaml"), nil
+}
+
+// loadProfiles reads the profiles file, which may not exist.
+func loadProfiles(path string) (*profiles, error) {
+	p := &profiles{Profiles: make(map[string]profile)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("invalid profiles file %s: %w", path, err)
+	}
+	if p.Profiles == nil {
+		p.Profiles = make(map[string]profile)
+	}
+	return p, nil
+}
+
+func (p *profiles) save(path string) error {
+	data, err := yaml.Marshal(p)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	// The file holds API keys.
+	return os.WriteFile(path, data, 0o600)
+}
+
+// resolve returns the profile selected by the flags, the environment
+// or the profiles file, with the flag and environment overrides
+// applied. Without any profile, the server is localhost:50051.
+func (o options) resolve() (profile, error) {
+	path, err := o.path()
+	if err != nil {
+		return profile{}, err
+	}
+	all, err := loadProfiles(path)
+	if err != nil {
+		return profile{}, err
+	}
+
+	name := o.profile
+	if name == "" {
+		name = os.Getenv("TELEGRAMCTL_PROFILE")
+	}
+	if name == "" {
+		name = all.Current
+	}
+
+	var p profile
+	if name != "" {
+		var ok bool
+		if p, ok = all.Profiles[name]; !ok {
+			return profile{}, fmt.Errorf("profile %q not found in %s", name, path)
+		}
+	}
+
+	if o.server != "" {
+		p.Server = o.server
+	}
+	if p.Server == "" {
+		p.Server = "localhost:50051"
+	}
+	if key := os.Getenv("TELEGRAMCTL_API_KEY"); key != "" {
+		p.APIKey = key
+	}
+	if o.apiKey != "" {
+		p.APIKey = o.apiKey
+	}
+	return p, nil
+}
+
+func runProfiles(ctx context.Context, c *cli, args []string) error {
+	if len(args) == 0 {
+		return usageError("profiles <list|use|set> [flags]")
+	}
+
+	path, err := c.opts.path()
+	if err != nil {
+		return err
+	}
+	all, err := loadProfiles(path)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		fs := newFlagSet("profiles list")
+		if err := parse(fs, args[1:], 0, 0); err != nil {
+			return err
+		}
+		return c.printProfiles(all)
+
+	case "use":
+		fs := newFlagSet("profiles use <name>")
+		if err := parse(fs, args[1:], 1, 1); err != nil {
+			return err
+		}
+		name := fs.Arg(0)
+		if _, ok := all.Profiles[name]; !ok {
+			return fmt.Errorf("profile %q not found in %s", name, path)
+		}
+		all.Current = name
+		return all.save(path)
+
+	case "set":
+		fs := newFlagSet("profiles set [flags] <name>")
+		var p profile
+		fs.StringVar(&p.Server, "server", "", "server address, host:port")
+		fs.StringVar(&p.APIKey, "api-key", "", "API key")
+		fs.BoolVar(&p.TLS, "tls", false, "connect over TLS")
+		fs.StringVar(&p.CAFile, "ca-file", "", "CA of the server certificate, PEM")
+		fs.StringVar(&p.CertFile, "cert-file", "", "client certificate for mTLS, PEM")
+		fs.StringVar(&p.KeyFile, "key-file", "", "key of the client certificate, PEM")
+		fs.StringVar(&p.ServerName, "server-name", "", "name to verify the server certificate against")
+		if err := parse(fs, args[1:], 1, 1); err != nil {
+			return err
+		}
+		if p.Server == "" {
+			return errors.New("-server is required")
+		}
+		if (p.CertFile == "") != (p.KeyFile == "") {
+			return errors.New("-cert-file and -key-file must be set together")
+		}
+
+		name := fs.Arg(0)
+		all.Profiles[name] = p
+		if all.Current == "" {
+			all.Current = name
+		}
+		return all.save(path)
+	}
+
+	return usageError("profiles <list|use|set> [flags]")
+}
+
+func (c *cli) printProfiles(all *profiles) error {
+	names := sortedKeys(all.Profiles)
+
+	if c.json() {
+		type entry struct {
+			Name    string `json:"name"`
+			Current bool   `json:"current"`
+			profile
+		}
+		entries := make([]entry, 0, len(names))
+		for _, name := range names {
+			entries = append(entries, entry{name, name == all.Current, all.Profiles[name]})
+		}
+		return c.printValue(entries)
+	}
+
+	tw := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CURRENT\tNAME\tSERVER\tTLS\tAPI KEY")
+	for _, name := range names {
+		p := all.Profiles[name]
+		current := ""
+		if name == all.Current {
+			current = "*"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%t\n", current, name, p.Server, p.secure(), p.APIKey != "")
+	}
+	return tw.Flush()
+}
+
+// usageError prints the usage of a command with subcommands.
+func usageError(usage string) error {
+	fmt.Fprintf(os.Stderr, "Usage: telegramctl %s\n", usage)
+	return errUsage
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	api "github.com/zen-flo/telegram-service/pkg/api/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+func runSessions(ctx context.Context, c *cli, args []string) error {
+	const usage = "sessions <create|list|status|delete|resume> [flags]"
+	if len(args) == 0 {
+		return usageError(usage)
+	}
+
+	switch args[0] {
+	case "create":
+		return sessionsCreate(ctx, c, args[1:])
+	case "list":
+		return sessionsList(ctx, c, args[1:])
+	case "status":
+		return sessionsStatus(ctx, c, args[1:])
+	case "delete":
+		return sessionsDelete(ctx, c, args[1:])
+	case "resume":
+		return sessionsResume(ctx, c, args[1:])
+	}
+	return usageError(usage)
+}
+
+func sessionsCreate(ctx context.Context, c *cli, args []string) error {
+	fs := newFlagSet("sessions create [-label k=v]...")
+	labels := labelFlag{}
+	fs.Var(labels, "label", "label of the session, repeatable")
+	if err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	client, err := c.dial()
+	if err != nil {
+		return err
+	}
+	resp, err := client.CreateSession(ctx, &api.CreateSessionRequest{Labels: labels})
+	if err != nil {
+		return err
+	}
+
+	if c.json() {
+		return c.printMessage(resp)
+	}
+	fmt.Fprintf(c.out, "session: %s\nqr code: %s\n", resp.GetSessionId(), resp.GetQrCode())
+	return nil
+}
+
+func sessionsList(ctx context.Context, c *cli, args []string) error {
+	fs := newFlagSet("sessions list [-label k=v]...")
+	selector := labelFlag{}
+	fs.Var(selector, "label", "only sessions with this label, repeatable")
+	if err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	client, err := c.dial()
+	if err != nil {
+		return err
+	}
+	resp, err := client.ListSessions(ctx, &api.ListSessionsRequest{LabelSelector: selector})
+	if err != nil {
+		return err
+	}
+
+	if c.json() {
+		return c.printMessage(resp)
+	}
+
+	tw := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SESSION\tTENANT\tSTATE\tCONNECTED\tCREATED\tLABELS")
+	for _, s := range resp.GetSessions() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%s\t%s\n",
+			s.GetSessionId(),
+			orDash(s.GetTenant()),
+			stateName(s.GetState()),
+			s.GetConnected(),
+			formatTime(s.GetCreatedAt()),
+			orDash(labelFlag(s.GetLabels()).String()),
+		)
+	}
+	return tw.Flush()
+}
+
+func sessionsStatus(ctx context.Context, c *cli, args []string) error {
+	fs := newFlagSet("sessions status <session>")
+	if err := parse(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	client, err := c.dial()
+	if err != nil {
+		return err
+	}
+	resp, err := client.GetSessionStatus(ctx, &api.GetSessionStatusRequest{SessionId: proto.String(fs.Arg(0))})
+	if err != nil {
+		return err
+	}
+
+	if c.json() {
+		return c.printMessage(resp)
+	}
+
+	tw := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "state:\t%s\n", stateName(resp.GetState()))
+	fmt.Fprintf(tw, "ready:\t%t\n", resp.GetReady())
+	fmt.Fprintf(tw, "connected:\t%t\n", resp.GetConnected())
+	fmt.Fprintf(tw, "created:\t%s\n", formatTime(resp.GetCreatedAt()))
+	fmt.Fprintf(tw, "last activity:\t%s\n", formatTime(resp.GetLastActivityAt()))
+	fmt.Fprintf(tw, "restarts:\t%d\n", resp.GetRestartCount())
+	if resp.GetLastError() != "" {
+		fmt.Fprintf(tw, "last error:\t%s (%s)\n", resp.GetLastError(), formatTime(resp.GetLastErrorAt()))
+	}
+	if resp.GetLogoutReason() != "" {
+		fmt.Fprintf(tw, "logout reason:\t%s\n", resp.GetLogoutReason())
+	}
+	return tw.Flush()
+}
+
+func sessionsDelete(ctx context.Context, c *cli, args []string) error {
+	fs := newFlagSet("sessions delete [-mode logout|detach|purge-local] <session>")
+	mode := fs.String("mode", "logout", "logout, detach (keep the auth key for resume) or purge-local")
+	if err := parse(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	name := "DELETE_MODE_" + strings.ToUpper(strings.ReplaceAll(*mode, "-", "_"))
+	value, ok := api.DeleteMode_value[name]
+	if !ok || value == int32(api.DeleteMode_DELETE_MODE_UNSPECIFIED) {
+		return fmt.Errorf("unknown mode %q, use logout, detach or purge-local", *mode)
+	}
+
+	client, err := c.dial()
+	if err != nil {
+		return err
+	}
+	resp, err := client.DeleteSession(ctx, &api.DeleteSessionRequest{
+		SessionId: proto.String(fs.Arg(0)),
+		Mode:      api.DeleteMode(value).Enum(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if c.json() {
+		return c.printMessage(resp)
+	}
+	fmt.Fprintf(c.out, "deleted %s\n", fs.Arg(0))
+	return nil
+}
+
+func sessionsResume(ctx context.Context, c *cli, args []string) error {
+	fs := newFlagSet("sessions resume <session>")
+	if err := parse(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	client, err := c.dial()
+	if err != nil {
+		return err
+	}
+	resp, err := client.ResumeSession(ctx, &api.ResumeSessionRequest{SessionId: proto.String(fs.Arg(0))})
+	if err != nil {
+		return err
+	}
+
+	if c.json() {
+		return c.printMessage(resp)
+	}
+	fmt.Fprintf(c.out, "resumed %s: %s\n", fs.Arg(0), stateName(resp.GetState()))
+	return nil
+}
+
+func runStatus(ctx context.Context, c *cli, args []string) error {
+	fs := newFlagSet("status [-tenant t]")
+	tenant := fs.String("tenant", "", "tenant to report usage for, for administrator keys")
+	if err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	client, err := c.dial()
+	if err != nil {
+		return err
+	}
+	resp, err := client.GetServiceStatus(ctx, &api.GetServiceStatusRequest{Tenant: optional(*tenant)})
+	if err != nil {
+		return err
+	}
+
+	if c.json() {
+		return c.printMessage(resp)
+	}
+
+	tw := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "sessions:\t%s\n", usageOf(resp.GetSessions(), resp.GetMaxSessions()))
+	if resp.GetTenant() != "" {
+		fmt.Fprintf(tw, "tenant %s:\t%s\n", resp.GetTenant(), usageOf(resp.GetTenantSessions(), resp.GetMaxTenantSessions()))
+	}
+	return tw.Flush()
+}
+
+// usageOf formats a count against a limit, zero meaning no limit.
+func usageOf(n, limit int32) string {
+	if limit == 0 {
+		return fmt.Sprintf("%d (no limit)", n)
+	}
+	return fmt.Sprintf("%d/%d", n, limit)
+}
+
+// stateName returns the short name of a session state, e.g. AUTHORIZED.
+func stateName(s api.SessionState) string {
+	return strings.TrimPrefix(s.String(), "SESSION_STATE_")
+}
+
+// optional returns nil for an empty flag, leaving the field unset.
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	return slices.Sorted(maps.Keys(m))
+}
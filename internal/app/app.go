@@ -2,8 +2,23 @@ package app
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
+	"github.com/zen-flo/telegram-service/internal/auth"
 	"github.com/zen-flo/telegram-service/internal/broker"
+	"github.com/zen-flo/telegram-service/internal/certs"
+	"github.com/zen-flo/telegram-service/internal/gateway"
+	"github.com/zen-flo/telegram-service/internal/metrics"
+	"github.com/zen-flo/telegram-service/internal/outbox"
 	"github.com/zen-flo/telegram-service/internal/session"
+	"github.com/zen-flo/telegram-service/internal/sink"
+	"github.com/zen-flo/telegram-service/internal/tracing"
+	"github.com/zen-flo/telegram-service/internal/webhook"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/zen-flo/telegram-service/internal/config"
 	"github.com/zen-flo/telegram-service/internal/grpc"
@@ -11,16 +26,37 @@ import (
 )
 
 type App struct {
-	cfg    *config.Config
-	logger *zap.Logger
-	server *grpc.Server
+	cfg      *config.Config
+	logger   *zap.Logger
+	level    zap.AtomicLevel
+	server   *grpc.Server
+	gateway  *gateway.Server // nil when HTTP_PORT is 0
+	metrics  *metrics.Server // nil when METRICS_PORT is 0
+	handler  *grpc.TelegramHandler
+	sessions *session.Manager
+	keys     *auth.KeyStore
+	certs    *certs.Reloader // nil without TLS
+	webhooks *webhook.Service
+	sink     *sink.Forwarder // nil when no external sink is configured
+
+	shutdownTracing func(context.Context) error
 }
 
 func New(
 	cfg *config.Config,
 	logger *zap.Logger,
+	level zap.AtomicLevel,
 ) (*App, error) {
 
+	// Set up first, so spans of the components go to the exporter.
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    cfg.TracingExporter,
+		SampleRatio: cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up tracing: %w", err)
+	}
+
 	dispatcher := broker.NewDispatcher()
 
 	sessionManager := session.NewManager(
@@ -28,26 +64,390 @@ func New(
 		cfg.TelegramAPIHash,
 		logger,
 		dispatcher,
+		session.Options{
+			Outbox: outbox.Options{
+				MaxAttempts:  cfg.OutboxMaxAttempts,
+				MaxFloodWait: cfg.OutboxMaxFloodWait,
+			},
+			DeleteDrainTimeout:    cfg.OutboxDrainTimeout,
+			RateLimit:             cfg.RateLimit,
+			RejectOverLimit:       cfg.RateLimitReject,
+			IdempotencyWindow:     cfg.IdempotencyWindow,
+			DeleteRevokedAuthKeys: cfg.DeleteRevokedAuthKeys,
+			Supervisor: session.SupervisorOptions{
+				MaxRestarts:    cfg.ClientMaxRestarts,
+				InitialBackoff: cfg.ClientRestartBackoff,
+				MaxBackoff:     cfg.ClientRestartMaxBackoff,
+			},
+			Expiry: session.ExpiryOptions{
+				PendingTimeout: cfg.SessionPendingTimeout,
+				TTL:            cfg.SessionTTL,
+				IdleTimeout:    cfg.SessionIdleTimeout,
+			},
+			Limits: session.Limits{
+				MaxSessions:          cfg.MaxSessions,
+				MaxSessionsPerTenant: cfg.MaxSessionsPerTenant,
+				TenantMaxSessions:    cfg.TenantMaxSessions,
+			},
+			TenantLabels: cfg.TenantLabels,
+		},
+	)
+
+	webhooks := webhook.NewService(
+		dispatcher,
+		func(sessionID string) (map[string]string, bool) {
+			s, err := sessionManager.Get(sessionID)
+			if err != nil {
+				return nil, false
+			}
+			return s.Labels(), true
+		},
+		webhook.NewFileDeadLetterStore(cfg.WebhookDeadLetterFile),
+		logger.Named("webhook"),
+		webhook.Options{
+			MaxAttempts:     cfg.WebhookMaxAttempts,
+			AllowedNetworks: cfg.WebhookAllowedNetworks,
+		},
 	)
 
+	if err := webhooks.Configure(cfg.Webhooks); err != nil {
+		return nil, fmt.Errorf("failed to configure webhooks: %w", err)
+	}
+
+	forwarder, err := newSinkForwarder(cfg, dispatcher, logger)
+	if err != nil {
+		return nil, err
+	}
+
 	telegramHandler := grpc.NewTelegramHandler(
 		sessionManager,
+		webhooks,
 		logger,
 	)
 
+	keys, err := auth.NewKeyStore(cfg.APIKeys, cfg.APIKeysFile, logger.Named("auth"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load api keys: %w", err)
+	}
+
+	certReloader, err := newCertReloader(cfg, logger.Named("tls"))
+	if err != nil {
+		return nil, err
+	}
+
+	var tlsConfig *tls.Config
+	if certReloader != nil {
+		tlsConfig = certReloader.ServerConfig()
+	}
+
+	authorize := grpc.NewAuthorizer(keys, logger.Named("auth"))
+
 	server := grpc.NewServer(
 		cfg.GRPCPort,
 		logger,
 		telegramHandler,
+		grpc.ServerOptions{
+			Keys: keys,
+			TLS:  tlsConfig,
+			Health: grpc.NewHealthChecker(
+				sessionManager,
+				logger.Named("health"),
+				grpc.HealthOptions{
+					MinConnected: cfg.HealthMinConnected,
+					Authorize:    authorize,
+				},
+			),
+		},
 	)
 
+	var gatewayServer *gateway.Server
+	if cfg.HTTPPort != 0 {
+		var gatewayTLS *tls.Config
+		if certReloader != nil {
+			gatewayTLS = certReloader.HTTPServerConfig()
+		}
+
+		gatewayServer = gateway.NewServer(
+			cfg.HTTPPort,
+			gateway.New(
+				telegramHandler,
+				authorize,
+				logger.Named("gateway"),
+			),
+			logger.Named("gateway"),
+			gateway.ServerOptions{
+				TLS:         gatewayTLS,
+				CORSOrigins: cfg.HTTPCORSOrigins,
+			},
+		)
+	}
+
+	var metricsServer *metrics.Server
+	if cfg.MetricsPort != 0 {
+		metricsServer = metrics.NewServer(
+			cfg.MetricsPort,
+			metrics.NewRegistry(metrics.Sources{
+				SessionStates: sessionManager.StateCounts,
+				Dispatcher:    dispatcher.Stats,
+			}),
+			logger.Named("metrics"),
+		)
+	}
+
 	return &App{
-		cfg:    cfg,
-		logger: logger,
-		server: server,
+		cfg:      cfg,
+		logger:   logger,
+		level:    level,
+		server:   server,
+		gateway:  gatewayServer,
+		metrics:  metricsServer,
+		handler:  telegramHandler,
+		sessions: sessionManager,
+		keys:     keys,
+		certs:    certReloader,
+		webhooks: webhooks,
+		sink:     forwarder,
+
+		shutdownTracing: shutdownTracing,
 	}, nil
 }
 
+// Run serves until ctx is cancelled, then shuts down gracefully.
 func (a *App) Run(ctx context.Context) error {
-	return a.server.Start(ctx)
+	// Background services outlive ctx, they are drained by shutdown.
+	runCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go a.webhooks.Run(runCtx)
+	if a.sink != nil {
+		go a.sink.Run(runCtx)
+	}
+
+	go a.keys.Watch(ctx, a.cfg.APIKeysReloadInterval)
+	if a.certs != nil {
+		go a.certs.Watch(ctx, a.cfg.TLSReloadInterval)
+	}
+
+	go a.watchConfig(ctx)
+
+	// The reaper stops with ctx, before sessions are shut down.
+	go a.sessions.RunReaper(ctx)
+
+	serveErr := make(chan error, 3)
+	go func() {
+		serveErr <- a.server.Start()
+	}()
+	if a.gateway != nil {
+		go func() {
+			if err := a.gateway.Start(); err != nil {
+				serveErr <- fmt.Errorf("http gateway: %w", err)
+			}
+		}()
+	}
+	if a.metrics != nil {
+		go func() {
+			if err := a.metrics.Start(); err != nil {
+				serveErr <- fmt.Errorf("metrics server: %w", err)
+			}
+		}()
+	}
+
+	var err error
+	select {
+	case err = <-serveErr:
+		// probes see the failure while the process shuts down
+		a.server.Fail(err)
+	case <-ctx.Done():
+	}
+
+	a.shutdown()
+	return err
+}
+
+// shutdown stops accepting RPCs, drains outbound queues, closes the
+// Telegram connections without logging out and flushes the event
+// sinks, all within cfg.ShutdownTimeout.
+func (a *App) shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.ShutdownTimeout)
+	defer cancel()
+
+	a.logger.Info("shutting down", zap.Duration("timeout", a.cfg.ShutdownTimeout))
+
+	var servers sync.WaitGroup
+	servers.Go(func() {
+		a.server.Shutdown(ctx)
+	})
+	if a.gateway != nil {
+		servers.Go(func() {
+			a.gateway.Shutdown(ctx)
+		})
+	}
+	// Ends the streams, which would keep the servers running.
+	a.handler.Close()
+	servers.Wait()
+
+	a.sessions.Shutdown(ctx)
+
+	a.webhooks.Shutdown(ctx)
+
+	if a.sink != nil {
+		a.sink.Shutdown(ctx)
+		if err := a.sink.Close(); err != nil {
+			a.logger.Warn("failed to close event sink", zap.Error(err))
+		}
+	}
+
+	// Metrics are served to the end, so the drain can be observed.
+	if a.metrics != nil {
+		a.metrics.Shutdown(ctx)
+	}
+
+	// Flush the spans of the shutdown itself.
+	if err := a.shutdownTracing(ctx); err != nil {
+		a.logger.Warn("failed to flush traces", zap.Error(err))
+	}
+
+	if ctx.Err() != nil {
+		a.logger.Warn("shutdown timed out")
+		return
+	}
+	a.logger.Info("shutdown complete")
+}
+
+// watchConfig reloads the configuration on SIGHUP and when the file
+// changes, until ctx is cancelled.
+func (a *App) watchConfig(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var (
+		tick    <-chan time.Time
+		modTime time.Time
+		size    int64
+	)
+	if a.cfg.File != "" {
+		ticker := time.NewTicker(a.cfg.ReloadInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+
+		if info, err := os.Stat(a.cfg.File); err == nil {
+			modTime, size = info.ModTime(), info.Size()
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		case <-tick:
+			info, err := os.Stat(a.cfg.File)
+			if err != nil {
+				a.logger.Warn("failed to stat config file", zap.String("path", a.cfg.File), zap.Error(err))
+				continue
+			}
+			if info.ModTime().Equal(modTime) && info.Size() == size {
+				continue
+			}
+			modTime, size = info.ModTime(), info.Size()
+		}
+
+		a.reload()
+	}
+}
+
+// reload applies the log level, rate limits and webhooks of the
+// current configuration. An invalid configuration is not applied.
+func (a *App) reload() {
+	cfg, err := config.Load()
+	if err != nil {
+		a.logger.Warn("failed to reload config, keeping the current settings", zap.Error(err))
+		return
+	}
+
+	if err := a.webhooks.Configure(cfg.Webhooks); err != nil {
+		a.logger.Warn("failed to reload webhooks, keeping the current ones", zap.Error(err))
+		return
+	}
+	a.sessions.Limiter().Update(cfg.RateLimit)
+	a.level.SetLevel(cfg.LogLevel)
+
+	if a.cfg.NeedsRestart(cfg) {
+		a.logger.Warn("config reloaded, settings other than the log level, rate limits and webhooks apply after a restart")
+		return
+	}
+	a.logger.Info("config reloaded")
+}
+
+// newCertReloader returns the certificates of the gRPC listener, or nil
+// when TLS is not configured.
+func newCertReloader(cfg *config.Config, logger *zap.Logger) (*certs.Reloader, error) {
+	if cfg.TLSSelfSigned {
+		cert, err := certs.SelfSigned()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate self-signed certificate: %w", err)
+		}
+		logger.Warn("serving a self-signed certificate, for development only",
+			zap.String("sha256", certs.Fingerprint(cert)),
+		)
+		return certs.NewStatic(cert, logger), nil
+	}
+
+	if cfg.TLSCertFile == "" {
+		return nil, nil
+	}
+
+	reloader, err := certs.NewReloader(certs.Options{
+		CertFile:     cfg.TLSCertFile,
+		KeyFile:      cfg.TLSKeyFile,
+		ClientCAFile: cfg.TLSClientCAFile,
+		ClientAuth:   certs.ClientAuth(cfg.TLSClientAuth),
+	}, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tls certificates: %w", err)
+	}
+	return reloader, nil
+}
+
+func newSinkForwarder(
+	cfg *config.Config,
+	dispatcher *broker.Dispatcher,
+	logger *zap.Logger,
+) (*sink.Forwarder, error) {
+
+	var (
+		publisher sink.Publisher
+		err       error
+	)
+
+	switch cfg.SinkType {
+	case "":
+		return nil, nil
+	case "nats":
+		publisher, err = sink.NewNATSPublisher(cfg.SinkURL)
+	case "redis":
+		publisher, err = sink.NewRedisStreamsPublisher(cfg.SinkURL, cfg.SinkRedisMaxLen)
+	default:
+		return nil, fmt.Errorf("unknown sink type %q", cfg.SinkType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s sink: %w", cfg.SinkType, err)
+	}
+
+	buffer, err := sink.NewDiskBuffer(cfg.SinkBufferFile, cfg.SinkBufferSize)
+	if err != nil {
+		_ = publisher.Close()
+		return nil, fmt.Errorf("failed to open sink buffer: %w", err)
+	}
+
+	return sink.NewForwarder(
+		dispatcher,
+		publisher,
+		buffer,
+		logger.Named("sink"),
+		sink.ForwarderOptions{
+			SubjectTemplate: cfg.SinkSubject,
+		},
+	), nil
 }
@@ -0,0 +1,294 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNoCredentials = errors.New("missing api key")
+	ErrInvalidKey    = errors.New("invalid api key")
+)
+
+type Scope string
+
+const (
+	ScopeSessionsCreate Scope = "sessions:create"
+	ScopeSessionsDelete Scope = "sessions:delete"
+	ScopeSessionsRead   Scope = "sessions:read"
+	ScopeMessagesSend   Scope = "messages:send"
+	ScopeMessagesRead   Scope = "messages:read"
+	ScopeWebhooks       Scope = "webhooks:manage"
+
+	// ScopeAll grants every scope.
+	ScopeAll Scope = "*"
+)
+
+var knownScopes = []Scope{
+	ScopeSessionsCreate,
+	ScopeSessionsDelete,
+	ScopeSessionsRead,
+	ScopeMessagesSend,
+	ScopeMessagesRead,
+	ScopeWebhooks,
+	ScopeAll,
+}
+
+// SubjectPrefix marks a Key whose Secret is not an API key but the
+// common name of a client certificate, e.g. "subject:billing.internal".
+const SubjectPrefix = "subject:"
+
+// Key is an API key and the scopes it grants.
+type Key struct {
+	// Name identifies the caller in logs, the key itself is never logged.
+	Name   string
+	Secret string
+	Scopes []Scope
+	// Tenant owns the sessions created with the key. Defaults to Name.
+	Tenant string
+}
+
+// Principal is the authenticated caller.
+type Principal struct {
+	Name   string
+	Scopes []Scope
+	Tenant string
+}
+
+// Allowed reports whether the principal was granted scope.
+func (p Principal) Allowed(scope Scope) bool {
+	return slices.Contains(p.Scopes, scope) || slices.Contains(p.Scopes, ScopeAll)
+}
+
+type principalKey struct{}
+
+func NewContext(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal authenticated for the request.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// ParseKeys parses API keys, one per line or separated by ";":
+//
+//	<name> <key> <scope>[,<scope>...] [<tenant>]
+//
+// Empty lines and lines starting with # are skipped.
+func ParseKeys(r io.Reader) ([]Key, error) {
+	var keys []Key
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		for entry := range strings.SplitSeq(scanner.Text(), ";") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" || strings.HasPrefix(entry, "#") {
+				continue
+			}
+
+			key, err := parseKey(entry)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			keys = append(keys, key)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func parseKey(entry string) (Key, error) {
+	fields := strings.Fields(entry)
+	if len(fields) != 3 && len(fields) != 4 {
+		return Key{}, errors.New("expected <name> <key> <scopes> [<tenant>]")
+	}
+
+	key := Key{Name: fields[0], Secret: fields[1], Tenant: fields[0]}
+	if len(fields) == 4 {
+		key.Tenant = fields[3]
+	}
+	for s := range strings.SplitSeq(fields[2], ",") {
+		scope := Scope(strings.TrimSpace(s))
+		if !slices.Contains(knownScopes, scope) {
+			return Key{}, fmt.Errorf("key %q: unknown scope %q", key.Name, scope)
+		}
+		key.Scopes = append(key.Scopes, scope)
+	}
+	return key, nil
+}
+
+// KeyStore authenticates API keys from the configuration and an
+// optional key file, which is reloaded when it changes.
+type KeyStore struct {
+	static []Key
+	path   string
+	logger *zap.Logger
+
+	mu       sync.RWMutex
+	keys     map[[32]byte]Principal
+	subjects map[string]Principal
+	modTime  time.Time
+	size     int64
+}
+
+// NewKeyStore returns a store of the static keys and the keys of the
+// file at path, if set.
+func NewKeyStore(static []Key, path string, logger *zap.Logger) (*KeyStore, error) {
+	s := &KeyStore{
+		static: static,
+		path:   path,
+		logger: logger,
+	}
+
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Enabled reports whether any key source is configured. Without one,
+// requests are not authenticated.
+func (s *KeyStore) Enabled() bool {
+	return len(s.static) > 0 || s.path != ""
+}
+
+// Authenticate returns the principal of the key.
+func (s *KeyStore) Authenticate(secret string) (Principal, error) {
+	if secret == "" {
+		return Principal{}, ErrNoCredentials
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.keys[sha256.Sum256([]byte(secret))]
+	if !ok {
+		return Principal{}, ErrInvalidKey
+	}
+	return p, nil
+}
+
+// AuthenticateSubject returns the principal of a verified client
+// certificate by its subject common name.
+func (s *KeyStore) AuthenticateSubject(commonName string) (Principal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.subjects[commonName]
+	if !ok || commonName == "" {
+		return Principal{}, ErrInvalidKey
+	}
+	return p, nil
+}
+
+// Reload re-reads the key file. On error the current keys are kept.
+func (s *KeyStore) Reload() error {
+	keys := slices.Clone(s.static)
+
+	var (
+		modTime time.Time
+		size    int64
+	)
+	if s.path != "" {
+		f, err := os.Open(s.path)
+		if err != nil {
+			return fmt.Errorf("open key file: %w", err)
+		}
+		defer f.Close()
+
+		info, err := f.Stat()
+		if err != nil {
+			return fmt.Errorf("stat key file: %w", err)
+		}
+		modTime, size = info.ModTime(), info.Size()
+
+		fileKeys, err := ParseKeys(f)
+		if err != nil {
+			return fmt.Errorf("parse key file: %w", err)
+		}
+		keys = append(keys, fileKeys...)
+	}
+
+	index := make(map[[32]byte]Principal, len(keys))
+	subjects := make(map[string]Principal)
+	for _, k := range keys {
+		tenant := k.Tenant
+		if tenant == "" {
+			tenant = k.Name
+		}
+		p := Principal{Name: k.Name, Scopes: k.Scopes, Tenant: tenant}
+
+		if subject, ok := strings.CutPrefix(k.Secret, SubjectPrefix); ok {
+			subjects[subject] = p
+			continue
+		}
+		index[sha256.Sum256([]byte(k.Secret))] = p
+	}
+
+	s.mu.Lock()
+	s.keys = index
+	s.subjects = subjects
+	s.modTime, s.size = modTime, size
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Watch reloads the key file when its modification time or size
+// changes, checking every interval until ctx is cancelled.
+func (s *KeyStore) Watch(ctx context.Context, interval time.Duration) {
+	if s.path == "" {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(s.path)
+		if err != nil {
+			s.logger.Warn("failed to stat key file", zap.String("path", s.path), zap.Error(err))
+			continue
+		}
+
+		s.mu.RLock()
+		changed := !info.ModTime().Equal(s.modTime) || info.Size() != s.size
+		s.mu.RUnlock()
+		if !changed {
+			continue
+		}
+
+		if err := s.Reload(); err != nil {
+			s.logger.Warn("failed to reload key file, keeping the current keys",
+				zap.String("path", s.path),
+				zap.Error(err),
+			)
+			continue
+		}
+		s.logger.Info("api keys reloaded", zap.String("path", s.path))
+	}
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestParseKeys(t *testing.T) {
+	keys, err := ParseKeys(strings.NewReader(`
+# ci pipeline
+ci secret1 messages:send,messages:read
+admin secret2 *; bot secret3 sessions:create acme
+`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(keys) != 3 {
+		t.Fatalf("expected 3 keys, got %d", len(keys))
+	}
+	if keys[0].Name != "ci" || len(keys[0].Scopes) != 2 || keys[0].Tenant != "ci" {
+		t.Fatalf("unexpected keys: %+v", keys)
+	}
+	if keys[2].Name != "bot" || keys[2].Tenant != "acme" {
+		t.Fatalf("unexpected keys: %+v", keys)
+	}
+
+	for _, bad := range []string{"ci secret1", "ci secret1 messages:delete"} {
+		if _, err := ParseKeys(strings.NewReader(bad)); err == nil {
+			t.Fatalf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestKeyStore_AuthenticateAndReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys")
+	if err := os.WriteFile(path, []byte("file old messages:read\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewKeyStore([]Key{{Name: "env", Secret: "static", Scopes: []Scope{ScopeAll}}}, path, zap.NewNop())
+	if err != nil {
+		t.Fatalf("new key store: %v", err)
+	}
+
+	p, err := store.Authenticate("old")
+	if err != nil || p.Name != "file" || !p.Allowed(ScopeMessagesRead) || p.Allowed(ScopeMessagesSend) {
+		t.Fatalf("unexpected principal %+v, err %v", p, err)
+	}
+	if p, err := store.Authenticate("static"); err != nil || !p.Allowed(ScopeMessagesSend) {
+		t.Fatalf("expected the static key to grant every scope, got %+v, %v", p, err)
+	}
+	if _, err := store.Authenticate(""); !errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("expected ErrNoCredentials, got %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("file new messages:read\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Reload(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if _, err := store.Authenticate("old"); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("expected the old key to be revoked, got %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("broken\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Reload(); err == nil {
+		t.Fatal("expected a parse error")
+	}
+	if _, err := store.Authenticate("new"); err != nil {
+		t.Fatalf("expected keys to be kept after a failed reload, got %v", err)
+	}
+}
+
+func TestKeyStore_Watch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys")
+	if err := os.WriteFile(path, []byte("a first *\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewKeyStore(nil, path, zap.NewNop())
+	if err != nil {
+		t.Fatalf("new key store: %v", err)
+	}
+
+	ctx := t.Context()
+	go store.Watch(ctx, 10*time.Millisecond)
+
+	if err := os.WriteFile(path, []byte("a second-key *\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := store.Authenticate("second-key"); err == nil {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("key file change was not picked up")
+}
@@ -2,23 +2,91 @@ package broker
 
 import (
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
+// SystemSender is the From of messages that describe session events,
+// such as "authorized" or "logged_out", rather than chat messages.
+const SystemSender = "system"
+
+// SystemMessage returns a session event message with the given text.
+func SystemMessage(text string) *Message {
+	now := time.Now()
+
+	return &Message{
+		ID:        now.UnixNano(),
+		From:      SystemSender,
+		Text:      text,
+		Timestamp: now.Unix(),
+	}
+}
+
 type Message struct {
-	ID        int64
-	From      string
-	Text      string
-	Timestamp int64
+	SessionID string `json:"session_id"`
+	ID        int64  `json:"message_id"`
+	From      string `json:"from"`
+	Text      string `json:"text"`
+	Timestamp int64  `json:"timestamp"`
+
+	// SpanContext is the span of the update the message came from.
+	// Subscribers link the spans of their own work to it.
+	SpanContext trace.SpanContext `json:"-"`
+
+	// Scope is set on events published after their session was
+	// removed, such as its expiry, so that subscribers routing by the
+	// tenant or labels of the session can still do so.
+	Scope *Scope `json:"-"`
+}
+
+// Scope is the owner and the labels of a session.
+type Scope struct {
+	Tenant string
+	Labels map[string]string
+}
+
+// MatchLabels reports whether labels contain every label of the selector.
+func MatchLabels(labels, selector map[string]string) bool {
+	for k, v := range selector {
+		if got, ok := labels[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
 }
 
 type Dispatcher struct {
 	mu   sync.RWMutex
 	subs map[string]map[chan *Message]struct{}
+
+	// all receives messages of every session, including sessions
+	// created after the subscription.
+	all map[chan *Message]struct{}
+
+	// handlers are called by Publish, see HandleAll.
+	handlers map[*func(*Message)]struct{}
+
+	dropped atomic.Uint64
+}
+
+// Stats describes the subscriptions of a Dispatcher.
+type Stats struct {
+	Subscribers int
+	// QueueDepth is the number of messages buffered in subscriber
+	// channels and not received yet.
+	QueueDepth int
+	// Dropped counts messages not delivered to a subscriber because
+	// its buffer was full.
+	Dropped uint64
 }
 
 func NewDispatcher() *Dispatcher {
 	return &Dispatcher{
-		subs: make(map[string]map[chan *Message]struct{}),
+		subs:     make(map[string]map[chan *Message]struct{}),
+		all:      make(map[chan *Message]struct{}),
+		handlers: make(map[*func(*Message)]struct{}),
 	}
 }
 
@@ -35,25 +103,115 @@ func (d *Dispatcher) Subscribe(sessionID string) <-chan *Message {
 	return ch
 }
 
+// SubscribeAll returns a channel receiving messages of all sessions.
+// Every message carries the ID of the session it belongs to.
+func (d *Dispatcher) SubscribeAll() <-chan *Message {
+	ch := make(chan *Message, 64)
+
+	d.mu.Lock()
+	d.all[ch] = struct{}{}
+	d.mu.Unlock()
+
+	return ch
+}
+
+// HandleAll registers fn to be called with messages of all sessions.
+// Unlike SubscribeAll it never drops a message: Publish calls fn
+// synchronously, so fn must return quickly and must not call the
+// Dispatcher. After remove returns, fn is not called anymore.
+func (d *Dispatcher) HandleAll(fn func(*Message)) (remove func()) {
+	key := &fn
+
+	d.mu.Lock()
+	d.handlers[key] = struct{}{}
+	d.mu.Unlock()
+
+	return func() {
+		d.mu.Lock()
+		delete(d.handlers, key)
+		d.mu.Unlock()
+	}
+}
+
 func (d *Dispatcher) Publish(sessionID string, msg *Message) {
+	if msg.SessionID == "" {
+		msg.SessionID = sessionID
+	}
+
+	// The read lock is held while sending so that Unsubscribe
+	// cannot close a channel in the middle of delivery.
 	d.mu.RLock()
-	subs := d.subs[sessionID]
-	d.mu.RUnlock()
+	defer d.mu.RUnlock()
+
+	for ch := range d.subs[sessionID] {
+		select {
+		case ch <- msg:
+		default:
+			d.dropped.Add(1)
+		}
+	}
 
-	for ch := range subs {
+	for ch := range d.all {
 		select {
 		case ch <- msg:
 		default:
+			d.dropped.Add(1)
 		}
 	}
+
+	for fn := range d.handlers {
+		(*fn)(msg)
+	}
 }
 
-func (d *Dispatcher) Unsubscribe(sessionID string, ch chan *Message) {
+func (d *Dispatcher) Stats() Stats {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	stats := Stats{Dropped: d.dropped.Load()}
+	for _, subs := range d.subs {
+		for ch := range subs {
+			stats.Subscribers++
+			stats.QueueDepth += len(ch)
+		}
+	}
+	for ch := range d.all {
+		stats.Subscribers++
+		stats.QueueDepth += len(ch)
+	}
+	stats.Subscribers += len(d.handlers)
+	return stats
+}
+
+func (d *Dispatcher) Unsubscribe(sessionID string, ch <-chan *Message) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	if subs, ok := d.subs[sessionID]; ok {
-		delete(subs, ch)
-		close(ch)
+	subs, ok := d.subs[sessionID]
+	if !ok {
+		return
+	}
+
+	for c := range subs {
+		if c == ch {
+			delete(subs, c)
+			close(c)
+		}
+	}
+
+	if len(subs) == 0 {
+		delete(d.subs, sessionID)
+	}
+}
+
+func (d *Dispatcher) UnsubscribeAll(ch <-chan *Message) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for c := range d.all {
+		if c == ch {
+			delete(d.all, c)
+			close(c)
+		}
 	}
 }
@@ -0,0 +1,95 @@
+package broker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDispatcher_SubscribeAll(t *testing.T) {
+	d := NewDispatcher()
+
+	all := d.SubscribeAll()
+	defer d.UnsubscribeAll(all)
+
+	// session that did not exist at subscription time
+	d.Publish("late-session", &Message{ID: 1, Text: "hello"})
+
+	select {
+	case msg := <-all:
+		if msg.SessionID != "late-session" {
+			t.Fatalf("expected session id late-session, got %q", msg.SessionID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected message on SubscribeAll channel")
+	}
+}
+
+func TestDispatcher_Unsubscribe(t *testing.T) {
+	d := NewDispatcher()
+
+	ch := d.Subscribe("s1")
+	d.Unsubscribe("s1", ch)
+
+	if _, ok := <-ch; ok {
+		t.Fatal("expected channel to be closed")
+	}
+
+	// must not panic after unsubscribe
+	d.Publish("s1", &Message{ID: 1})
+}
+
+func TestDispatcher_Stats(t *testing.T) {
+	d := NewDispatcher()
+
+	ch := d.Subscribe("s1")
+	defer d.Unsubscribe("s1", ch)
+	all := d.SubscribeAll()
+	defer d.UnsubscribeAll(all)
+
+	// the session channel buffers 16 messages, the rest are dropped
+	for i := range 20 {
+		d.Publish("s1", &Message{ID: int64(i)})
+	}
+
+	stats := d.Stats()
+	if stats.Subscribers != 2 {
+		t.Fatalf("expected 2 subscribers, got %d", stats.Subscribers)
+	}
+	if stats.QueueDepth != 16+20 {
+		t.Fatalf("expected queue depth 36, got %d", stats.QueueDepth)
+	}
+	if stats.Dropped != 4 {
+		t.Fatalf("expected 4 dropped messages, got %d", stats.Dropped)
+	}
+}
+
+func TestDispatcher_HandleAll(t *testing.T) {
+	d := NewDispatcher()
+
+	var got []int64
+	remove := d.HandleAll(func(msg *Message) {
+		got = append(got, msg.ID)
+	})
+
+	// more than any channel buffers, none may be dropped
+	for i := range 100 {
+		d.Publish("s1", &Message{ID: int64(i)})
+	}
+
+	if len(got) != 100 || got[99] != 99 {
+		t.Fatalf("expected 100 messages in order, got %d", len(got))
+	}
+	if stats := d.Stats(); stats.Subscribers != 1 || stats.Dropped != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	remove()
+	d.Publish("s1", &Message{ID: 100})
+
+	if len(got) != 100 {
+		t.Fatal("expected no calls after remove")
+	}
+	if stats := d.Stats(); stats.Subscribers != 0 {
+		t.Fatalf("expected no subscribers after remove, got %d", stats.Subscribers)
+	}
+}
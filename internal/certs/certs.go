@@ -0,0 +1,201 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var ErrNoClientCA = errors.New("no certificates found in the client CA file")
+
+type ClientAuth string
+
+const (
+	// ClientAuthRequire rejects connections without a client certificate
+	// signed by the client CA.
+	ClientAuthRequire ClientAuth = "require"
+	// ClientAuthVerifyIfGiven verifies client certificates when sent,
+	// so callers may authenticate with either a certificate or an API key.
+	ClientAuthVerifyIfGiven ClientAuth = "verify_if_given"
+)
+
+type Options struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile enables mutual TLS.
+	ClientCAFile string
+	ClientAuth   ClientAuth
+}
+
+// Reloader serves a certificate and client CA loaded from files and
+// reloads them when the files change. The current files stay in use if
+// a reload fails.
+type Reloader struct {
+	opts   Options
+	logger *zap.Logger
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	clientCA *x509.CertPool
+	modTimes map[string]time.Time
+}
+
+// NewReloader loads the certificate, key and client CA of opts.
+func NewReloader(opts Options, logger *zap.Logger) (*Reloader, error) {
+	if opts.ClientAuth == "" {
+		opts.ClientAuth = ClientAuthRequire
+	}
+
+	r := &Reloader{
+		opts:   opts,
+		logger: logger,
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// NewStatic serves a fixed certificate, e.g. a self-signed one.
+func NewStatic(cert tls.Certificate, logger *zap.Logger) *Reloader {
+	return &Reloader{
+		cert:   &cert,
+		logger: logger,
+	}
+}
+
+// Reload re-reads the certificate files.
+func (r *Reloader) Reload() error {
+	modTimes := make(map[string]time.Time)
+	for _, path := range r.files() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		modTimes[path] = info.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.opts.CertFile, r.opts.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load server certificate: %w", err)
+	}
+
+	var pool *x509.CertPool
+	if r.opts.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.opts.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("read client CA: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return ErrNoClientCA
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.clientCA = pool
+	r.modTimes = modTimes
+	r.mu.Unlock()
+
+	return nil
+}
+
+func (r *Reloader) files() []string {
+	var files []string
+	for _, path := range []string{r.opts.CertFile, r.opts.KeyFile, r.opts.ClientCAFile} {
+		if path != "" {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// Watch reloads the certificates when a file changes, checking every
+// interval until ctx is cancelled.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	if len(r.files()) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !r.changed() {
+			continue
+		}
+
+		if err := r.Reload(); err != nil {
+			r.logger.Warn("failed to reload tls certificates, keeping the current ones", zap.Error(err))
+			continue
+		}
+		r.logger.Info("tls certificates reloaded")
+	}
+}
+
+func (r *Reloader) changed() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, path := range r.files() {
+		info, err := os.Stat(path)
+		if err != nil {
+			// being replaced, try again on the next tick
+			return false
+		}
+		if !info.ModTime().Equal(r.modTimes[path]) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServerConfig returns a TLS config using the current certificates
+// for every new connection.
+func (r *Reloader) ServerConfig() *tls.Config {
+	return r.serverConfig([]string{"h2"})
+}
+
+// HTTPServerConfig is ServerConfig for HTTP servers, which negotiate
+// HTTP/1.1 as well.
+func (r *Reloader) HTTPServerConfig() *tls.Config {
+	return r.serverConfig([]string{"h2", "http/1.1"})
+}
+
+func (r *Reloader) serverConfig(nextProtos []string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+
+			cfg := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*r.cert},
+				NextProtos:   nextProtos,
+			}
+			if r.clientCA != nil {
+				cfg.ClientCAs = r.clientCA
+				cfg.ClientAuth = tls.RequireAndVerifyClientCert
+				if r.opts.ClientAuth == ClientAuthVerifyIfGiven {
+					cfg.ClientAuth = tls.VerifyClientCertIfGiven
+				}
+			}
+			return cfg, nil
+		},
+	}
+}
@@ -0,0 +1,118 @@
+package certs
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func writeCert(t *testing.T, certFile, keyFile string) tls.Certificate {
+	t.Helper()
+
+	cert, err := SelfSigned()
+	if err != nil {
+		t.Fatalf("self-signed: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func servedCert(t *testing.T, r *Reloader) []byte {
+	t.Helper()
+
+	cfg, err := r.ServerConfig().GetConfigForClient(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatalf("config for client: %v", err)
+	}
+	return cfg.Certificates[0].Certificate[0]
+}
+
+func TestReloader_WatchReloadsChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	caFile := filepath.Join(dir, "ca.crt")
+
+	first := writeCert(t, certFile, keyFile)
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: first.Certificate[0]})
+	if err := os.WriteFile(caFile, caPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReloader(Options{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("new reloader: %v", err)
+	}
+	if !bytes.Equal(servedCert(t, r), first.Certificate[0]) {
+		t.Fatal("expected the loaded certificate to be served")
+	}
+
+	cfg, _ := r.ServerConfig().GetConfigForClient(&tls.ClientHelloInfo{})
+	if cfg.ClientAuth != tls.RequireAndVerifyClientCert || cfg.ClientCAs == nil {
+		t.Fatal("expected client certificates to be required")
+	}
+
+	go r.Watch(t.Context(), 10*time.Millisecond)
+
+	// make sure the modification time changes on coarse filesystems
+	time.Sleep(20 * time.Millisecond)
+	second := writeCert(t, certFile, keyFile)
+	later := time.Now().Add(time.Second)
+	for _, f := range []string{certFile, keyFile} {
+		if err := os.Chtimes(f, later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if bytes.Equal(servedCert(t, r), second.Certificate[0]) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("changed certificate was not reloaded")
+}
+
+func TestReloader_KeepsCertificateOnBadReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+
+	cert := writeCert(t, certFile, keyFile)
+
+	r, err := NewReloader(Options{CertFile: certFile, KeyFile: keyFile}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("new reloader: %v", err)
+	}
+
+	if err := os.WriteFile(keyFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reload(); err == nil {
+		t.Fatal("expected reload to fail")
+	}
+	if !bytes.Equal(servedCert(t, r), cert.Certificate[0]) {
+		t.Fatal("expected the previous certificate to stay in use")
+	}
+}
@@ -1,12 +1,22 @@
 package config
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/netip"
 	"os"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/zen-flo/telegram-service/internal/auth"
+	"github.com/zen-flo/telegram-service/internal/ratelimit"
+	"github.com/zen-flo/telegram-service/internal/tracing"
+	"github.com/zen-flo/telegram-service/internal/webhook"
+	"go.uber.org/zap/zapcore"
 )
 
 var (
@@ -15,15 +25,147 @@ var (
 )
 
 type Config struct {
+	// File is the configuration file, checked for changes every
+	// ReloadInterval.
+	File           string
+	ReloadInterval time.Duration
+
+	// LogLevel, RateLimit and Webhooks are applied on reload, changes to
+	// other settings need a restart.
+	LogLevel zapcore.Level
+
 	GRPCPort        int
 	TelegramAPIID   int
 	TelegramAPIHash string
+
+	// ShutdownTimeout bounds the graceful shutdown on SIGTERM.
+	ShutdownTimeout time.Duration
+
+	// TLSCertFile and TLSKeyFile serve gRPC over TLS, TLSClientCAFile
+	// enables mutual TLS. The files are checked for changes every
+	// TLSReloadInterval. TLSSelfSigned serves a generated certificate
+	// for development instead.
+	TLSCertFile       string
+	TLSKeyFile        string
+	TLSClientCAFile   string
+	TLSClientAuth     string
+	TLSSelfSigned     bool
+	TLSReloadInterval time.Duration
+
+	// HTTPPort serves the HTTP/JSON gateway, 0 (the default) disables
+	// it. It requires API keys. Browsers may call it from HTTPCORSOrigins.
+	HTTPPort        int
+	HTTPCORSOrigins []string
+
+	// MetricsPort serves Prometheus metrics at /metrics, 0 (the
+	// default) disables it. The endpoint is not authenticated.
+	MetricsPort int
+
+	// TracingExporter is "none", "otlp" or "stdout". The OTLP endpoint
+	// is set by the standard OTEL_EXPORTER_OTLP_* variables.
+	// TracingSampleRatio is the fraction of new traces recorded.
+	TracingExporter    string
+	TracingSampleRatio float64
+
+	// HealthMinConnected keeps the readiness check failing until at
+	// least this many sessions are connected to Telegram.
+	HealthMinConnected int
+
+	// APIKeys and the keys of APIKeysFile authenticate gRPC requests.
+	// The file is checked for changes every APIKeysReloadInterval.
+	// Without keys, requests are not authenticated.
+	APIKeys               []auth.Key
+	APIKeysFile           string
+	APIKeysReloadInterval time.Duration
+
+	// Webhooks are delivered to besides those registered by RPC.
+	Webhooks              []webhook.Webhook
+	WebhookMaxAttempts    int
+	WebhookDeadLetterFile string
+	// WebhookAllowedNetworks are internal networks webhooks may be
+	// delivered to, all internal addresses are rejected by default.
+	WebhookAllowedNetworks []netip.Prefix
+
+	OutboxMaxAttempts  int
+	OutboxMaxFloodWait time.Duration
+	// OutboxDrainTimeout bounds how long DeleteSession waits for the
+	// outbound queue of a detached session.
+	OutboxDrainTimeout time.Duration
+
+	// IdempotencyWindow is how long SendMessage idempotency keys are kept.
+	IdempotencyWindow time.Duration
+
+	// DeleteRevokedAuthKeys deletes stored auth keys of sessions logged
+	// out from another device.
+	DeleteRevokedAuthKeys bool
+
+	// ClientMaxRestarts limits consecutive restarts of a failing
+	// Telegram client, with backoff between ClientRestartBackoff and
+	// ClientRestartMaxBackoff.
+	ClientMaxRestarts       int
+	ClientRestartBackoff    time.Duration
+	ClientRestartMaxBackoff time.Duration
+
+	// SessionPendingTimeout expires sessions whose QR login is not
+	// completed in time. SessionTTL and SessionIdleTimeout expire
+	// authorized sessions by age and by RPC inactivity. Zero disables.
+	SessionPendingTimeout time.Duration
+	SessionTTL            time.Duration
+	SessionIdleTimeout    time.Duration
+
+	// MaxSessions and MaxSessionsPerTenant limit active sessions,
+	// zero means no limit.
+	MaxSessions          int
+	MaxSessionsPerTenant int
+	// TenantMaxSessions overrides MaxSessionsPerTenant by tenant and
+	// TenantLabels are added to every session of a tenant.
+	TenantMaxSessions map[string]int
+	TenantLabels      map[string]map[string]string
+
+	RateLimit ratelimit.Config
+	// RateLimitReject rejects SendMessage over the limit with
+	// RESOURCE_EXHAUSTED instead of waiting.
+	RateLimitReject bool
+
+	// SinkType selects the external event sink: "", "nats" or "redis".
+	SinkType       string
+	SinkURL        string
+	SinkSubject    string
+	SinkBufferFile string
+	// SinkBufferSize limits the events buffered on disk while the sink
+	// is unavailable.
+	SinkBufferSize  int
+	SinkRedisMaxLen int64
 }
 
+// Load reads the settings from the environment and from the YAML or
+// TOML file named by CONFIG_FILE, with the environment taking
+// precedence.
 func Load() (*Config, error) {
 	var validationErrors []string
 
-	portStr := getEnv("GRPC_PORT", "50051")
+	src := newSource()
+	configFile := os.Getenv("CONFIG_FILE")
+	if configFile != "" {
+		if err := src.readFile(configFile); err != nil {
+			validationErrors = append(validationErrors, "CONFIG_FILE could not be read: "+err.Error())
+		}
+	}
+
+	reloadStr := src.get("CONFIG_RELOAD_INTERVAL", "10s")
+	reload, err := time.ParseDuration(reloadStr)
+	if err != nil {
+		validationErrors = append(validationErrors, "CONFIG_RELOAD_INTERVAL must be a valid duration")
+	} else if reload <= 0 {
+		validationErrors = append(validationErrors, "CONFIG_RELOAD_INTERVAL must be positive")
+	}
+
+	logLevel, err := zapcore.ParseLevel(src.get("LOG_LEVEL", "info"))
+	if err != nil {
+		validationErrors = append(validationErrors, "LOG_LEVEL must be one of debug, info, warn, error")
+	}
+
+	portStr := src.get("GRPC_PORT", "50051")
 	port, err := strconv.Atoi(portStr)
 	if err != nil {
 		validationErrors = append(validationErrors, "GRPC_PORT must be a valid integer")
@@ -31,7 +173,15 @@ func Load() (*Config, error) {
 		validationErrors = append(validationErrors, "GRPC_PORT must be between 1 and 65535")
 	}
 
-	apiIDStr := os.Getenv("TELEGRAM_API_ID")
+	shutdownTimeoutStr := src.get("SHUTDOWN_TIMEOUT", "30s")
+	shutdownTimeout, err := time.ParseDuration(shutdownTimeoutStr)
+	if err != nil {
+		validationErrors = append(validationErrors, "SHUTDOWN_TIMEOUT must be a valid duration")
+	} else if shutdownTimeout <= 0 {
+		validationErrors = append(validationErrors, "SHUTDOWN_TIMEOUT must be positive")
+	}
+
+	apiIDStr := src.get("TELEGRAM_API_ID", "")
 	var apiID int
 	if apiIDStr == "" {
 		validationErrors = append(validationErrors, "TELEGRAM_API_ID is required")
@@ -44,27 +194,505 @@ func Load() (*Config, error) {
 		}
 	}
 
-	apiHash := os.Getenv("TELEGRAM_API_HASH")
+	apiHash := src.get("TELEGRAM_API_HASH", "")
 	if apiHash == "" {
 		validationErrors = append(validationErrors, "TELEGRAM_API_HASH is required")
 	} else if !apiHashRegex.MatchString(apiHash) {
 		validationErrors = append(validationErrors, "TELEGRAM_API_HASH must be a 32-character hex string")
 	}
 
+	tlsCertFile := src.get("TLS_CERT_FILE", "")
+	tlsKeyFile := src.get("TLS_KEY_FILE", "")
+	tlsClientCAFile := src.get("TLS_CLIENT_CA_FILE", "")
+	if (tlsCertFile == "") != (tlsKeyFile == "") {
+		validationErrors = append(validationErrors, "TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	tlsSelfSignedStr := src.get("TLS_SELF_SIGNED", "false")
+	tlsSelfSigned, err := strconv.ParseBool(tlsSelfSignedStr)
+	if err != nil {
+		validationErrors = append(validationErrors, "TLS_SELF_SIGNED must be a boolean")
+	} else if tlsSelfSigned && tlsCertFile != "" {
+		validationErrors = append(validationErrors, "TLS_SELF_SIGNED cannot be combined with TLS_CERT_FILE")
+	}
+	if tlsClientCAFile != "" && tlsCertFile == "" {
+		validationErrors = append(validationErrors, "TLS_CLIENT_CA_FILE requires TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+
+	tlsClientAuth := src.get("TLS_CLIENT_AUTH", "require")
+	if tlsClientAuth != "require" && tlsClientAuth != "verify_if_given" {
+		validationErrors = append(validationErrors, "TLS_CLIENT_AUTH must be one of: require, verify_if_given")
+	}
+
+	tlsReloadStr := src.get("TLS_RELOAD_INTERVAL", "30s")
+	tlsReload, err := time.ParseDuration(tlsReloadStr)
+	if err != nil {
+		validationErrors = append(validationErrors, "TLS_RELOAD_INTERVAL must be a valid duration")
+	} else if tlsReload <= 0 {
+		validationErrors = append(validationErrors, "TLS_RELOAD_INTERVAL must be positive")
+	}
+
+	httpPortStr := src.get("HTTP_PORT", "0")
+	httpPort, err := strconv.Atoi(httpPortStr)
+	if err != nil {
+		validationErrors = append(validationErrors, "HTTP_PORT must be a valid integer")
+	} else if httpPort < 0 || httpPort > 65535 {
+		validationErrors = append(validationErrors, "HTTP_PORT must be between 0 and 65535")
+	} else if httpPort != 0 && httpPort == port {
+		validationErrors = append(validationErrors, "HTTP_PORT must differ from GRPC_PORT")
+	}
+
+	var httpCORSOrigins []string
+	for _, origin := range strings.Split(src.get("HTTP_CORS_ORIGINS", ""), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			httpCORSOrigins = append(httpCORSOrigins, origin)
+		}
+	}
+
+	metricsPortStr := src.get("METRICS_PORT", "0")
+	metricsPort, err := strconv.Atoi(metricsPortStr)
+	if err != nil {
+		validationErrors = append(validationErrors, "METRICS_PORT must be a valid integer")
+	} else if metricsPort < 0 || metricsPort > 65535 {
+		validationErrors = append(validationErrors, "METRICS_PORT must be between 0 and 65535")
+	} else if metricsPort != 0 && (metricsPort == port || metricsPort == httpPort) {
+		validationErrors = append(validationErrors, "METRICS_PORT must differ from GRPC_PORT and HTTP_PORT")
+	}
+
+	tracingExporter := src.get("TRACING_EXPORTER", tracing.ExporterNone)
+	switch tracingExporter {
+	case tracing.ExporterNone, tracing.ExporterOTLP, tracing.ExporterStdout:
+	default:
+		validationErrors = append(validationErrors, "TRACING_EXPORTER must be one of none, otlp, stdout")
+	}
+
+	tracingSampleRatioStr := src.get("TRACING_SAMPLE_RATIO", "1")
+	tracingSampleRatio, err := strconv.ParseFloat(tracingSampleRatioStr, 64)
+	if err != nil {
+		validationErrors = append(validationErrors, "TRACING_SAMPLE_RATIO must be a valid number")
+	} else if tracingSampleRatio < 0 || tracingSampleRatio > 1 {
+		validationErrors = append(validationErrors, "TRACING_SAMPLE_RATIO must be between 0 and 1")
+	}
+
+	healthMinConnectedStr := src.get("HEALTH_MIN_CONNECTED_SESSIONS", "0")
+	healthMinConnected, err := strconv.Atoi(healthMinConnectedStr)
+	if err != nil {
+		validationErrors = append(validationErrors, "HEALTH_MIN_CONNECTED_SESSIONS must be a valid integer")
+	} else if healthMinConnected < 0 {
+		validationErrors = append(validationErrors, "HEALTH_MIN_CONNECTED_SESSIONS must not be negative")
+	}
+
+	apiKeysStr := src.get("API_KEYS", "")
+	apiKeys, err := auth.ParseKeys(strings.NewReader(apiKeysStr))
+	if err != nil {
+		validationErrors = append(validationErrors, "API_KEYS must be a list of '<name> <key> <scopes> [<tenant>]' separated by ';': "+err.Error())
+	}
+
+	apiKeysFile := src.get("API_KEYS_FILE", "")
+	if httpPort != 0 && strings.TrimSpace(apiKeysStr) == "" && apiKeysFile == "" {
+		// the gateway is reachable from browsers, never serve it unauthenticated
+		validationErrors = append(validationErrors, "HTTP_PORT requires API_KEYS or API_KEYS_FILE")
+	}
+
+	keysReloadStr := src.get("API_KEYS_RELOAD_INTERVAL", "10s")
+	keysReload, err := time.ParseDuration(keysReloadStr)
+	if err != nil {
+		validationErrors = append(validationErrors, "API_KEYS_RELOAD_INTERVAL must be a valid duration")
+	} else if keysReload <= 0 {
+		validationErrors = append(validationErrors, "API_KEYS_RELOAD_INTERVAL must be positive")
+	}
+
+	webhooks, err := parseWebhooks(src.get("WEBHOOKS", ""))
+	if err != nil {
+		validationErrors = append(validationErrors, "WEBHOOKS must be a JSON list of webhooks: "+err.Error())
+	}
+
+	webhookAttemptsStr := src.get("WEBHOOK_MAX_ATTEMPTS", "5")
+	webhookAttempts, err := strconv.Atoi(webhookAttemptsStr)
+	if err != nil {
+		validationErrors = append(validationErrors, "WEBHOOK_MAX_ATTEMPTS must be a valid integer")
+	} else if webhookAttempts < 1 {
+		validationErrors = append(validationErrors, "WEBHOOK_MAX_ATTEMPTS must be positive")
+	}
+
+	webhookNetworks, err := parseNetworks(src.get("WEBHOOK_ALLOWED_NETWORKS", ""))
+	if err != nil {
+		validationErrors = append(validationErrors, "WEBHOOK_ALLOWED_NETWORKS must be a comma-separated list of CIDR prefixes or addresses: "+err.Error())
+	}
+
+	outboxAttemptsStr := src.get("OUTBOX_MAX_ATTEMPTS", "5")
+	outboxAttempts, err := strconv.Atoi(outboxAttemptsStr)
+	if err != nil {
+		validationErrors = append(validationErrors, "OUTBOX_MAX_ATTEMPTS must be a valid integer")
+	} else if outboxAttempts < 1 {
+		validationErrors = append(validationErrors, "OUTBOX_MAX_ATTEMPTS must be positive")
+	}
+
+	outboxFloodWaitStr := src.get("OUTBOX_MAX_FLOOD_WAIT", "1h")
+	outboxFloodWait, err := time.ParseDuration(outboxFloodWaitStr)
+	if err != nil {
+		validationErrors = append(validationErrors, "OUTBOX_MAX_FLOOD_WAIT must be a valid duration")
+	} else if outboxFloodWait <= 0 {
+		validationErrors = append(validationErrors, "OUTBOX_MAX_FLOOD_WAIT must be positive")
+	}
+
+	outboxDrainTimeoutStr := src.get("OUTBOX_DRAIN_TIMEOUT", "30s")
+	outboxDrainTimeout, err := time.ParseDuration(outboxDrainTimeoutStr)
+	if err != nil {
+		validationErrors = append(validationErrors, "OUTBOX_DRAIN_TIMEOUT must be a valid duration")
+	} else if outboxDrainTimeout <= 0 {
+		validationErrors = append(validationErrors, "OUTBOX_DRAIN_TIMEOUT must be positive")
+	}
+
+	idempotencyWindowStr := src.get("IDEMPOTENCY_WINDOW", "24h")
+	idempotencyWindow, err := time.ParseDuration(idempotencyWindowStr)
+	if err != nil {
+		validationErrors = append(validationErrors, "IDEMPOTENCY_WINDOW must be a valid duration")
+	} else if idempotencyWindow <= 0 {
+		validationErrors = append(validationErrors, "IDEMPOTENCY_WINDOW must be positive")
+	}
+
+	deleteRevokedStr := src.get("DELETE_REVOKED_AUTH_KEYS", "false")
+	deleteRevoked, err := strconv.ParseBool(deleteRevokedStr)
+	if err != nil {
+		validationErrors = append(validationErrors, "DELETE_REVOKED_AUTH_KEYS must be a boolean")
+	}
+
+	maxRestartsStr := src.get("CLIENT_MAX_RESTARTS", "10")
+	maxRestarts, err := strconv.Atoi(maxRestartsStr)
+	if err != nil {
+		validationErrors = append(validationErrors, "CLIENT_MAX_RESTARTS must be a valid integer")
+	} else if maxRestarts < 1 {
+		validationErrors = append(validationErrors, "CLIENT_MAX_RESTARTS must be positive")
+	}
+
+	restartBackoffStr := src.get("CLIENT_RESTART_BACKOFF", "1s")
+	restartBackoff, err := time.ParseDuration(restartBackoffStr)
+	if err != nil {
+		validationErrors = append(validationErrors, "CLIENT_RESTART_BACKOFF must be a valid duration")
+	} else if restartBackoff <= 0 {
+		validationErrors = append(validationErrors, "CLIENT_RESTART_BACKOFF must be positive")
+	}
+
+	restartMaxBackoffStr := src.get("CLIENT_RESTART_MAX_BACKOFF", "5m")
+	restartMaxBackoff, err := time.ParseDuration(restartMaxBackoffStr)
+	if err != nil {
+		validationErrors = append(validationErrors, "CLIENT_RESTART_MAX_BACKOFF must be a valid duration")
+	} else if restartMaxBackoff < restartBackoff {
+		validationErrors = append(validationErrors, "CLIENT_RESTART_MAX_BACKOFF must not be less than CLIENT_RESTART_BACKOFF")
+	}
+
+	var sessionPendingTimeout, sessionTTL, sessionIdleTimeout time.Duration
+	for _, d := range []struct {
+		env      string
+		fallback string
+		value    *time.Duration
+	}{
+		{"SESSION_PENDING_TIMEOUT", "10m", &sessionPendingTimeout},
+		{"SESSION_TTL", "0", &sessionTTL},
+		{"SESSION_IDLE_TIMEOUT", "0", &sessionIdleTimeout},
+	} {
+		v, err := time.ParseDuration(src.get(d.env, d.fallback))
+		if err != nil {
+			validationErrors = append(validationErrors, d.env+" must be a valid duration")
+			continue
+		}
+		if v < 0 {
+			validationErrors = append(validationErrors, d.env+" must not be negative")
+			continue
+		}
+		*d.value = v
+	}
+
+	maxSessionsStr := src.get("MAX_SESSIONS", "0")
+	maxSessions, err := strconv.Atoi(maxSessionsStr)
+	if err != nil {
+		validationErrors = append(validationErrors, "MAX_SESSIONS must be a valid integer")
+	} else if maxSessions < 0 {
+		validationErrors = append(validationErrors, "MAX_SESSIONS must not be negative")
+	}
+
+	maxTenantSessionsStr := src.get("MAX_SESSIONS_PER_TENANT", "0")
+	maxTenantSessions, err := strconv.Atoi(maxTenantSessionsStr)
+	if err != nil {
+		validationErrors = append(validationErrors, "MAX_SESSIONS_PER_TENANT must be a valid integer")
+	} else if maxTenantSessions < 0 {
+		validationErrors = append(validationErrors, "MAX_SESSIONS_PER_TENANT must not be negative")
+	}
+
+	tenantMaxSessions, err := parseTenantMaxSessions(src.get("TENANT_MAX_SESSIONS", ""))
+	if err != nil {
+		validationErrors = append(validationErrors, "TENANT_MAX_SESSIONS must look like acme=10,globex=50")
+	}
+
+	tenantLabels, err := parseTenantLabels(src.get("TENANT_LABELS", ""))
+	if err != nil {
+		validationErrors = append(validationErrors, "TENANT_LABELS must look like acme:plan=pro,region=eu;globex:plan=free")
+	}
+
+	var rateLimit ratelimit.Config
+	for _, l := range []struct {
+		env   string
+		limit *ratelimit.Limit
+	}{
+		{"RATE_LIMIT_GLOBAL", &rateLimit.Global},
+		{"RATE_LIMIT_SESSION", &rateLimit.Session},
+		{"RATE_LIMIT_PEER", &rateLimit.Peer},
+		{"RATE_LIMIT_NEW_CONTACT", &rateLimit.NewContact},
+	} {
+		limit, err := ratelimit.ParseLimit(src.get(l.env, ""))
+		if err != nil {
+			validationErrors = append(validationErrors, l.env+" must look like <events>/<duration>, e.g. 30/1s")
+			continue
+		}
+		*l.limit = limit
+	}
+
+	rateLimitMode := src.get("RATE_LIMIT_MODE", "queue")
+	if rateLimitMode != "queue" && rateLimitMode != "reject" {
+		validationErrors = append(validationErrors, "RATE_LIMIT_MODE must be one of: queue, reject")
+	}
+
+	sinkType := src.get("SINK_TYPE", "")
+	sinkURL := src.get("SINK_URL", "")
+	sinkSubject := src.get("SINK_SUBJECT", "")
+	switch sinkType {
+	case "":
+	case "nats":
+		if sinkSubject == "" {
+			sinkSubject = "telegram.sessions.{session_id}.messages"
+		}
+	case "redis":
+		if sinkSubject == "" {
+			sinkSubject = "telegram:sessions:{session_id}"
+		}
+	default:
+		validationErrors = append(validationErrors, "SINK_TYPE must be one of: nats, redis")
+	}
+	if sinkType != "" && sinkURL == "" {
+		validationErrors = append(validationErrors, "SINK_URL is required when SINK_TYPE is set")
+	}
+
+	sinkMaxLenStr := src.get("SINK_REDIS_MAX_LEN", "0")
+	sinkMaxLen, err := strconv.ParseInt(sinkMaxLenStr, 10, 64)
+	if err != nil {
+		validationErrors = append(validationErrors, "SINK_REDIS_MAX_LEN must be a valid integer")
+	} else if sinkMaxLen < 0 {
+		validationErrors = append(validationErrors, "SINK_REDIS_MAX_LEN must not be negative")
+	}
+
+	sinkBufferSizeStr := src.get("SINK_BUFFER_SIZE", "100000")
+	sinkBufferSize, err := strconv.Atoi(sinkBufferSizeStr)
+	if err != nil {
+		validationErrors = append(validationErrors, "SINK_BUFFER_SIZE must be a valid integer")
+	} else if sinkBufferSize < 1 {
+		validationErrors = append(validationErrors, "SINK_BUFFER_SIZE must be positive")
+	}
+
+	for _, key := range src.unknown() {
+		validationErrors = append(validationErrors, "CONFIG_FILE sets unknown setting "+key)
+	}
+
 	if len(validationErrors) > 0 {
 		return nil, fmt.Errorf("%w: %s", ErrValidation, strings.Join(validationErrors, "; "))
 	}
 
 	return &Config{
+		File:           configFile,
+		ReloadInterval: reload,
+		LogLevel:       logLevel,
+
 		GRPCPort:        port,
 		TelegramAPIID:   apiID,
 		TelegramAPIHash: apiHash,
+
+		ShutdownTimeout: shutdownTimeout,
+
+		TLSCertFile:       tlsCertFile,
+		TLSKeyFile:        tlsKeyFile,
+		TLSClientCAFile:   tlsClientCAFile,
+		TLSClientAuth:     tlsClientAuth,
+		TLSSelfSigned:     tlsSelfSigned,
+		TLSReloadInterval: tlsReload,
+
+		HTTPPort:        httpPort,
+		HTTPCORSOrigins: httpCORSOrigins,
+
+		MetricsPort: metricsPort,
+
+		TracingExporter:    tracingExporter,
+		TracingSampleRatio: tracingSampleRatio,
+
+		HealthMinConnected: healthMinConnected,
+
+		APIKeys:               apiKeys,
+		APIKeysFile:           apiKeysFile,
+		APIKeysReloadInterval: keysReload,
+
+		Webhooks:               webhooks,
+		WebhookMaxAttempts:     webhookAttempts,
+		WebhookDeadLetterFile:  src.get("WEBHOOK_DEAD_LETTER_FILE", "sessions/webhook_dead_letters.jsonl"),
+		WebhookAllowedNetworks: webhookNetworks,
+
+		OutboxMaxAttempts:  outboxAttempts,
+		OutboxMaxFloodWait: outboxFloodWait,
+		OutboxDrainTimeout: outboxDrainTimeout,
+
+		IdempotencyWindow:     idempotencyWindow,
+		DeleteRevokedAuthKeys: deleteRevoked,
+
+		ClientMaxRestarts:       maxRestarts,
+		ClientRestartBackoff:    restartBackoff,
+		ClientRestartMaxBackoff: restartMaxBackoff,
+
+		SessionPendingTimeout: sessionPendingTimeout,
+		SessionTTL:            sessionTTL,
+		SessionIdleTimeout:    sessionIdleTimeout,
+
+		MaxSessions:          maxSessions,
+		MaxSessionsPerTenant: maxTenantSessions,
+		TenantMaxSessions:    tenantMaxSessions,
+		TenantLabels:         tenantLabels,
+
+		RateLimit:       rateLimit,
+		RateLimitReject: rateLimitMode == "reject",
+
+		SinkType:        sinkType,
+		SinkURL:         sinkURL,
+		SinkSubject:     sinkSubject,
+		SinkBufferFile:  src.get("SINK_BUFFER_FILE", "sessions/sink_buffer.jsonl"),
+		SinkBufferSize:  sinkBufferSize,
+		SinkRedisMaxLen: sinkMaxLen,
 	}, nil
 }
 
-func getEnv(key, fallback string) string {
-	if v := os.Getenv(key); v != "" {
-		return v
+// NeedsRestart reports whether next changes settings that are not
+// applied on reload.
+func (c *Config) NeedsRestart(next *Config) bool {
+	reloaded := *next
+	reloaded.LogLevel = c.LogLevel
+	reloaded.RateLimit = c.RateLimit
+	reloaded.Webhooks = c.Webhooks
+	return !reflect.DeepEqual(*c, reloaded)
+}
+
+// parseWebhooks parses a JSON list of webhooks with an id, url, secret
+// and session_id or label_selector each.
+func parseWebhooks(s string) ([]webhook.Webhook, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var entries []struct {
+		ID            string            `json:"id"`
+		URL           string            `json:"url"`
+		Secret        string            `json:"secret"`
+		SessionID     string            `json:"session_id"`
+		LabelSelector map[string]string `json:"label_selector"`
+	}
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&entries); err != nil {
+		return nil, err
+	}
+
+	webhooks := make([]webhook.Webhook, 0, len(entries))
+	ids := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		w := webhook.Webhook{
+			ID:            e.ID,
+			URL:           e.URL,
+			Secret:        e.Secret,
+			SessionID:     e.SessionID,
+			LabelSelector: e.LabelSelector,
+		}
+		if w.ID == "" {
+			return nil, errors.New("every webhook needs an id")
+		}
+		if ids[w.ID] {
+			return nil, fmt.Errorf("duplicate id %q", w.ID)
+		}
+		ids[w.ID] = true
+		if err := webhook.Validate(&w); err != nil {
+			return nil, fmt.Errorf("webhook %q: %w", w.ID, err)
+		}
+		webhooks = append(webhooks, w)
+	}
+	return webhooks, nil
+}
+
+// parseNetworks parses a comma-separated list of CIDR prefixes, a
+// single address standing for itself.
+func parseNetworks(s string) ([]netip.Prefix, error) {
+	var networks []netip.Prefix
+	for entry := range strings.SplitSeq(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, err
+			}
+			networks = append(networks, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, prefix.Masked())
+	}
+	return networks, nil
+}
+
+// parseTenantMaxSessions parses "<tenant>=<n>,..." into a map.
+func parseTenantMaxSessions(s string) (map[string]int, error) {
+	limits := make(map[string]int)
+	if s == "" {
+		return limits, nil
+	}
+
+	for entry := range strings.SplitSeq(s, ",") {
+		tenant, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || tenant == "" {
+			return nil, fmt.Errorf("invalid entry %q", entry)
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid limit of tenant %q", tenant)
+		}
+		limits[tenant] = n
+	}
+	return limits, nil
+}
+
+// parseTenantLabels parses "<tenant>:<key>=<value>,...;..." into labels
+// by tenant.
+func parseTenantLabels(s string) (map[string]map[string]string, error) {
+	labels := make(map[string]map[string]string)
+	if s == "" {
+		return labels, nil
+	}
+
+	for entry := range strings.SplitSeq(s, ";") {
+		tenant, list, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || tenant == "" {
+			return nil, fmt.Errorf("invalid entry %q", entry)
+		}
+
+		tenantLabels := make(map[string]string)
+		for pair := range strings.SplitSeq(list, ",") {
+			k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok || k == "" {
+				return nil, fmt.Errorf("invalid label %q of tenant %q", pair, tenant)
+			}
+			tenantLabels[k] = v
+		}
+		labels[tenant] = tenantLabels
 	}
-	return fallback
+	return labels, nil
 }
@@ -0,0 +1,276 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// source looks settings up in the environment, then in the
+// configuration file.
+type source struct {
+	file map[string]setting
+	used map[string]bool
+}
+
+// setting is a value of the configuration file with the key it was
+// given under.
+type setting struct {
+	key   string
+	value string
+}
+
+func newSource() *source {
+	return &source{
+		file: make(map[string]setting),
+		used: make(map[string]bool),
+	}
+}
+
+// get returns the setting named by the environment variable key, or
+// fallback when it is set neither in the environment nor in the file.
+func (s *source) get(key, fallback string) string {
+	s.used[key] = true
+
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	if v, ok := s.file[key]; ok && v.value != "" {
+		return v.value
+	}
+	return fallback
+}
+
+// unknown returns the keys of the file that name no setting.
+func (s *source) unknown() []string {
+	var keys []string
+	for name, v := range s.file {
+		if !s.used[name] {
+			keys = append(keys, v.key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// readFile loads a YAML or TOML file, chosen by the extension. Every
+// setting is named like its environment variable in lower case, with
+// nested tables joined by "_": rate_limit: {global: 30/1s} sets
+// RATE_LIMIT_GLOBAL. Settings holding several values may also be given
+// as lists and tables, see formatters; any other setting takes a single
+// value.
+func (s *source) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	doc := make(map[string]any)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &doc)
+	case ".toml":
+		err = toml.Unmarshal(data, &doc)
+	default:
+		return fmt.Errorf("unsupported file type %q, use .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.flatten("", doc)
+}
+
+func (s *source) flatten(prefix string, table map[string]any) error {
+	for k, v := range table {
+		key := k
+		if prefix != "" {
+			key = prefix + "_" + k
+		}
+
+		name := strings.ToUpper(key)
+		if format, ok := formatters[name]; ok {
+			value, err := format(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			if err := s.set(name, key, value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if nested, ok := v.(map[string]any); ok {
+			if err := s.flatten(key, nested); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, err := formatValue(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+
+		if err := s.set(name, key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *source) set(name, key, value string) error {
+	if prev, ok := s.file[name]; ok {
+		return fmt.Errorf("%s is set twice, also as %s", key, prev.key)
+	}
+	s.file[name] = setting{key: key, value: value}
+	return nil
+}
+
+// formatters convert the lists and tables of settings holding several
+// values to the text of their environment variable, joined by the
+// separators the setting is parsed with:
+//
+//	api_keys: ["crm secret sessions:read", ...]    "...;..."
+//	http_cors_origins: [https://a.example.com, ...] "...,..."
+//	tenant_max_sessions: {acme: 10, ...}           "acme=10,..."
+//	tenant_labels: {acme: {plan: pro}, ...}        "acme:plan=pro;..."
+//	webhooks: [{id: crm, url: ...}, ...]           JSON
+//	webhook_allowed_networks: [10.0.0.0/8, ...]    "...,..."
+//
+// A single string is passed as it is.
+var formatters = map[string]func(any) (string, error){
+	"API_KEYS":            formatList(";"),
+	"HTTP_CORS_ORIGINS":   formatList(","),
+	"TENANT_MAX_SESSIONS": formatTenantMaxSessions,
+	"TENANT_LABELS":       formatTenantLabels,
+	"WEBHOOKS":            formatJSON,
+
+	"WEBHOOK_ALLOWED_NETWORKS": formatList(","),
+}
+
+func formatList(sep string) func(any) (string, error) {
+	return func(v any) (string, error) {
+		list, ok := v.([]any)
+		if !ok {
+			return formatValue(v)
+		}
+
+		values := make([]string, 0, len(list))
+		for _, item := range list {
+			value, err := formatValue(item)
+			if err != nil {
+				return "", err
+			}
+			if strings.Contains(value, sep) {
+				return "", fmt.Errorf("list item %q must not contain %q", value, sep)
+			}
+			values = append(values, value)
+		}
+		return strings.Join(values, sep), nil
+	}
+}
+
+func formatTenantMaxSessions(v any) (string, error) {
+	table, ok := v.(map[string]any)
+	if !ok {
+		return formatValue(v)
+	}
+
+	entries := make([]string, 0, len(table))
+	for _, tenant := range sortedKeys(table) {
+		if strings.ContainsAny(tenant, "=,") {
+			return "", fmt.Errorf("invalid tenant %q", tenant)
+		}
+		limit, err := formatValue(table[tenant])
+		if err != nil {
+			return "", fmt.Errorf("tenant %s: %w", tenant, err)
+		}
+		entries = append(entries, tenant+"="+limit)
+	}
+	return strings.Join(entries, ","), nil
+}
+
+func formatTenantLabels(v any) (string, error) {
+	table, ok := v.(map[string]any)
+	if !ok {
+		return formatValue(v)
+	}
+
+	entries := make([]string, 0, len(table))
+	for _, tenant := range sortedKeys(table) {
+		if strings.ContainsAny(tenant, ":;") {
+			return "", fmt.Errorf("invalid tenant %q", tenant)
+		}
+		labels, ok := table[tenant].(map[string]any)
+		if !ok {
+			return "", fmt.Errorf("tenant %s: labels must be a table", tenant)
+		}
+
+		pairs := make([]string, 0, len(labels))
+		for _, k := range sortedKeys(labels) {
+			value, err := formatValue(labels[k])
+			if err != nil {
+				return "", fmt.Errorf("tenant %s: label %s: %w", tenant, k, err)
+			}
+			if strings.ContainsAny(k, "=,;") || strings.ContainsAny(value, ",;") {
+				return "", fmt.Errorf("tenant %s: invalid label %s=%s", tenant, k, value)
+			}
+			pairs = append(pairs, k+"="+value)
+		}
+		entries = append(entries, tenant+":"+strings.Join(pairs, ","))
+	}
+	return strings.Join(entries, ";"), nil
+}
+
+func formatJSON(v any) (string, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func sortedKeys(table map[string]any) []string {
+	keys := make([]string, 0, len(table))
+	for k := range table {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatValue formats a single value.
+func formatValue(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []any, []map[string]any:
+		return "", errors.New("must be a string, number or boolean, not a list")
+	case map[string]any:
+		return "", errors.New("must be a string, number or boolean, not a table")
+	}
+	return "", fmt.Errorf("unsupported value %v", v)
+}
@@ -0,0 +1,259 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func writeConfig(t *testing.T, name, content string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", path)
+}
+
+func TestLoad_YAML(t *testing.T) {
+	writeConfig(t, "config.yaml", `
+grpc_port: 6000
+log_level: debug
+telegram:
+  api_id: 12345
+  api_hash: 0123456789abcdef0123456789abcdef
+http_cors_origins: [https://a.example.com, https://b.example.com]
+rate_limit:
+  global: 30/1s
+  peer: 1/1s
+webhooks:
+  - id: crm
+    url: https://crm.example.com/hook
+    label_selector: {team: sales}
+`)
+	// the environment takes precedence
+	t.Setenv("RATE_LIMIT_PEER", "2/1s")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.GRPCPort != 6000 || cfg.TelegramAPIID != 12345 || cfg.LogLevel != zapcore.DebugLevel {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	if strings.Join(cfg.HTTPCORSOrigins, " ") != "https://a.example.com https://b.example.com" {
+		t.Fatalf("unexpected origins %v", cfg.HTTPCORSOrigins)
+	}
+	if cfg.RateLimit.Global.String() != "30/1s" || cfg.RateLimit.Peer.String() != "2/1s" {
+		t.Fatalf("unexpected rate limits %+v", cfg.RateLimit)
+	}
+	if len(cfg.Webhooks) != 1 || cfg.Webhooks[0].ID != "crm" || cfg.Webhooks[0].LabelSelector["team"] != "sales" {
+		t.Fatalf("unexpected webhooks %+v", cfg.Webhooks)
+	}
+}
+
+func TestLoad_TOML(t *testing.T) {
+	writeConfig(t, "config.toml", `
+shutdown_timeout = "1m"
+
+[telegram]
+api_id = 12345
+api_hash = "0123456789abcdef0123456789abcdef"
+
+[[webhooks]]
+id = "crm"
+url = "https://crm.example.com/hook"
+label_selector = { team = "sales" }
+
+[[webhooks]]
+id = "audit"
+url = "https://audit.example.com/hook"
+session_id = "s1"
+`)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.TelegramAPIID != 12345 || cfg.ShutdownTimeout.String() != "1m0s" {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	if len(cfg.Webhooks) != 2 || cfg.Webhooks[0].ID != "crm" || cfg.Webhooks[0].LabelSelector["team"] != "sales" || cfg.Webhooks[1].ID != "audit" {
+		t.Fatalf("unexpected webhooks %+v", cfg.Webhooks)
+	}
+}
+
+func TestLoad_ListsAndTables(t *testing.T) {
+	for name, content := range map[string]string{
+		"config.yaml": `
+telegram:
+  api_id: 12345
+  api_hash: 0123456789abcdef0123456789abcdef
+api_keys:
+  - crm secret1 sessions:read,messages:send acme
+  - admin secret2 *
+tenant_max_sessions: {acme: 10, globex: 50}
+tenant_labels:
+  acme: {plan: pro, region: eu}
+  globex: {plan: free}
+webhook_allowed_networks: [10.0.0.0/8, 192.168.1.5]
+`,
+		"config.toml": `
+api_keys = ["crm secret1 sessions:read,messages:send acme", "admin secret2 *"]
+webhook_allowed_networks = ["10.0.0.0/8", "192.168.1.5"]
+
+[telegram]
+api_id = 12345
+api_hash = "0123456789abcdef0123456789abcdef"
+
+[tenant_max_sessions]
+acme = 10
+globex = 50
+
+[tenant_labels]
+acme = { plan = "pro", region = "eu" }
+globex = { plan = "free" }
+`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			writeConfig(t, name, content)
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(cfg.APIKeys) != 2 || cfg.APIKeys[0].Name != "crm" || cfg.APIKeys[0].Tenant != "acme" || cfg.APIKeys[1].Name != "admin" {
+				t.Fatalf("unexpected api keys %+v", cfg.APIKeys)
+			}
+			if len(cfg.TenantMaxSessions) != 2 || cfg.TenantMaxSessions["acme"] != 10 || cfg.TenantMaxSessions["globex"] != 50 {
+				t.Fatalf("unexpected tenant limits %v", cfg.TenantMaxSessions)
+			}
+			acme := cfg.TenantLabels["acme"]
+			if len(cfg.TenantLabels) != 2 || acme["plan"] != "pro" || acme["region"] != "eu" || cfg.TenantLabels["globex"]["plan"] != "free" {
+				t.Fatalf("unexpected tenant labels %v", cfg.TenantLabels)
+			}
+			if nets := cfg.WebhookAllowedNetworks; len(nets) != 2 || nets[0].String() != "10.0.0.0/8" || nets[1].String() != "192.168.1.5/32" {
+				t.Fatalf("unexpected webhook networks %v", nets)
+			}
+		})
+	}
+}
+
+func TestLoad_ListOfSingleValueSetting(t *testing.T) {
+	writeConfig(t, "config.yaml", `
+telegram:
+  api_id: 12345
+  api_hash: 0123456789abcdef0123456789abcdef
+sink_url: [nats://a:4222, nats://b:4222]
+`)
+
+	_, err := Load()
+	if !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), "sink_url: must be a string, number or boolean, not a list") {
+		t.Fatalf("expected a list to be rejected, got %v", err)
+	}
+}
+
+func TestLoad_FileValidation(t *testing.T) {
+	writeConfig(t, "config.yaml", `
+telegram:
+  api_id: 12345
+  api_hash: 0123456789abcdef0123456789abcdef
+grpc_prot: 6000
+rate_limit:
+  global: fast
+webhooks:
+  - url: https://crm.example.com/hook
+    session_id: s1
+`)
+
+	_, err := Load()
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	for _, want := range []string{"unknown setting grpc_prot", "RATE_LIMIT_GLOBAL", "WEBHOOKS"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q to be reported, got %v", want, err)
+		}
+	}
+}
+
+func TestLoad_HTTPPortRequiresKeys(t *testing.T) {
+	writeConfig(t, "config.yaml", `
+telegram:
+  api_id: 12345
+  api_hash: 0123456789abcdef0123456789abcdef
+`)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.HTTPPort != 0 {
+		t.Fatalf("expected the gateway to be off by default, got port %d", cfg.HTTPPort)
+	}
+
+	t.Setenv("HTTP_PORT", "8080")
+	if _, err := Load(); !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), "HTTP_PORT requires API_KEYS") {
+		t.Fatalf("expected the gateway to require api keys, got %v", err)
+	}
+
+	t.Setenv("API_KEYS", "crm secret sessions:read")
+	if cfg, err := Load(); err != nil || cfg.HTTPPort != 8080 {
+		t.Fatalf("expected the gateway with api keys, got %+v, %v", cfg, err)
+	}
+}
+
+func TestLoad_MetricsOffByDefault(t *testing.T) {
+	writeConfig(t, "config.yaml", `
+telegram:
+  api_id: 12345
+  api_hash: 0123456789abcdef0123456789abcdef
+`)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MetricsPort != 0 {
+		t.Fatalf("expected metrics to be off by default, got port %d", cfg.MetricsPort)
+	}
+
+	t.Setenv("METRICS_PORT", "9090")
+	if cfg, err := Load(); err != nil || cfg.MetricsPort != 9090 {
+		t.Fatalf("expected metrics on the given port, got %+v, %v", cfg, err)
+	}
+}
+
+func TestConfig_NeedsRestart(t *testing.T) {
+	writeConfig(t, "config.yaml", `
+telegram:
+  api_id: 12345
+  api_hash: 0123456789abcdef0123456789abcdef
+`)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("LOG_LEVEL", "warn")
+	t.Setenv("RATE_LIMIT_GLOBAL", "10/1s")
+	next, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.NeedsRestart(next) {
+		t.Fatal("expected the log level and rate limits to be reloadable")
+	}
+
+	t.Setenv("GRPC_PORT", "6000")
+	if next, _ = Load(); !cfg.NeedsRestart(next) {
+		t.Fatal("expected the port to need a restart")
+	}
+}
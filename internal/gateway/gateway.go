@@ -0,0 +1,377 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/zen-flo/telegram-service/internal/grpc"
+	api "github.com/zen-flo/telegram-service/pkg/api/proto"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// maxBodySize bounds JSON request bodies.
+const maxBodySize = 1 << 20
+
+var (
+	marshal   = protojson.MarshalOptions{UseProtoNames: true}
+	unmarshal = protojson.UnmarshalOptions{}
+)
+
+// Gateway serves the TelegramService as HTTP/JSON. Unary RPCs return
+// the JSON response, server-streaming RPCs send Server-Sent Events.
+// GET /v1/ws upgrades to a WebSocket multiplexing subscriptions and
+// sends. Requests are authenticated like gRPC calls: by the x-api-key
+// or authorization header, or by the client certificate.
+type Gateway struct {
+	handler   api.TelegramServiceServer
+	authorize grpc.Authorizer
+	logger    *zap.Logger
+	mux       *http.ServeMux
+
+	origins []string // origins allowed to open WebSockets, besides the own host
+	closed  context.Context
+	close   context.CancelFunc
+}
+
+func New(
+	handler api.TelegramServiceServer,
+	authorize grpc.Authorizer,
+	logger *zap.Logger,
+) *Gateway {
+
+	g := &Gateway{
+		handler:   handler,
+		authorize: authorize,
+		logger:    logger,
+		mux:       http.NewServeMux(),
+	}
+	g.closed, g.close = context.WithCancel(context.Background())
+
+	for _, r := range routes {
+		g.mux.HandleFunc(r.method+" "+r.path, func(w http.ResponseWriter, req *http.Request) {
+			g.serve(w, req, r)
+		})
+	}
+	g.mux.HandleFunc("GET "+wsPath, g.serveWebSocket)
+	g.mux.HandleFunc("GET /openapi.json", g.serveOpenAPI)
+	g.mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		writeError(w, status.Error(codes.NotFound, "no route for "+req.Method+" "+req.URL.Path))
+	})
+
+	return g
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mux.ServeHTTP(w, r)
+}
+
+// Close closes the WebSockets, which http.Server.Shutdown does not
+// track once upgraded.
+func (g *Gateway) Close() {
+	g.close()
+}
+
+func (g *Gateway) serve(w http.ResponseWriter, r *http.Request, rt route) {
+	span := trace.SpanFromContext(r.Context())
+	span.SetName(rt.method + " " + rt.path)
+	span.SetAttributes(semconv.HTTPRoute(rt.path))
+
+	ctx, err := g.authorize(incomingContext(r), rt.fullMethod())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	req, err := decodeRequest(r, rt)
+	if err != nil {
+		writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	if err := rt.call(ctx, g.handler, req, w); err != nil {
+		writeError(w, err)
+	}
+}
+
+// incomingContext returns the context of r as a gRPC server would see
+// it: headers as incoming metadata and the TLS state as the peer.
+func incomingContext(r *http.Request) context.Context {
+	md := metadata.MD{}
+	for _, key := range []string{"x-api-key", "authorization"} {
+		if v := r.Header.Values(key); len(v) > 0 {
+			md.Set(key, v...)
+		}
+	}
+	ctx := metadata.NewIncomingContext(r.Context(), md)
+
+	if r.TLS != nil {
+		ctx = peer.NewContext(ctx, &peer.Peer{
+			AuthInfo: credentials.TLSInfo{State: *r.TLS},
+		})
+	}
+	return ctx
+}
+
+// decodeRequest builds the request message of rt from the body, the
+// query string and the path, in increasing order of precedence.
+func decodeRequest(r *http.Request, rt route) (proto.Message, error) {
+	req := rt.request.New()
+
+	if rt.body {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+		if err != nil {
+			return nil, err
+		}
+		if len(strings.TrimSpace(string(body))) > 0 {
+			if err := unmarshal.Unmarshal(body, req.Interface()); err != nil {
+				return nil, fmt.Errorf("invalid body: %w", err)
+			}
+		}
+	} else {
+		for key, values := range r.URL.Query() {
+			if err := setQueryParam(req, key, values); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	fields := req.Descriptor().Fields()
+	for _, name := range pathParams(rt.path) {
+		fd := fields.ByName(protoreflect.Name(name))
+		req.Set(fd, protoreflect.ValueOfString(r.PathValue(name)))
+	}
+
+	return req.Interface(), nil
+}
+
+// pathParams returns the wildcard names of a path pattern.
+func pathParams(path string) []string {
+	var names []string
+	for _, seg := range strings.Split(path, "/") {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			names = append(names, seg[1:len(seg)-1])
+		}
+	}
+	return names
+}
+
+// setQueryParam sets a field from the query string. Repeated fields
+// take every value, map entries are passed as field[key]=value.
+func setQueryParam(req protoreflect.Message, key string, values []string) error {
+	name, mapKey, isMap := strings.Cut(key, "[")
+	if isMap {
+		if !strings.HasSuffix(mapKey, "]") {
+			return fmt.Errorf("invalid query parameter %q", key)
+		}
+		mapKey = strings.TrimSuffix(mapKey, "]")
+	}
+
+	fd := req.Descriptor().Fields().ByName(protoreflect.Name(name))
+	if fd == nil {
+		fd = req.Descriptor().Fields().ByJSONName(name)
+	}
+	if fd == nil {
+		return fmt.Errorf("unknown query parameter %q", name)
+	}
+
+	switch {
+	case fd.IsMap():
+		if !isMap {
+			return fmt.Errorf("query parameter %q must be passed as %s[key]=value", name, name)
+		}
+		v, err := parseScalar(fd.MapValue(), values[len(values)-1])
+		if err != nil {
+			return fmt.Errorf("query parameter %q: %w", key, err)
+		}
+		req.Mutable(fd).Map().Set(protoreflect.ValueOfString(mapKey).MapKey(), v)
+
+	case isMap:
+		return fmt.Errorf("query parameter %q is not a map", name)
+
+	case fd.IsList():
+		list := req.Mutable(fd).List()
+		for _, s := range values {
+			v, err := parseScalar(fd, s)
+			if err != nil {
+				return fmt.Errorf("query parameter %q: %w", key, err)
+			}
+			list.Append(v)
+		}
+
+	case fd.Kind() == protoreflect.MessageKind:
+		return fmt.Errorf("query parameter %q is a message, not supported", name)
+
+	default:
+		v, err := parseScalar(fd, values[len(values)-1])
+		if err != nil {
+			return fmt.Errorf("query parameter %q: %w", key, err)
+		}
+		req.Set(fd, v)
+	}
+
+	return nil
+}
+
+func parseScalar(fd protoreflect.FieldDescriptor, s string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(s)
+		return protoreflect.ValueOfBool(b), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfInt32(int32(n)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(s, 10, 64)
+		return protoreflect.ValueOfInt64(n), err
+	case protoreflect.EnumKind:
+		return parseEnum(fd.Enum(), s)
+	}
+	return protoreflect.Value{}, fmt.Errorf("unsupported type %s", fd.Kind())
+}
+
+// parseEnum accepts the value name with or without the enum prefix,
+// e.g. DELETE_MODE_DETACH or DETACH, or its number.
+func parseEnum(ed protoreflect.EnumDescriptor, s string) (protoreflect.Value, error) {
+	values := ed.Values()
+
+	if n, err := strconv.ParseInt(s, 10, 32); err == nil {
+		if v := values.ByNumber(protoreflect.EnumNumber(n)); v != nil {
+			return protoreflect.ValueOfEnum(v.Number()), nil
+		}
+	}
+
+	name := strings.ToUpper(s)
+	if v := values.ByName(protoreflect.Name(name)); v != nil {
+		return protoreflect.ValueOfEnum(v.Number()), nil
+	}
+	if v := values.ByName(protoreflect.Name(enumPrefix(ed) + name)); v != nil {
+		return protoreflect.ValueOfEnum(v.Number()), nil
+	}
+
+	return protoreflect.Value{}, fmt.Errorf("invalid %s %q", ed.Name(), s)
+}
+
+// enumPrefix returns the prefix of the value names of ed, e.g.
+// "DELETE_MODE_" for DeleteMode.
+func enumPrefix(ed protoreflect.EnumDescriptor) string {
+	return upperSnake(string(ed.Name())) + "_"
+}
+
+// upperSnake converts a CamelCase name to UPPER_SNAKE_CASE.
+func upperSnake(name string) string {
+	var b strings.Builder
+	lower := false
+	for _, r := range name {
+		upper := r >= 'A' && r <= 'Z'
+		if upper && lower {
+			b.WriteByte('_')
+		}
+		lower = !upper
+		b.WriteRune(r)
+	}
+	return strings.ToUpper(b.String())
+}
+
+func writeJSON(w http.ResponseWriter, code int, m proto.Message) {
+	body, err := marshal.Marshal(m)
+	if err != nil {
+		writeError(w, status.Error(codes.Internal, "failed to encode response"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(body)
+}
+
+// errorBody is the JSON body of failed requests.
+type errorBody struct {
+	Code    int    `json:"code"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+func errorOf(err error) errorBody {
+	st, ok := status.FromError(err)
+	if !ok {
+		st = status.New(codes.Internal, "internal error")
+	}
+	return errorBody{
+		Code:    int(st.Code()),
+		Status:  codeName(st.Code()),
+		Message: st.Message(),
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	body := errorOf(err)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus(codes.Code(body.Code)))
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// codeName returns the canonical name of c, e.g. NOT_FOUND.
+func codeName(c codes.Code) string {
+	return upperSnake(c.String())
+}
+
+// httpStatus maps a gRPC code to the HTTP status of the same meaning.
+func httpStatus(c codes.Code) int {
+	switch c {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.FailedPrecondition:
+		return http.StatusPreconditionFailed
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+func (g *Gateway) serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	spec, err := OpenAPI()
+	if err != nil {
+		g.logger.Error("failed to build openapi spec", zap.Error(err))
+		writeError(w, status.Error(codes.Internal, "failed to build openapi spec"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(spec)
+}
@@ -0,0 +1,302 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/zen-flo/telegram-service/internal/auth"
+	"github.com/zen-flo/telegram-service/internal/grpc"
+	api "github.com/zen-flo/telegram-service/pkg/api/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+type fakeService struct {
+	api.UnimplementedTelegramServiceServer
+
+	principal chan auth.Principal
+	// release, if set, holds SendMessage until it is closed.
+	release chan struct{}
+}
+
+func (f *fakeService) SendMessage(ctx context.Context, req *api.SendMessageRequest) (*api.SendMessageResponse, error) {
+	if p, ok := auth.FromContext(ctx); ok && f.principal != nil {
+		f.principal <- p
+	}
+	if f.release != nil {
+		select {
+		case <-f.release:
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	if req.GetSessionId() != "s1" || req.GetPeer() != "@durov" || req.GetText() != "hi" {
+		return nil, status.Errorf(codes.InvalidArgument, "unexpected request %v", req)
+	}
+	return &api.SendMessageResponse{MessageId: proto.Int64(42), Peer: proto.String("user:1")}, nil
+}
+
+func (f *fakeService) ListSessions(ctx context.Context, req *api.ListSessionsRequest) (*api.ListSessionsResponse, error) {
+	var sessions []*api.SessionInfo
+	for k, v := range req.GetLabelSelector() {
+		sessions = append(sessions, &api.SessionInfo{
+			SessionId: proto.String(k + "=" + v),
+		})
+	}
+	return &api.ListSessionsResponse{Sessions: sessions}, nil
+}
+
+func (f *fakeService) DeleteSession(ctx context.Context, req *api.DeleteSessionRequest) (*api.DeleteSessionResponse, error) {
+	if req.GetMode() != api.DeleteMode_DELETE_MODE_DETACH {
+		return nil, status.Errorf(codes.InvalidArgument, "unexpected mode %v", req.GetMode())
+	}
+	return &api.DeleteSessionResponse{}, nil
+}
+
+func (f *fakeService) GetSessionStatus(ctx context.Context, req *api.GetSessionStatusRequest) (*api.GetSessionStatusResponse, error) {
+	return nil, status.Error(codes.NotFound, "session not found")
+}
+
+func (f *fakeService) SubscribeMessages(req *api.SubscribeMessagesRequest, stream api.TelegramService_SubscribeMessagesServer) error {
+	if req.GetSessionId() != "s1" {
+		return status.Error(codes.NotFound, "session not found")
+	}
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+	for _, text := range []string{"one", "two"} {
+		if err := stream.Send(&api.MessageUpdate{SessionId: proto.String("s1"), Text: proto.String(text)}); err != nil {
+			return err
+		}
+	}
+	return status.Error(codes.Unavailable, "server is shutting down")
+}
+
+func newTestGateway(t *testing.T, service *fakeService, keys *auth.KeyStore) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(New(service, grpc.NewAuthorizer(keys, zap.NewNop()), zap.NewNop()))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func do(t *testing.T, method, url, body string, header http.Header) (*http.Response, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	b, _ := io.ReadAll(resp.Body)
+	return resp, string(b)
+}
+
+func TestGateway_Unary(t *testing.T) {
+	srv := newTestGateway(t, &fakeService{}, nil)
+
+	resp, body := do(t, http.MethodPost, srv.URL+"/v1/sessions/s1/messages", `{"peer":"@durov","text":"hi"}`, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+	}
+	if resp.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected content type %q", resp.Header.Get("Content-Type"))
+	}
+	// proto names, 64-bit integers as strings
+	var sent map[string]any
+	if err := json.Unmarshal([]byte(body), &sent); err != nil || sent["message_id"] != "42" {
+		t.Fatalf("unexpected body %s", body)
+	}
+
+	resp, body = do(t, http.MethodGet, srv.URL+"/v1/sessions?label_selector[env]=prod", "", nil)
+	var list api.ListSessionsResponse
+	if err := protojson.Unmarshal([]byte(body), &list); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected response %d: %s", resp.StatusCode, body)
+	}
+	if len(list.GetSessions()) != 1 || list.GetSessions()[0].GetSessionId() != "env=prod" {
+		t.Fatalf("expected the label selector from the query, got %s", body)
+	}
+
+	resp, body = do(t, http.MethodDelete, srv.URL+"/v1/sessions/s1?mode=DETACH", "", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the short enum name to be accepted, got %d: %s", resp.StatusCode, body)
+	}
+}
+
+func TestGateway_Errors(t *testing.T) {
+	srv := newTestGateway(t, &fakeService{}, nil)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantCode   int
+		wantStatus string
+	}{
+		{"rpc error", http.MethodGet, "/v1/sessions/s1", "", http.StatusNotFound, "NOT_FOUND"},
+		{"unknown query parameter", http.MethodGet, "/v1/sessions?foo=bar", "", http.StatusBadRequest, "INVALID_ARGUMENT"},
+		{"invalid enum", http.MethodDelete, "/v1/sessions/s1?mode=NOPE", "", http.StatusBadRequest, "INVALID_ARGUMENT"},
+		{"invalid body", http.MethodPost, "/v1/sessions/s1/messages", `{"peer":`, http.StatusBadRequest, "INVALID_ARGUMENT"},
+		{"unimplemented", http.MethodGet, "/v1/webhooks", "", http.StatusNotImplemented, "UNIMPLEMENTED"},
+		{"unknown route", http.MethodGet, "/v2/sessions", "", http.StatusNotFound, "NOT_FOUND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := do(t, tt.method, srv.URL+tt.path, tt.body, nil)
+			if resp.StatusCode != tt.wantCode {
+				t.Fatalf("expected %d, got %d: %s", tt.wantCode, resp.StatusCode, body)
+			}
+
+			var e errorBody
+			if err := json.Unmarshal([]byte(body), &e); err != nil {
+				t.Fatalf("invalid error body %q: %v", body, err)
+			}
+			if e.Status != tt.wantStatus {
+				t.Fatalf("expected status %s, got %s", tt.wantStatus, e.Status)
+			}
+		})
+	}
+}
+
+func TestGateway_Auth(t *testing.T) {
+	keys, err := auth.NewKeyStore([]auth.Key{
+		{Name: "reader", Secret: "r", Scopes: []auth.Scope{auth.ScopeSessionsRead}},
+		{Name: "sender", Secret: "s", Scopes: []auth.Scope{auth.ScopeMessagesSend}, Tenant: "acme"},
+	}, "", zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := &fakeService{principal: make(chan auth.Principal, 1)}
+	srv := newTestGateway(t, service, keys)
+	send := func(header http.Header) int {
+		resp, _ := do(t, http.MethodPost, srv.URL+"/v1/sessions/s1/messages", `{"peer":"@durov","text":"hi"}`, header)
+		return resp.StatusCode
+	}
+
+	if code := send(nil); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a key, got %d", code)
+	}
+	if code := send(http.Header{"X-Api-Key": {"r"}}); code != http.StatusForbidden {
+		t.Fatalf("expected 403 without the scope, got %d", code)
+	}
+	if code := send(http.Header{"Authorization": {"Bearer s"}}); code != http.StatusOK {
+		t.Fatalf("expected 200 with a bearer key, got %d", code)
+	}
+	if p := <-service.principal; p.Tenant != "acme" {
+		t.Fatalf("expected the principal of the key, got %+v", p)
+	}
+
+	// the spec is public
+	resp, _ := do(t, http.MethodGet, srv.URL+"/openapi.json", "", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the spec without a key, got %d", resp.StatusCode)
+	}
+}
+
+func TestGateway_ServerSentEvents(t *testing.T) {
+	srv := newTestGateway(t, &fakeService{}, nil)
+
+	resp, err := http.Get(srv.URL + "/v1/sessions/s1/messages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", resp.Header.Get("Content-Type"))
+	}
+
+	var texts []string
+	var event string
+	var last errorBody
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: ") && event == "error":
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &last); err != nil {
+				t.Fatal(err)
+			}
+		case strings.HasPrefix(line, "data: "):
+			var msg api.MessageUpdate
+			if err := protojson.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &msg); err != nil {
+				t.Fatal(err)
+			}
+			texts = append(texts, msg.GetText())
+		}
+	}
+
+	if strings.Join(texts, ",") != "one,two" {
+		t.Fatalf("expected two messages, got %v", texts)
+	}
+	if last.Status != "UNAVAILABLE" {
+		t.Fatalf("expected the stream error as an error event, got %+v", last)
+	}
+
+	// errors before the stream started keep their status
+	resp2, body := do(t, http.MethodGet, srv.URL+"/v1/sessions/nope/messages", "", nil)
+	if resp2.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", resp2.StatusCode, body)
+	}
+}
+
+func TestOpenAPI_UpToDate(t *testing.T) {
+	spec, err := OpenAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := os.ReadFile("../../pkg/api/proto/telegram.openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(spec) != string(stored) {
+		t.Fatal("telegram.openapi.json is out of date, run go generate ./internal/gateway")
+	}
+
+	var doc struct {
+		Paths map[string]map[string]struct {
+			OperationID string `json:"operationId"`
+		}
+	}
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	ops := make(map[string]bool)
+	for _, methods := range doc.Paths {
+		for _, op := range methods {
+			ops[op.OperationID] = true
+		}
+	}
+	methods := api.File_proto_telegram_proto.Services().ByName("TelegramService").Methods()
+	for i := range methods.Len() {
+		if name := string(methods.Get(i).Name()); !ops[name] {
+			t.Errorf("rpc %s has no route", name)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package gateway
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+
+	api "github.com/zen-flo/telegram-service/pkg/api/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+//go:generate go run ../../cmd/openapi -out ../../pkg/api/proto/telegram.openapi.json
+
+// OpenAPI returns the OpenAPI 3 spec of the gateway, built from the
+// routes and the descriptors of telegram.proto.
+func OpenAPI() ([]byte, error) {
+	paths := make(map[string]map[string]any)
+	for _, r := range routes {
+		if paths[r.path] == nil {
+			paths[r.path] = make(map[string]any)
+		}
+		paths[r.path][strings.ToLower(r.method)] = operation(r)
+	}
+
+	schemas := map[string]any{
+		"Error": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"code":    map[string]any{"type": "integer", "description": "gRPC status code"},
+				"status":  map[string]any{"type": "string", "example": "NOT_FOUND"},
+				"message": map[string]any{"type": "string"},
+			},
+		},
+	}
+	messages := api.File_proto_telegram_proto.Messages()
+	for i := range messages.Len() {
+		md := messages.Get(i)
+		schemas[string(md.Name())] = messageSchema(md)
+	}
+
+	spec := map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "Telegram Service",
+			"version": "v1",
+			"description": "HTTP/JSON gateway of the pact.telegram.TelegramService gRPC API. " +
+				"Fields use the proto names, 64-bit integers are strings.",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"securitySchemes": map[string]any{
+				"apiKey": map[string]any{"type": "apiKey", "in": "header", "name": "x-api-key"},
+				"bearer": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+		"security": []any{
+			map[string]any{"apiKey": []string{}},
+			map[string]any{"bearer": []string{}},
+		},
+	}
+
+	out, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+func operation(r route) map[string]any {
+	request := r.request.Descriptor()
+	inPath := pathParams(r.path)
+
+	var params []any
+	for _, name := range inPath {
+		params = append(params, map[string]any{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]any{"type": "string"},
+		})
+	}
+	if !r.body {
+		fields := request.Fields()
+		for i := range fields.Len() {
+			fd := fields.Get(i)
+			if slices.Contains(inPath, string(fd.Name())) {
+				continue
+			}
+
+			param := map[string]any{
+				"name":   string(fd.Name()),
+				"in":     "query",
+				"schema": fieldSchema(fd),
+			}
+			if fd.IsMap() {
+				param["style"] = "deepObject"
+				param["explode"] = true
+			}
+			params = append(params, param)
+		}
+	}
+
+	op := map[string]any{
+		"operationId": string(r.rpc.Name()),
+		"summary":     r.summary,
+		"tags":        []string{strings.Split(r.path, "/")[2]},
+		"responses": map[string]any{
+			"200": response(r),
+			"default": map[string]any{
+				"description": "Error",
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": ref("Error")},
+				},
+			},
+		},
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+	if r.body {
+		op["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": ref(string(request.Name()))},
+			},
+		}
+	}
+	return op
+}
+
+func response(r route) map[string]any {
+	name := string(r.response.Name())
+
+	if r.streaming() {
+		return map[string]any{
+			"description": "Server-Sent Events. The data of each event is a JSON " + name +
+				`; an error after the stream started is sent as an "error" event with a JSON Error.`,
+			"content": map[string]any{
+				"text/event-stream": map[string]any{"schema": ref(name)},
+			},
+		}
+	}
+
+	return map[string]any{
+		"description": "OK",
+		"content": map[string]any{
+			"application/json": map[string]any{"schema": ref(name)},
+		},
+	}
+}
+
+func messageSchema(md protoreflect.MessageDescriptor) map[string]any {
+	props := make(map[string]any)
+
+	fields := md.Fields()
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		props[string(fd.Name())] = fieldSchema(fd)
+	}
+
+	return map[string]any{
+		"type":       "object",
+		"properties": props,
+	}
+}
+
+func fieldSchema(fd protoreflect.FieldDescriptor) map[string]any {
+	switch {
+	case fd.IsMap():
+		return map[string]any{
+			"type":                 "object",
+			"additionalProperties": scalarSchema(fd.MapValue()),
+		}
+	case fd.IsList():
+		return map[string]any{
+			"type":  "array",
+			"items": scalarSchema(fd),
+		}
+	}
+	return scalarSchema(fd)
+}
+
+func scalarSchema(fd protoreflect.FieldDescriptor) map[string]any {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return map[string]any{"type": "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		// protojson encodes 64-bit integers as strings
+		return map[string]any{"type": "string", "format": "int64"}
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		names := make([]string, 0, values.Len())
+		for i := range values.Len() {
+			names = append(names, string(values.Get(i).Name()))
+		}
+		return map[string]any{"type": "string", "enum": names}
+	case protoreflect.MessageKind:
+		return ref(string(fd.Message().Name()))
+	}
+	return map[string]any{"type": "string"}
+}
+
+func ref(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
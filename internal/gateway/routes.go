@@ -0,0 +1,173 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+
+	api "github.com/zen-flo/telegram-service/pkg/api/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// route maps an HTTP method and path onto an RPC. Path wildcards name
+// fields of the request, other fields come from the JSON body (when
+// body is set) or the query string.
+type route struct {
+	method  string
+	path    string
+	summary string
+	body    bool
+
+	rpc      protoreflect.MethodDescriptor
+	request  protoreflect.MessageType
+	response protoreflect.MessageDescriptor
+
+	// call runs the RPC, writing the response or the events of
+	// a stream to w.
+	call func(ctx context.Context, h api.TelegramServiceServer, req proto.Message, w http.ResponseWriter) error
+}
+
+var routes = []route{
+	unary(http.MethodPost, "/v1/sessions", true,
+		"Create a session and return the QR login code.",
+		api.TelegramServiceServer.CreateSession),
+	unary(http.MethodGet, "/v1/sessions", false,
+		"List sessions, optionally filtered by labels.",
+		api.TelegramServiceServer.ListSessions),
+	unary(http.MethodGet, "/v1/sessions/{session_id}", false,
+		"Get the status of a session.",
+		api.TelegramServiceServer.GetSessionStatus),
+	unary(http.MethodDelete, "/v1/sessions/{session_id}", false,
+		"Delete a session. mode is LOGOUT (default), DETACH or PURGE_LOCAL.",
+		api.TelegramServiceServer.DeleteSession),
+	unary(http.MethodPost, "/v1/sessions/{session_id}/resume", false,
+		"Resume a detached session from its stored authorization.",
+		api.TelegramServiceServer.ResumeSession),
+	unary(http.MethodPost, "/v1/sessions/{session_id}/password", true,
+		"Submit the 2FA password of a QR login waiting for it.",
+		api.TelegramServiceServer.SubmitPassword),
+
+	unary(http.MethodPost, "/v1/sessions/{session_id}/messages", true,
+		"Send a message and wait for Telegram to accept it.",
+		api.TelegramServiceServer.SendMessage),
+	stream(http.MethodGet, "/v1/sessions/{session_id}/messages",
+		"Stream incoming messages and events of a session.",
+		api.TelegramServiceServer.SubscribeMessages),
+	unary(http.MethodGet, "/v1/sessions/{session_id}/history", false,
+		"Get messages of a chat, newest first.",
+		api.TelegramServiceServer.GetHistory),
+	unary(http.MethodPost, "/v1/sessions/{session_id}/jobs", true,
+		"Queue a message for sending and return the job ID.",
+		api.TelegramServiceServer.EnqueueMessage),
+	unary(http.MethodGet, "/v1/sessions/{session_id}/jobs/{job_id}", false,
+		"Get the status of a queued message.",
+		api.TelegramServiceServer.GetSendStatus),
+	stream(http.MethodGet, "/v1/sessions/{session_id}/deliveries",
+		"Stream status changes of queued messages.",
+		api.TelegramServiceServer.SubscribeDeliveries),
+
+	stream(http.MethodGet, "/v1/messages",
+		"Stream incoming messages of several sessions.",
+		api.TelegramServiceServer.SubscribeAll),
+	unary(http.MethodGet, "/v1/status", false,
+		"Get the session usage and limits.",
+		api.TelegramServiceServer.GetServiceStatus),
+
+	unary(http.MethodPost, "/v1/webhooks", true,
+		"Register a webhook for incoming messages.",
+		api.TelegramServiceServer.RegisterWebhook),
+	unary(http.MethodGet, "/v1/webhooks", false,
+		"List webhooks.",
+		api.TelegramServiceServer.ListWebhooks),
+	unary(http.MethodDelete, "/v1/webhooks/{webhook_id}", false,
+		"Delete a webhook.",
+		api.TelegramServiceServer.DeleteWebhook),
+}
+
+func unary[Req, Resp proto.Message](
+	method, path string,
+	body bool,
+	summary string,
+	fn func(api.TelegramServiceServer, context.Context, Req) (Resp, error),
+) route {
+
+	var req Req
+	var resp Resp
+
+	return route{
+		method:   method,
+		path:     path,
+		summary:  summary,
+		body:     body,
+		rpc:      methodOf(req.ProtoReflect().Descriptor()),
+		request:  req.ProtoReflect().Type(),
+		response: resp.ProtoReflect().Descriptor(),
+		call: func(ctx context.Context, h api.TelegramServiceServer, m proto.Message, w http.ResponseWriter) error {
+			resp, err := fn(h, ctx, m.(Req))
+			if err != nil {
+				return err
+			}
+			writeJSON(w, http.StatusOK, resp)
+			return nil
+		},
+	}
+}
+
+// stream maps a server-streaming RPC onto Server-Sent Events.
+func stream[Req proto.Message, Resp any, PResp interface {
+	*Resp
+	proto.Message
+}](
+	method, path string,
+	summary string,
+	fn func(api.TelegramServiceServer, Req, grpc.ServerStreamingServer[Resp]) error,
+) route {
+
+	var req Req
+
+	return route{
+		method:   method,
+		path:     path,
+		summary:  summary,
+		rpc:      methodOf(req.ProtoReflect().Descriptor()),
+		request:  req.ProtoReflect().Type(),
+		response: PResp(new(Resp)).ProtoReflect().Descriptor(),
+		call: func(ctx context.Context, h api.TelegramServiceServer, m proto.Message, w http.ResponseWriter) error {
+			s := newEventStream[Resp](ctx, w)
+			defer s.close()
+
+			err := fn(h, m.(Req), s)
+			if err != nil && s.started() {
+				// Too late for an HTTP status.
+				s.sendError(err)
+				return nil
+			}
+			return err
+		},
+	}
+}
+
+// methodOf returns the RPC of the TelegramService taking req.
+func methodOf(req protoreflect.MessageDescriptor) protoreflect.MethodDescriptor {
+	methods := api.File_proto_telegram_proto.Services().
+		ByName("TelegramService").
+		Methods()
+
+	for i := range methods.Len() {
+		if methods.Get(i).Input() == req {
+			return methods.Get(i)
+		}
+	}
+	panic("gateway: no rpc takes " + req.FullName())
+}
+
+// fullMethod returns the gRPC method name of r, e.g.
+// "/pact.telegram.TelegramService/SendMessage".
+func (r route) fullMethod() string {
+	return "/" + string(r.rpc.Parent().FullName()) + "/" + string(r.rpc.Name())
+}
+
+func (r route) streaming() bool {
+	return r.rpc.IsStreamingServer()
+}
@@ -0,0 +1,112 @@
+package gateway
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.uber.org/zap"
+)
+
+type ServerOptions struct {
+	// TLS serves over TLS when set.
+	TLS *tls.Config
+	// CORSOrigins are the origins browsers may call the gateway and
+	// open WebSockets from, "*" allows any.
+	CORSOrigins []string
+}
+
+// Server serves a Gateway over HTTP.
+type Server struct {
+	httpServer *http.Server
+	gateway    *Gateway
+	logger     *zap.Logger
+	port       int
+	tls        bool
+}
+
+func NewServer(port int, gateway *Gateway, logger *zap.Logger, opts ServerOptions) *Server {
+	gateway.origins = opts.CORSOrigins
+
+	var handler http.Handler = gateway
+	if len(opts.CORSOrigins) > 0 {
+		handler = cors(opts.CORSOrigins, handler)
+	}
+	handler = otelhttp.NewHandler(handler, "gateway")
+
+	return &Server{
+		httpServer: &http.Server{
+			Handler:           handler,
+			TLSConfig:         opts.TLS,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+		gateway: gateway,
+		logger:  logger,
+		port:    port,
+		tls:     opts.TLS != nil,
+	}
+}
+
+// Start serves until Shutdown is called.
+func (s *Server) Start() error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	s.logger.Info("http gateway started", zap.Int("port", s.port), zap.Bool("tls", s.tls))
+
+	if s.tls {
+		err = s.httpServer.ServeTLS(lis, "", "")
+	} else {
+		err = s.httpServer.Serve(lis)
+	}
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops accepting requests and waits for active ones until
+// ctx is done. WebSockets are closed right away, event streams end when
+// the handler is closed.
+func (s *Server) Shutdown(ctx context.Context) {
+	s.gateway.Close()
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		s.logger.Warn("http gateway did not stop in time", zap.Error(err))
+		_ = s.httpServer.Close()
+	}
+}
+
+// cors allows browsers on origins to call next.
+func cors(origins []string, next http.Handler) http.Handler {
+	anyOrigin := slices.Contains(origins, "*")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || (!anyOrigin && !slices.Contains(origins, origin)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Add("Vary", "Origin")
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", "GET, POST, DELETE")
+			h.Set("Access-Control-Allow-Headers", "Authorization, Content-Type, X-Api-Key")
+			h.Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,160 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// keepAliveInterval is how often comments are sent on idle streams, so
+// proxies do not close them.
+const keepAliveInterval = 15 * time.Second
+
+var errNotSupported = errors.New("not supported by the http gateway")
+
+// eventStream adapts http.ResponseWriter to a gRPC server stream sending
+// every message as a Server-Sent Event. The HTTP status is written on
+// the first message or SendHeader, so errors returned before that are
+// still reported with a status code.
+type eventStream[T any] struct {
+	ctx context.Context
+	w   http.ResponseWriter
+	rc  *http.ResponseController
+
+	mu      sync.Mutex
+	header  bool
+	stopped chan struct{}
+	done    sync.WaitGroup
+}
+
+func newEventStream[T any](ctx context.Context, w http.ResponseWriter) *eventStream[T] {
+	s := &eventStream[T]{
+		ctx:     ctx,
+		w:       w,
+		rc:      http.NewResponseController(w),
+		stopped: make(chan struct{}),
+	}
+
+	s.done.Add(1)
+	go s.keepAlive()
+
+	return s
+}
+
+func (s *eventStream[T]) keepAlive() {
+	defer s.done.Done()
+
+	ticker := time.NewTicker(keepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopped:
+			return
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		if s.header {
+			_, _ = fmt.Fprint(s.w, ": keep-alive\n\n")
+			_ = s.rc.Flush()
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *eventStream[T]) close() {
+	close(s.stopped)
+	s.done.Wait()
+}
+
+func (s *eventStream[T]) started() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.header
+}
+
+// writeHeaderLocked commits the event stream headers.
+func (s *eventStream[T]) writeHeaderLocked() {
+	if s.header {
+		return
+	}
+	s.header = true
+
+	h := s.w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no")
+	s.w.WriteHeader(http.StatusOK)
+}
+
+func (s *eventStream[T]) writeEvent(event string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.writeHeaderLocked()
+
+	if event != "" {
+		if _, err := fmt.Fprintf(s.w, "event: %s\n", event); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(s.w, "data: %s\n\n", data); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+func (s *eventStream[T]) Send(m *T) error {
+	data, err := marshal.Marshal(any(m).(proto.Message))
+	if err != nil {
+		return err
+	}
+	return s.writeEvent("", data)
+}
+
+// sendError reports an error of the RPC as an "error" event.
+func (s *eventStream[T]) sendError(err error) {
+	data, _ := json.Marshal(errorOf(err))
+	_ = s.writeEvent("error", data)
+}
+
+func (s *eventStream[T]) Context() context.Context {
+	return s.ctx
+}
+
+func (s *eventStream[T]) SendHeader(metadata.MD) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.writeHeaderLocked()
+	return s.rc.Flush()
+}
+
+func (s *eventStream[T]) SetHeader(metadata.MD) error {
+	return nil
+}
+
+func (s *eventStream[T]) SetTrailer(metadata.MD) {}
+
+func (s *eventStream[T]) SendMsg(m any) error {
+	msg, ok := m.(*T)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", m)
+	}
+	return s.Send(msg)
+}
+
+func (s *eventStream[T]) RecvMsg(any) error {
+	return errNotSupported
+}
@@ -0,0 +1,417 @@
+package gateway
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	api "github.com/zen-flo/telegram-service/pkg/api/proto"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Frame types of the WebSocket protocol. Clients send subscribe,
+// subscribe_all, unsubscribe and send frames; the server answers with
+// subscribed, message, unsubscribed, result and error frames carrying
+// the id of the request.
+const (
+	frameSubscribe    = "subscribe"     // SubscribeMessages
+	frameSubscribeAll = "subscribe_all" // SubscribeAll
+	frameUnsubscribe  = "unsubscribe"
+	frameSend         = "send" // SendMessage
+
+	frameSubscribed   = "subscribed"
+	frameMessage      = "message"
+	frameUnsubscribed = "unsubscribed"
+	frameResult       = "result"
+	frameError        = "error"
+)
+
+// wsPath is the route of the WebSocket endpoint.
+const wsPath = "/v1/ws"
+
+// Subprotocols of the WebSocket endpoint. Browsers cannot set headers
+// on WebSocket requests, so they offer wsKeyProtocol followed by the
+// API key in unpadded base64url next to wsProtocol. Unlike a query
+// parameter, the key does not end up in URLs and access logs.
+const (
+	wsProtocol    = "telegram.v1"
+	wsKeyProtocol = "base64url.api-key."
+)
+
+// writeTimeout bounds writing a frame to a slow client.
+const writeTimeout = 10 * time.Second
+
+// maxSubscriptions bounds the subscriptions of a connection.
+const maxSubscriptions = 32
+
+// maxSends bounds the send frames of a connection waiting for their
+// result, e.g. for the rate limiter.
+const maxSends = 16
+
+// frame is a message of the WebSocket protocol. Request and Message
+// hold protojson of the request or response of the RPC.
+type frame struct {
+	Type     string          `json:"type"`
+	ID       string          `json:"id,omitempty"`
+	Request  json.RawMessage `json:"request,omitempty"`
+	Message  json.RawMessage `json:"message,omitempty"`
+	Response json.RawMessage `json:"response,omitempty"`
+	Error    *errorBody      `json:"error,omitempty"`
+}
+
+// socket is a WebSocket connection. Every subscription runs the
+// stream handler of its RPC in its own goroutine.
+type socket struct {
+	g    *Gateway
+	conn *websocket.Conn
+	ctx  context.Context // carries the credentials of the upgrade request
+
+	mu    sync.Mutex
+	subs  map[string]context.CancelFunc
+	sends chan struct{} // a slot of maxSends for every send in flight
+	wg    sync.WaitGroup
+}
+
+// serveWebSocket upgrades the request. Credentials are taken from the
+// headers as for other routes or from the subprotocols, see
+// wsKeyProtocol. They are checked on upgrade and the scopes again for
+// every frame.
+func (g *Gateway) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	span := trace.SpanFromContext(r.Context())
+	span.SetName("GET " + wsPath)
+	span.SetAttributes(semconv.HTTPRoute(wsPath))
+
+	ctx := incomingContext(r)
+	key, err := protocolKey(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if key != "" {
+		md, _ := metadata.FromIncomingContext(ctx)
+		md = md.Copy()
+		md.Set("x-api-key", key)
+		ctx = metadata.NewIncomingContext(ctx, md)
+	}
+
+	// A key lacking the scope of SubscribeMessages may still send.
+	_, err = g.authorize(ctx, api.TelegramService_SubscribeMessages_FullMethodName)
+	if status.Code(err) == codes.Unauthenticated {
+		writeError(w, err)
+		return
+	}
+
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		OriginPatterns: g.origins,
+		Subprotocols:   []string{wsProtocol},
+	})
+	if err != nil {
+		// Accept has written the response.
+		return
+	}
+	conn.SetReadLimit(maxBodySize)
+
+	// The socket outlives ServeHTTP, so it is closed with the gateway.
+	// Cancelling a read would close it without a status.
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+	stop := context.AfterFunc(g.closed, func() {
+		_ = conn.Close(websocket.StatusGoingAway, "server is shutting down")
+	})
+	defer stop()
+
+	s := &socket{
+		g:     g,
+		conn:  conn,
+		ctx:   ctx,
+		subs:  make(map[string]context.CancelFunc),
+		sends: make(chan struct{}, maxSends),
+	}
+	err = s.run(ctx)
+
+	cancel()
+	s.wg.Wait()
+
+	if websocket.CloseStatus(err) == -1 && g.closed.Err() == nil {
+		g.logger.Debug("websocket closed", zap.Error(err))
+		_ = conn.Close(websocket.StatusInternalError, "")
+		return
+	}
+	_ = conn.CloseNow()
+}
+
+// protocolKey returns the API key offered as a subprotocol of r, or ""
+// when there is none.
+func protocolKey(r *http.Request) (string, error) {
+	for _, header := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, p := range strings.Split(header, ",") {
+			encoded, ok := strings.CutPrefix(strings.TrimSpace(p), wsKeyProtocol)
+			if !ok {
+				continue
+			}
+			key, err := base64.RawURLEncoding.DecodeString(encoded)
+			if err != nil {
+				return "", status.Error(codes.Unauthenticated, "api key subprotocol must be unpadded base64url")
+			}
+			return string(key), nil
+		}
+	}
+	return "", nil
+}
+
+// run reads frames until the connection or ctx is closed.
+func (s *socket) run(ctx context.Context) error {
+	go s.keepAlive(ctx)
+
+	for {
+		typ, data, err := s.conn.Read(ctx)
+		if err != nil {
+			return err
+		}
+		if typ != websocket.MessageText {
+			s.writeError("", status.Error(codes.InvalidArgument, "frames must be JSON text"))
+			continue
+		}
+
+		var f frame
+		if err := json.Unmarshal(data, &f); err != nil {
+			s.writeError("", status.Error(codes.InvalidArgument, "invalid frame: "+err.Error()))
+			continue
+		}
+		s.handle(f)
+	}
+}
+
+func (s *socket) keepAlive(ctx context.Context) {
+	ticker := time.NewTicker(keepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, writeTimeout)
+		err := s.conn.Ping(pingCtx)
+		cancel()
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *socket) handle(f frame) {
+	switch f.Type {
+	case frameSubscribe:
+		subscribe(s, f, api.TelegramServiceServer.SubscribeMessages)
+	case frameSubscribeAll:
+		subscribe(s, f, api.TelegramServiceServer.SubscribeAll)
+	case frameUnsubscribe:
+		s.unsubscribe(f.ID)
+	case frameSend:
+		s.send(f)
+	default:
+		s.writeError(f.ID, status.Errorf(codes.InvalidArgument, "unknown frame type %q", f.Type))
+	}
+}
+
+// authorize checks the credentials of the socket for the RPC taking req.
+func (s *socket) authorize(req proto.Message) (context.Context, error) {
+	rt := route{rpc: methodOf(req.ProtoReflect().Descriptor())}
+	return s.g.authorize(s.ctx, rt.fullMethod())
+}
+
+func (s *socket) send(f frame) {
+	req := &api.SendMessageRequest{}
+	if err := decodeFrameRequest(f, req); err != nil {
+		s.writeError(f.ID, err)
+		return
+	}
+
+	ctx, err := s.authorize(req)
+	if err != nil {
+		s.writeError(f.ID, err)
+		return
+	}
+
+	select {
+	case s.sends <- struct{}{}:
+	default:
+		s.writeError(f.ID, status.Errorf(codes.ResourceExhausted, "at most %d sends in flight per connection", maxSends))
+		return
+	}
+
+	// Sends may wait for the rate limiter, so they do not block reading.
+	s.wg.Go(func() {
+		defer func() { <-s.sends }()
+
+		resp, err := s.g.handler.SendMessage(ctx, req)
+		if err != nil {
+			s.writeError(f.ID, err)
+			return
+		}
+
+		data, err := marshal.Marshal(resp)
+		if err != nil {
+			s.writeError(f.ID, status.Error(codes.Internal, "failed to encode response"))
+			return
+		}
+		_ = s.write(frame{Type: frameResult, ID: f.ID, Response: data})
+	})
+}
+
+func subscribe[Req proto.Message, Resp any](
+	s *socket,
+	f frame,
+	fn func(api.TelegramServiceServer, Req, grpc.ServerStreamingServer[Resp]) error,
+) {
+
+	if f.ID == "" {
+		s.writeError("", status.Error(codes.InvalidArgument, "subscriptions need an id"))
+		return
+	}
+
+	var zero Req
+	req := zero.ProtoReflect().New().Interface().(Req)
+	if err := decodeFrameRequest(f, req); err != nil {
+		s.writeError(f.ID, err)
+		return
+	}
+
+	ctx, err := s.authorize(req)
+	if err != nil {
+		s.writeError(f.ID, err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	s.mu.Lock()
+	if _, ok := s.subs[f.ID]; ok {
+		s.mu.Unlock()
+		cancel()
+		s.writeError(f.ID, status.Errorf(codes.AlreadyExists, "subscription %q already exists", f.ID))
+		return
+	}
+	if len(s.subs) >= maxSubscriptions {
+		s.mu.Unlock()
+		cancel()
+		s.writeError(f.ID, status.Errorf(codes.ResourceExhausted, "at most %d subscriptions per connection", maxSubscriptions))
+		return
+	}
+	s.subs[f.ID] = cancel
+	s.mu.Unlock()
+
+	s.wg.Go(func() {
+		err := fn(s.g.handler, req, &socketStream[Resp]{socket: s, ctx: ctx, id: f.ID})
+
+		// the id is free once the client learns the subscription ended
+		s.mu.Lock()
+		delete(s.subs, f.ID)
+		s.mu.Unlock()
+		cancel()
+
+		if err != nil {
+			s.writeError(f.ID, err)
+			return
+		}
+		_ = s.write(frame{Type: frameUnsubscribed, ID: f.ID})
+	})
+}
+
+func (s *socket) unsubscribe(id string) {
+	s.mu.Lock()
+	cancel, ok := s.subs[id]
+	s.mu.Unlock()
+
+	if !ok {
+		s.writeError(id, status.Errorf(codes.NotFound, "subscription %q not found", id))
+		return
+	}
+	cancel()
+}
+
+func decodeFrameRequest(f frame, req proto.Message) error {
+	if len(f.Request) == 0 {
+		return nil
+	}
+	if err := unmarshal.Unmarshal(f.Request, req); err != nil {
+		return status.Error(codes.InvalidArgument, "invalid request: "+err.Error())
+	}
+	return nil
+}
+
+func (s *socket) write(f frame) error {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, writeTimeout)
+	defer cancel()
+
+	return s.conn.Write(ctx, websocket.MessageText, data)
+}
+
+func (s *socket) writeError(id string, err error) {
+	body := errorOf(err)
+	_ = s.write(frame{Type: frameError, ID: id, Error: &body})
+}
+
+// socketStream is the server stream of a subscription, sending every
+// message as a frame.
+type socketStream[T any] struct {
+	socket *socket
+	ctx    context.Context
+	id     string
+}
+
+func (s *socketStream[T]) Send(m *T) error {
+	data, err := marshal.Marshal(any(m).(proto.Message))
+	if err != nil {
+		return err
+	}
+	return s.socket.write(frame{Type: frameMessage, ID: s.id, Message: data})
+}
+
+func (s *socketStream[T]) Context() context.Context {
+	return s.ctx
+}
+
+// SendHeader confirms the subscription.
+func (s *socketStream[T]) SendHeader(metadata.MD) error {
+	return s.socket.write(frame{Type: frameSubscribed, ID: s.id})
+}
+
+func (s *socketStream[T]) SetHeader(metadata.MD) error {
+	return nil
+}
+
+func (s *socketStream[T]) SetTrailer(metadata.MD) {}
+
+func (s *socketStream[T]) SendMsg(m any) error {
+	msg, ok := m.(*T)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", m)
+	}
+	return s.Send(msg)
+}
+
+func (s *socketStream[T]) RecvMsg(any) error {
+	return errNotSupported
+}
@@ -0,0 +1,254 @@
+package gateway
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/zen-flo/telegram-service/internal/auth"
+	"github.com/zen-flo/telegram-service/internal/grpc"
+	api "github.com/zen-flo/telegram-service/pkg/api/proto"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// SubscribeAll streams until the subscription is cancelled.
+func (f *fakeService) SubscribeAll(req *api.SubscribeAllRequest, stream api.TelegramService_SubscribeAllServer) error {
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+	<-stream.Context().Done()
+	return nil
+}
+
+func dialWS(t *testing.T, g *Gateway, protocols ...string) *websocket.Conn {
+	t.Helper()
+
+	srv := httptest.NewServer(g)
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http")+wsPath, &websocket.DialOptions{
+		Subprotocols: protocols,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.CloseNow() })
+	return conn
+}
+
+func writeFrame(t *testing.T, conn *websocket.Conn, f string) {
+	t.Helper()
+
+	if err := conn.Write(context.Background(), websocket.MessageText, []byte(f)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFrame(t *testing.T, conn *websocket.Conn) frame {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, data, err := conn.Read(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f frame
+	if err := json.Unmarshal(data, &f); err != nil {
+		t.Fatalf("invalid frame %s: %v", data, err)
+	}
+	return f
+}
+
+func TestWebSocket_SubscribeAndSend(t *testing.T) {
+	conn := dialWS(t, New(&fakeService{}, grpc.NewAuthorizer(nil, zap.NewNop()), zap.NewNop()))
+
+	writeFrame(t, conn, `{"type":"subscribe","id":"a","request":{"session_id":"s1"}}`)
+
+	if f := readFrame(t, conn); f.Type != frameSubscribed || f.ID != "a" {
+		t.Fatalf("expected the subscription to be confirmed, got %+v", f)
+	}
+	for _, want := range []string{"one", "two"} {
+		f := readFrame(t, conn)
+		var msg api.MessageUpdate
+		if err := protojson.Unmarshal(f.Message, &msg); err != nil || f.Type != frameMessage || f.ID != "a" {
+			t.Fatalf("expected a message, got %+v", f)
+		}
+		if msg.GetText() != want {
+			t.Fatalf("expected %q, got %q", want, msg.GetText())
+		}
+	}
+	if f := readFrame(t, conn); f.Type != frameError || f.ID != "a" || f.Error.Status != "UNAVAILABLE" {
+		t.Fatalf("expected the stream error, got %+v", f)
+	}
+
+	writeFrame(t, conn, `{"type":"send","id":"b","request":{"session_id":"s1","peer":"@durov","text":"hi"}}`)
+
+	f := readFrame(t, conn)
+	var sent api.SendMessageResponse
+	if err := protojson.Unmarshal(f.Response, &sent); err != nil || f.Type != frameResult || f.ID != "b" {
+		t.Fatalf("expected the send result, got %+v", f)
+	}
+	if sent.GetMessageId() != 42 {
+		t.Fatalf("unexpected response %v", &sent)
+	}
+
+	writeFrame(t, conn, `{"type":"nope","id":"c"}`)
+	if f := readFrame(t, conn); f.Type != frameError || f.Error.Status != "INVALID_ARGUMENT" {
+		t.Fatalf("expected an error for an unknown frame, got %+v", f)
+	}
+}
+
+func TestWebSocket_Unsubscribe(t *testing.T) {
+	g := New(&fakeService{}, grpc.NewAuthorizer(nil, zap.NewNop()), zap.NewNop())
+	conn := dialWS(t, g)
+
+	writeFrame(t, conn, `{"type":"subscribe_all","id":"a","request":{"label_selector":{"env":"prod"}}}`)
+	if f := readFrame(t, conn); f.Type != frameSubscribed {
+		t.Fatalf("expected the subscription to be confirmed, got %+v", f)
+	}
+
+	writeFrame(t, conn, `{"type":"subscribe_all","id":"a"}`)
+	if f := readFrame(t, conn); f.Type != frameError || f.Error.Status != "ALREADY_EXISTS" {
+		t.Fatalf("expected a duplicate id to be rejected, got %+v", f)
+	}
+
+	writeFrame(t, conn, `{"type":"unsubscribe","id":"a"}`)
+	if f := readFrame(t, conn); f.Type != frameUnsubscribed || f.ID != "a" {
+		t.Fatalf("expected the subscription to end, got %+v", f)
+	}
+
+	writeFrame(t, conn, `{"type":"subscribe_all","id":"b"}`)
+	if f := readFrame(t, conn); f.Type != frameSubscribed {
+		t.Fatalf("expected the subscription to be confirmed, got %+v", f)
+	}
+
+	// closing the gateway closes the socket
+	g.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, _, err := conn.Read(ctx); websocket.CloseStatus(err) != websocket.StatusGoingAway {
+		t.Fatalf("expected the socket to go away, got %v", err)
+	}
+}
+
+func TestWebSocket_Auth(t *testing.T) {
+	keys, err := auth.NewKeyStore([]auth.Key{
+		{Name: "sender", Secret: "s", Scopes: []auth.Scope{auth.ScopeMessagesSend}},
+	}, "", zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := New(&fakeService{}, grpc.NewAuthorizer(keys, zap.NewNop()), zap.NewNop())
+
+	srv := httptest.NewServer(g)
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + wsPath
+	for name, opts := range map[string]*websocket.DialOptions{
+		"no key":       nil,
+		"query":        nil,
+		"invalid key":  {Subprotocols: []string{wsProtocol, wsKeyProtocol + "s=="}},
+		"wrong secret": {Subprotocols: []string{wsProtocol, wsKeyProtocol + base64.RawURLEncoding.EncodeToString([]byte("x"))}},
+	} {
+		u := url
+		if name == "query" {
+			// keys in the query string would leak into logs
+			u += "?api_key=s"
+		}
+		_, resp, err := websocket.Dial(context.Background(), u, opts)
+		if err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("%s: expected 401, got %v", name, err)
+		}
+	}
+
+	conn := dialWS(t, g, wsProtocol, wsKeyProtocol+base64.RawURLEncoding.EncodeToString([]byte("s")))
+	if conn.Subprotocol() != wsProtocol {
+		t.Fatalf("expected the %s subprotocol, got %q", wsProtocol, conn.Subprotocol())
+	}
+
+	writeFrame(t, conn, `{"type":"subscribe","id":"a","request":{"session_id":"s1"}}`)
+	if f := readFrame(t, conn); f.Type != frameError || f.Error.Status != "PERMISSION_DENIED" {
+		t.Fatalf("expected the scope to be checked, got %+v", f)
+	}
+
+	writeFrame(t, conn, `{"type":"send","id":"b","request":{"session_id":"s1","peer":"@durov","text":"hi"}}`)
+	if f := readFrame(t, conn); f.Type != frameResult {
+		t.Fatalf("expected the send to be allowed, got %+v", f)
+	}
+}
+
+func TestWebSocket_SubscriptionLimit(t *testing.T) {
+	conn := dialWS(t, New(&fakeService{}, grpc.NewAuthorizer(nil, zap.NewNop()), zap.NewNop()))
+
+	for i := range maxSubscriptions {
+		writeFrame(t, conn, fmt.Sprintf(`{"type":"subscribe_all","id":"%d"}`, i))
+		if f := readFrame(t, conn); f.Type != frameSubscribed {
+			t.Fatalf("expected the subscription to be confirmed, got %+v", f)
+		}
+	}
+
+	writeFrame(t, conn, `{"type":"subscribe_all","id":"over"}`)
+	if f := readFrame(t, conn); f.Type != frameError || f.ID != "over" || f.Error.Status != "RESOURCE_EXHAUSTED" {
+		t.Fatalf("expected the subscription to be rejected, got %+v", f)
+	}
+
+	writeFrame(t, conn, `{"type":"unsubscribe","id":"0"}`)
+	if f := readFrame(t, conn); f.Type != frameUnsubscribed {
+		t.Fatalf("expected the subscription to end, got %+v", f)
+	}
+	writeFrame(t, conn, `{"type":"subscribe_all","id":"over"}`)
+	if f := readFrame(t, conn); f.Type != frameSubscribed {
+		t.Fatalf("expected a free slot after unsubscribe, got %+v", f)
+	}
+}
+
+func TestWebSocket_SendLimit(t *testing.T) {
+	service := &fakeService{release: make(chan struct{})}
+	conn := dialWS(t, New(service, grpc.NewAuthorizer(nil, zap.NewNop()), zap.NewNop()))
+
+	const send = `{"type":"send","id":"%s","request":{"session_id":"s1","peer":"@durov","text":"hi"}}`
+
+	// sends waiting for the rate limiter hold their slot
+	for i := range maxSends {
+		writeFrame(t, conn, fmt.Sprintf(send, fmt.Sprint(i)))
+	}
+	writeFrame(t, conn, fmt.Sprintf(send, "over"))
+	if f := readFrame(t, conn); f.Type != frameError || f.ID != "over" || f.Error.Status != "RESOURCE_EXHAUSTED" {
+		t.Fatalf("expected the send to be rejected, got %+v", f)
+	}
+
+	close(service.release)
+	for range maxSends {
+		if f := readFrame(t, conn); f.Type != frameResult {
+			t.Fatalf("expected a result, got %+v", f)
+		}
+	}
+
+	// a slot is freed right after its result is written
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		writeFrame(t, conn, fmt.Sprintf(send, "after"))
+		f := readFrame(t, conn)
+		if f.Type == frameResult && f.ID == "after" {
+			break
+		}
+		if f.Type != frameError || f.Error.Status != "RESOURCE_EXHAUSTED" || time.Now().After(deadline) {
+			t.Fatalf("expected a free slot once the sends finished, got %+v", f)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,184 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/zen-flo/telegram-service/internal/auth"
+	api "github.com/zen-flo/telegram-service/pkg/api/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// methodScopes is the scope required by each RPC. Methods missing
+// from the map require auth.ScopeAll.
+var methodScopes = map[string]auth.Scope{
+	api.TelegramService_CreateSession_FullMethodName:    auth.ScopeSessionsCreate,
+	api.TelegramService_ResumeSession_FullMethodName:    auth.ScopeSessionsCreate,
+	api.TelegramService_SubmitPassword_FullMethodName:   auth.ScopeSessionsCreate,
+	api.TelegramService_DeleteSession_FullMethodName:    auth.ScopeSessionsDelete,
+	api.TelegramService_GetSessionStatus_FullMethodName: auth.ScopeSessionsRead,
+	api.TelegramService_ListSessions_FullMethodName:     auth.ScopeSessionsRead,
+	api.TelegramService_GetServiceStatus_FullMethodName: auth.ScopeSessionsRead,
+
+	api.TelegramService_SendMessage_FullMethodName:         auth.ScopeMessagesSend,
+	api.TelegramService_EnqueueMessage_FullMethodName:      auth.ScopeMessagesSend,
+	api.TelegramService_GetSendStatus_FullMethodName:       auth.ScopeMessagesSend,
+	api.TelegramService_SubscribeDeliveries_FullMethodName: auth.ScopeMessagesSend,
+	api.TelegramService_SubscribeMessages_FullMethodName:   auth.ScopeMessagesRead,
+	api.TelegramService_SubscribeAll_FullMethodName:        auth.ScopeMessagesRead,
+	api.TelegramService_GetHistory_FullMethodName:          auth.ScopeMessagesRead,
+
+	api.TelegramService_RegisterWebhook_FullMethodName: auth.ScopeWebhooks,
+	api.TelegramService_DeleteWebhook_FullMethodName:   auth.ScopeWebhooks,
+	api.TelegramService_ListWebhooks_FullMethodName:    auth.ScopeWebhooks,
+}
+
+// publicServices are served without an API key.
+var publicServices = []string{
+	"/grpc.reflection.",
+	"/grpc.health.v1.",
+}
+
+func isPublic(method string) bool {
+	for _, prefix := range publicServices {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorizer authenticates calls that reach the handler through
+// another transport, such as the HTTP gateway, as the interceptors do.
+// ctx carries the credentials as incoming metadata and, for client
+// certificates, a peer with credentials.TLSInfo.
+type Authorizer func(ctx context.Context, method string) (context.Context, error)
+
+// NewAuthorizer returns an Authorizer checking keys. Without keys,
+// calls are not authenticated.
+func NewAuthorizer(keys *auth.KeyStore, logger *zap.Logger) Authorizer {
+	if keys == nil || !keys.Enabled() {
+		return func(ctx context.Context, _ string) (context.Context, error) {
+			return ctx, nil
+		}
+	}
+	a := &authenticator{keys: keys, logger: logger}
+	return a.authorize
+}
+
+type authenticator struct {
+	keys   *auth.KeyStore
+	logger *zap.Logger
+}
+
+// authorize authenticates the API key of the request, or the client
+// certificate when no key is sent, and checks that it grants the scope
+// of method. The returned context carries the
+// principal.
+func (a *authenticator) authorize(ctx context.Context, method string) (context.Context, error) {
+	if isPublic(method) {
+		return ctx, nil
+	}
+
+	p, err := a.keys.Authenticate(apiKey(ctx))
+	if errors.Is(err, auth.ErrNoCredentials) {
+		if cn, ok := clientCertSubject(ctx); ok {
+			p, err = a.keys.AuthenticateSubject(cn)
+		}
+	}
+	if err != nil {
+		if !errors.Is(err, auth.ErrNoCredentials) {
+			a.logger.Warn("rejected api key", zap.String("method", method))
+		}
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	scope, ok := methodScopes[method]
+	if !ok {
+		scope = auth.ScopeAll
+	}
+	if !p.Allowed(scope) {
+		return nil, status.Errorf(codes.PermissionDenied, "api key %q lacks scope %s", p.Name, scope)
+	}
+
+	return auth.NewContext(ctx, p), nil
+}
+
+// apiKey returns the key from the x-api-key header or a bearer token
+// of the authorization header.
+func apiKey(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	if v := md.Get("x-api-key"); len(v) > 0 {
+		return v[0]
+	}
+	for _, v := range md.Get("authorization") {
+		scheme, token, ok := strings.Cut(v, " ")
+		if ok && strings.EqualFold(scheme, "bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	return ""
+}
+
+// clientCertSubject returns the common name of the verified client
+// certificate of a mutual TLS connection.
+func clientCertSubject(ctx context.Context) (string, bool) {
+	pr, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	info, ok := pr.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+	return info.State.VerifiedChains[0][0].Subject.CommonName, true
+}
+
+func (a *authenticator) unary(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+
+	ctx, err := a.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *authenticator) stream(
+	srv any,
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+
+	ctx, err := a.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authStream{ServerStream: ss, ctx: ctx})
+}
+
+// authStream carries the context with the principal to stream handlers.
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,106 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/zen-flo/telegram-service/internal/auth"
+	api "github.com/zen-flo/telegram-service/pkg/api/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthenticator_Unary(t *testing.T) {
+	keys, err := auth.NewKeyStore([]auth.Key{
+		{Name: "sender", Secret: "k1", Scopes: []auth.Scope{auth.ScopeMessagesSend}},
+	}, "", zap.NewNop())
+	if err != nil {
+		t.Fatalf("new key store: %v", err)
+	}
+	a := &authenticator{keys: keys, logger: zap.NewNop()}
+
+	call := func(md metadata.MD, method string) (string, error) {
+		ctx := metadata.NewIncomingContext(context.Background(), md)
+		res, err := a.unary(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method},
+			func(ctx context.Context, req any) (any, error) {
+				p, _ := auth.FromContext(ctx)
+				return p.Name, nil
+			})
+		if err != nil {
+			return "", err
+		}
+		return res.(string), nil
+	}
+
+	send := api.TelegramService_SendMessage_FullMethodName
+
+	if name, err := call(metadata.Pairs("authorization", "Bearer k1"), send); err != nil || name != "sender" {
+		t.Fatalf("expected bearer token to be accepted, got %q, %v", name, err)
+	}
+	if _, err := call(metadata.Pairs("x-api-key", "k1"), send); err != nil {
+		t.Fatalf("expected x-api-key to be accepted, got %v", err)
+	}
+
+	for _, tc := range []struct {
+		md     metadata.MD
+		method string
+		code   codes.Code
+	}{
+		{metadata.MD{}, send, codes.Unauthenticated},
+		{metadata.Pairs("x-api-key", "wrong"), send, codes.Unauthenticated},
+		{metadata.Pairs("x-api-key", "k1"), api.TelegramService_SubscribeMessages_FullMethodName, codes.PermissionDenied},
+		{metadata.Pairs("x-api-key", "k1"), "/other.Service/Method", codes.PermissionDenied},
+	} {
+		if _, err := call(tc.md, tc.method); status.Code(err) != tc.code {
+			t.Fatalf("%s: expected %s, got %v", tc.method, tc.code, err)
+		}
+	}
+
+	if _, err := call(metadata.MD{}, "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo"); err != nil {
+		t.Fatalf("expected reflection to be public, got %v", err)
+	}
+}
+
+func TestAuthenticator_ClientCertificate(t *testing.T) {
+	keys, err := auth.NewKeyStore([]auth.Key{
+		{Name: "billing", Secret: auth.SubjectPrefix + "billing.internal", Scopes: []auth.Scope{auth.ScopeMessagesSend}},
+	}, "", zap.NewNop())
+	if err != nil {
+		t.Fatalf("new key store: %v", err)
+	}
+	a := &authenticator{keys: keys, logger: zap.NewNop()}
+
+	withCert := func(cn string) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{
+			AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+				VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: cn}}}},
+			}},
+		})
+	}
+
+	ctx, err := a.authorize(withCert("billing.internal"), api.TelegramService_SendMessage_FullMethodName)
+	if err != nil {
+		t.Fatalf("expected the certificate subject to authenticate, got %v", err)
+	}
+	if p, _ := auth.FromContext(ctx); p.Name != "billing" || p.Tenant != "billing" {
+		t.Fatalf("unexpected principal: %+v", p)
+	}
+
+	if _, err := a.authorize(withCert("unknown"), api.TelegramService_SendMessage_FullMethodName); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected UNAUTHENTICATED for an unknown subject, got %v", err)
+	}
+
+	// the subject is not an api key
+	md := metadata.Pairs("x-api-key", auth.SubjectPrefix+"billing.internal")
+	if _, err := a.authorize(metadata.NewIncomingContext(context.Background(), md), api.TelegramService_SendMessage_FullMethodName); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected UNAUTHENTICATED, got %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package grpc
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gotd/td/tgerr"
+	"github.com/zen-flo/telegram-service/internal/ratelimit"
+	"github.com/zen-flo/telegram-service/internal/telegram"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// telegramErrorDomain is the ErrorInfo domain of Telegram RPC errors.
+const telegramErrorDomain = "telegram.org"
+
+// rateLimitStatus converts a rate limit rejection into RESOURCE_EXHAUSTED
+// carrying the retry delay and the exceeded scope.
+func rateLimitStatus(e *ratelimit.ExceededError) error {
+	st := status.New(codes.ResourceExhausted, e.Error())
+
+	withDetails, err := st.WithDetails(
+		&errdetails.RetryInfo{
+			RetryDelay: durationpb.New(e.RetryAfter),
+		},
+		&errdetails.QuotaFailure{
+			Violations: []*errdetails.QuotaFailure_Violation{{
+				Subject:     string(e.Scope),
+				Description: "send rate limit exceeded",
+			}},
+		},
+	)
+	if err != nil {
+		return st.Err()
+	}
+
+	return withDetails.Err()
+}
+
+// telegramCodes maps Telegram error types to gRPC codes. Types missing
+// here are mapped by their HTTP-like error code.
+var telegramCodes = map[string]codes.Code{
+	"USERNAME_NOT_OCCUPIED": codes.NotFound,
+	"USERNAME_INVALID":      codes.InvalidArgument,
+	"PEER_ID_INVALID":       codes.InvalidArgument,
+	"MESSAGE_EMPTY":         codes.InvalidArgument,
+	"MESSAGE_TOO_LONG":      codes.InvalidArgument,
+	"RANDOM_ID_DUPLICATE":   codes.AlreadyExists,
+
+	"AUTH_KEY_UNREGISTERED": codes.Unauthenticated,
+	"AUTH_KEY_INVALID":      codes.Unauthenticated,
+	"SESSION_REVOKED":       codes.Unauthenticated,
+	"SESSION_EXPIRED":       codes.Unauthenticated,
+	"USER_DEACTIVATED":      codes.Unauthenticated,
+	"USER_DEACTIVATED_BAN":  codes.Unauthenticated,
+
+	"CHAT_WRITE_FORBIDDEN":    codes.PermissionDenied,
+	"CHAT_ADMIN_REQUIRED":     codes.PermissionDenied,
+	"CHANNEL_PRIVATE":         codes.PermissionDenied,
+	"USER_BANNED_IN_CHANNEL":  codes.PermissionDenied,
+	"USER_IS_BLOCKED":         codes.PermissionDenied,
+	"YOU_BLOCKED_USER":        codes.PermissionDenied,
+	"PEER_FLOOD":              codes.ResourceExhausted,
+	"INPUT_USER_DEACTIVATED":  codes.FailedPrecondition,
+	"USER_PRIVACY_RESTRICTED": codes.PermissionDenied,
+
+	tgerr.ErrFloodWait:        codes.ResourceExhausted,
+	tgerr.ErrPremiumFloodWait: codes.ResourceExhausted,
+	"SLOWMODE_WAIT":           codes.ResourceExhausted,
+}
+
+// telegramStatus converts errors of the Telegram client into a gRPC
+// status. Telegram RPC errors carry a google.rpc.ErrorInfo with the
+// original error type as reason, and a google.rpc.RetryInfo when
+// Telegram asks to wait. It returns false for errors it does not know.
+func telegramStatus(err error) (*status.Status, bool) {
+	switch {
+	case errors.Is(err, telegram.ErrInvalidPeer):
+		return status.New(codes.InvalidArgument, err.Error()), true
+	case errors.Is(err, telegram.ErrPeerNotFound):
+		return status.New(codes.NotFound, err.Error()), true
+	case errors.Is(err, telegram.ErrNotStarted):
+		return status.New(codes.Unavailable, err.Error()), true
+	case errors.Is(err, telegram.ErrNoopMode):
+		return status.New(codes.FailedPrecondition, err.Error()), true
+	case errors.Is(err, telegram.ErrMessageIDUnknown):
+		return status.New(codes.Unknown, "message may have been sent but its id could not be determined"), true
+	}
+
+	rpcErr, ok := tgerr.As(err)
+	if !ok {
+		return nil, false
+	}
+
+	code, ok := telegramCodes[rpcErr.Type]
+	if !ok {
+		code = codeFromTelegram(rpcErr.Code)
+	}
+
+	st := status.New(code, rpcErr.Error())
+
+	details := []protoadapt.MessageV1{
+		&errdetails.ErrorInfo{
+			Reason: rpcErr.Type,
+			Domain: telegramErrorDomain,
+			Metadata: map[string]string{
+				"code":     strconv.Itoa(rpcErr.Code),
+				"argument": strconv.Itoa(rpcErr.Argument),
+			},
+		},
+	}
+	if code == codes.ResourceExhausted && rpcErr.Argument > 0 {
+		details = append(details, &errdetails.RetryInfo{
+			RetryDelay: durationpb.New(time.Duration(rpcErr.Argument) * time.Second),
+		})
+	}
+
+	withDetails, detailsErr := st.WithDetails(details...)
+	if detailsErr != nil {
+		return st, true
+	}
+	return withDetails, true
+}
+
+// codeFromTelegram maps the HTTP-like code of a Telegram error.
+func codeFromTelegram(code int) codes.Code {
+	switch {
+	case code == 400:
+		return codes.InvalidArgument
+	case code == 401:
+		return codes.Unauthenticated
+	case code == 403:
+		return codes.PermissionDenied
+	case code == 404:
+		return codes.NotFound
+	case code == 406:
+		return codes.FailedPrecondition
+	case code == 420:
+		return codes.ResourceExhausted
+	case code >= 500:
+		return codes.Unavailable
+	}
+	return codes.Unknown
+}
@@ -0,0 +1,67 @@
+package grpc
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gotd/td/tgerr"
+	"github.com/zen-flo/telegram-service/internal/telegram"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+)
+
+func TestTelegramStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{tgerr.New(400, "USERNAME_NOT_OCCUPIED"), codes.NotFound},
+		{tgerr.New(400, "PEER_ID_INVALID"), codes.InvalidArgument},
+		{tgerr.New(401, "AUTH_KEY_UNREGISTERED"), codes.Unauthenticated},
+		{tgerr.New(403, "CHAT_WRITE_FORBIDDEN"), codes.PermissionDenied},
+		{tgerr.New(400, "SOMETHING_NEW"), codes.InvalidArgument},
+		{tgerr.New(500, "INTERNAL"), codes.Unavailable},
+		{fmt.Errorf("send: %w", tgerr.New(420, "FLOOD_WAIT_30")), codes.ResourceExhausted},
+		{fmt.Errorf("%w: peer is empty", telegram.ErrInvalidPeer), codes.InvalidArgument},
+		{&telegram.UnknownSendResultError{Result: "x"}, codes.Unknown},
+	}
+
+	for _, tt := range tests {
+		st, ok := telegramStatus(tt.err)
+		if !ok {
+			t.Fatalf("%v: not mapped", tt.err)
+		}
+		if st.Code() != tt.code {
+			t.Fatalf("%v: expected %s, got %s", tt.err, tt.code, st.Code())
+		}
+	}
+
+	if _, ok := telegramStatus(fmt.Errorf("boom")); ok {
+		t.Fatal("unknown errors must not be mapped")
+	}
+}
+
+func TestTelegramStatus_FloodWaitDetails(t *testing.T) {
+	st, _ := telegramStatus(tgerr.New(420, "FLOOD_WAIT_30"))
+
+	var (
+		info  *errdetails.ErrorInfo
+		retry *errdetails.RetryInfo
+	)
+	for _, d := range st.Details() {
+		switch v := d.(type) {
+		case *errdetails.ErrorInfo:
+			info = v
+		case *errdetails.RetryInfo:
+			retry = v
+		}
+	}
+
+	if info == nil || info.GetReason() != "FLOOD_WAIT" || info.GetDomain() != telegramErrorDomain {
+		t.Fatalf("unexpected error info: %v", info)
+	}
+	if retry == nil || retry.GetRetryDelay().AsDuration() != 30*time.Second {
+		t.Fatalf("unexpected retry info: %v", retry)
+	}
+}
@@ -0,0 +1,258 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zen-flo/telegram-service/internal/session"
+	"github.com/zen-flo/telegram-service/internal/telegram"
+	api "github.com/zen-flo/telegram-service/pkg/api/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// Health service names. The empty name is liveness as well, and the
+// name of the Telegram service is readiness, as probes expect.
+const (
+	HealthLiveness      = "liveness"
+	HealthReadiness     = "readiness"
+	HealthSessionPrefix = "session/"
+)
+
+type HealthOptions struct {
+	// MinConnected makes the service unready until at least this many
+	// sessions are connected to Telegram.
+	MinConnected int
+	// CheckStore reports whether session files can be written.
+	// Defaults to telegram.CheckSessionDir.
+	CheckStore func() error
+	// WatchInterval is how often Watch streams re-check the status.
+	// Defaults to 5s.
+	WatchInterval time.Duration
+	// Authorize authenticates checks of sessions, which need the scope
+	// of GetSessionStatus. Defaults to allowing every caller, as
+	// without API keys.
+	Authorize Authorizer
+}
+
+func (o *HealthOptions) setDefaults() {
+	if o.CheckStore == nil {
+		o.CheckStore = telegram.CheckSessionDir
+	}
+	if o.WatchInterval <= 0 {
+		o.WatchInterval = 5 * time.Second
+	}
+	if o.Authorize == nil {
+		o.Authorize = NewAuthorizer(nil, nil)
+	}
+}
+
+// HealthChecker implements grpc.health.v1.Health. Statuses are computed
+// on every request:
+//
+//   - "" and "liveness" serve while the server is running and has not
+//     failed;
+//   - "readiness" and "pact.telegram.TelegramService" serve when the
+//     session store is writable and enough sessions are connected;
+//   - "session/<id>" serves while the session is authorized and
+//     connected. Checking it takes an API key with the sessions:read
+//     scope, and sessions of other tenants are unknown.
+type HealthChecker struct {
+	healthpb.UnimplementedHealthServer
+
+	manager *session.Manager
+	logger  *zap.Logger
+	opts    HealthOptions
+
+	done     chan struct{}
+	stopOnce sync.Once
+	failed   atomic.Bool
+}
+
+func NewHealthChecker(manager *session.Manager, logger *zap.Logger, opts HealthOptions) *HealthChecker {
+	opts.setDefaults()
+
+	return &HealthChecker{
+		manager: manager,
+		logger:  logger,
+		opts:    opts,
+		done:    make(chan struct{}),
+	}
+}
+
+// Shutdown reports every service as not serving and ends Watch streams.
+func (h *HealthChecker) Shutdown() {
+	h.stopOnce.Do(func() {
+		close(h.done)
+	})
+}
+
+// Fail reports every service as not serving from now on, for a server
+// that stopped working, e.g. because Serve returned. Failures during
+// Shutdown are expected and not logged.
+func (h *HealthChecker) Fail(err error) {
+	if h.failed.CompareAndSwap(false, true) && !h.stopping() {
+		h.logger.Error("server failed, reporting not serving", zap.Error(err))
+	}
+}
+
+func (h *HealthChecker) alive() bool {
+	return !h.stopping() && !h.failed.Load()
+}
+
+func (h *HealthChecker) stopping() bool {
+	select {
+	case <-h.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// authorize authenticates checks of sessions and returns the sessions
+// the caller may check. Other services are public.
+func (h *HealthChecker) authorize(ctx context.Context, service string) (session.Owner, error) {
+	if !strings.HasPrefix(service, HealthSessionPrefix) {
+		return session.Owner{}, nil
+	}
+
+	ctx, err := h.opts.Authorize(ctx, api.TelegramService_GetSessionStatus_FullMethodName)
+	if err != nil {
+		return session.Owner{}, err
+	}
+	return ownerFrom(ctx), nil
+}
+
+// status returns the status of service, false for unknown services.
+// Sessions not owned by owner are unknown.
+func (h *HealthChecker) status(
+	service string,
+	owner session.Owner,
+) (healthpb.HealthCheckResponse_ServingStatus, bool) {
+
+	switch {
+	case service == "" || service == HealthLiveness:
+		return h.serving(h.alive()), true
+
+	case service == HealthReadiness || service == api.TelegramService_ServiceDesc.ServiceName:
+		return h.serving(h.ready()), true
+
+	case strings.HasPrefix(service, HealthSessionPrefix):
+		s, err := h.manager.Get(strings.TrimPrefix(service, HealthSessionPrefix))
+		if err != nil || !owner.Owns(s.Tenant()) {
+			return healthpb.HealthCheckResponse_SERVICE_UNKNOWN, false
+		}
+		return h.serving(h.alive() && s.State() == session.StateAuthorized && s.Connected()), true
+	}
+
+	return healthpb.HealthCheckResponse_SERVICE_UNKNOWN, false
+}
+
+func (h *HealthChecker) ready() bool {
+	if !h.alive() {
+		return false
+	}
+
+	if err := h.opts.CheckStore(); err != nil {
+		h.logger.Warn("session store is not available", zap.Error(err))
+		return false
+	}
+
+	if h.opts.MinConnected > 0 {
+		connected := 0
+		for _, s := range h.manager.List(session.Owner{All: true}, nil) {
+			if s.Connected() {
+				connected++
+			}
+		}
+		if connected < h.opts.MinConnected {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (h *HealthChecker) serving(ok bool) healthpb.HealthCheckResponse_ServingStatus {
+	if ok {
+		return healthpb.HealthCheckResponse_SERVING
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING
+}
+
+func (h *HealthChecker) Check(
+	ctx context.Context,
+	req *healthpb.HealthCheckRequest,
+) (*healthpb.HealthCheckResponse, error) {
+
+	owner, err := h.authorize(ctx, req.GetService())
+	if err != nil {
+		return nil, err
+	}
+
+	st, ok := h.status(req.GetService(), owner)
+	if !ok {
+		return nil, status.Error(codes.NotFound, "unknown service")
+	}
+	return &healthpb.HealthCheckResponse{Status: st}, nil
+}
+
+// List returns liveness and readiness. Sessions are not listed, List
+// is public and would disclose their IDs.
+func (h *HealthChecker) List(
+	ctx context.Context,
+	req *healthpb.HealthListRequest,
+) (*healthpb.HealthListResponse, error) {
+
+	statuses := make(map[string]*healthpb.HealthCheckResponse)
+	for _, name := range []string{HealthLiveness, HealthReadiness} {
+		st, _ := h.status(name, session.Owner{})
+		statuses[name] = &healthpb.HealthCheckResponse{Status: st}
+	}
+
+	return &healthpb.HealthListResponse{Statuses: statuses}, nil
+}
+
+// Watch sends the status of the service and then every change of it.
+// Unknown services are reported as SERVICE_UNKNOWN, since they may
+// appear later.
+func (h *HealthChecker) Watch(
+	req *healthpb.HealthCheckRequest,
+	stream healthpb.Health_WatchServer,
+) error {
+
+	owner, err := h.authorize(stream.Context(), req.GetService())
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(h.opts.WatchInterval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_ServingStatus(-1)
+	for {
+		st, _ := h.status(req.GetService(), owner)
+		if st != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: st}); err != nil {
+				return err
+			}
+			last = st
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-h.done:
+			if last != healthpb.HealthCheckResponse_NOT_SERVING {
+				_ = stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING})
+			}
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/zen-flo/telegram-service/internal/auth"
+	"github.com/zen-flo/telegram-service/internal/broker"
+	"github.com/zen-flo/telegram-service/internal/session"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestHealthChecker(t *testing.T) {
+	manager := session.NewManager(0, "", zap.NewNop(), broker.NewDispatcher(), session.Options{})
+	s, err := manager.Create(session.CreateOptions{})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	var storeErr error
+	h := NewHealthChecker(manager, zap.NewNop(), HealthOptions{
+		CheckStore: func() error { return storeErr },
+	})
+
+	check := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		t.Helper()
+		res, err := h.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatalf("check %q: %v", service, err)
+		}
+		return res.GetStatus()
+	}
+
+	serving := healthpb.HealthCheckResponse_SERVING
+	notServing := healthpb.HealthCheckResponse_NOT_SERVING
+
+	if check("") != serving || check(HealthReadiness) != serving {
+		t.Fatal("expected liveness and readiness to serve")
+	}
+	if check(HealthSessionPrefix+s.ID()) != notServing {
+		t.Fatal("expected a session that is not connected to be not serving")
+	}
+
+	storeErr = errors.New("read-only file system")
+	if check(HealthReadiness) != notServing || check(HealthLiveness) != serving {
+		t.Fatal("expected only readiness to fail without a session store")
+	}
+	storeErr = nil
+
+	h.opts.MinConnected = 1
+	if check(HealthReadiness) != notServing {
+		t.Fatal("expected readiness to wait for connected sessions")
+	}
+	h.opts.MinConnected = 0
+
+	_, err = h.Check(context.Background(), &healthpb.HealthCheckRequest{Service: HealthSessionPrefix + "missing"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NOT_FOUND for an unknown session, got %v", err)
+	}
+
+	list, err := h.List(context.Background(), &healthpb.HealthListRequest{})
+	if err != nil || len(list.GetStatuses()) != 2 {
+		t.Fatalf("expected only liveness and readiness, got %v, %v", list, err)
+	}
+
+	h.Fail(errors.New("listener closed"))
+	if check(HealthLiveness) != notServing || check(HealthReadiness) != notServing {
+		t.Fatal("expected every service to stop serving after a failure")
+	}
+
+	h.Shutdown()
+	if check("") != notServing || check(HealthReadiness) != notServing {
+		t.Fatal("expected every service to stop serving on shutdown")
+	}
+}
+
+func TestHealthChecker_SessionAuth(t *testing.T) {
+	keys, err := auth.NewKeyStore([]auth.Key{
+		{Name: "reader", Secret: "r", Scopes: []auth.Scope{auth.ScopeSessionsRead}, Tenant: "a"},
+		{Name: "sender", Secret: "s", Scopes: []auth.Scope{auth.ScopeMessagesSend}, Tenant: "a"},
+	}, "", zap.NewNop())
+	if err != nil {
+		t.Fatalf("new key store: %v", err)
+	}
+
+	manager := session.NewManager(0, "", zap.NewNop(), broker.NewDispatcher(), session.Options{})
+	own, _ := manager.Create(session.CreateOptions{Tenant: "a"})
+	other, _ := manager.Create(session.CreateOptions{Tenant: "b"})
+
+	h := NewHealthChecker(manager, zap.NewNop(), HealthOptions{
+		CheckStore: func() error { return nil },
+		Authorize:  NewAuthorizer(keys, zap.NewNop()),
+	})
+
+	check := func(key, service string) error {
+		ctx := context.Background()
+		if key != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-api-key", key))
+		}
+		_, err := h.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		return err
+	}
+
+	if err := check("", HealthLiveness); err != nil {
+		t.Fatalf("expected liveness to be public, got %v", err)
+	}
+	if err := check("", HealthSessionPrefix+own.ID()); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected UNAUTHENTICATED without a key, got %v", err)
+	}
+	if err := check("s", HealthSessionPrefix+own.ID()); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PERMISSION_DENIED without sessions:read, got %v", err)
+	}
+	if err := check("r", HealthSessionPrefix+own.ID()); err != nil {
+		t.Fatalf("expected a session of the tenant to be checked, got %v", err)
+	}
+	if err := check("r", HealthSessionPrefix+other.ID()); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected a session of another tenant to be unknown, got %v", err)
+	}
+}
@@ -2,17 +2,35 @@ package grpc
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"net"
 
+	"github.com/zen-flo/telegram-service/internal/auth"
 	api "github.com/zen-flo/telegram-service/pkg/api/proto"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/stats"
 )
 
+type ServerOptions struct {
+	// Keys authenticates requests. Nil or a store without a key source
+	// leaves the server unauthenticated.
+	Keys *auth.KeyStore
+	// TLS serves over TLS when set.
+	TLS *tls.Config
+	// Health is registered as grpc.health.v1.Health when set.
+	Health *HealthChecker
+}
+
 type Server struct {
 	grpcServer *grpc.Server
+	health     *HealthChecker
 	logger     *zap.Logger
 	port       int
 }
@@ -21,34 +39,100 @@ func NewServer(
 	port int,
 	logger *zap.Logger,
 	telegramHandler api.TelegramServiceServer,
+	opts ServerOptions,
 ) *Server {
 
-	grpcServer := grpc.NewServer()
+	// Health checks and reflection are not traced, they would drown
+	// the RPCs of the service.
+	serverOpts := []grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler(
+			otelgrpc.WithFilter(func(info *stats.RPCTagInfo) bool {
+				return !isPublic(info.FullMethodName)
+			}),
+		)),
+	}
+	if opts.Keys != nil && opts.Keys.Enabled() {
+		a := &authenticator{keys: opts.Keys, logger: logger}
+		serverOpts = append(serverOpts,
+			grpc.ChainUnaryInterceptor(a.unary),
+			grpc.ChainStreamInterceptor(a.stream),
+		)
+	} else {
+		logger.Warn("no api keys configured, grpc requests are not authenticated")
+	}
+
+	if opts.TLS != nil {
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(opts.TLS)))
+	} else {
+		logger.Warn("tls is not configured, serving plaintext grpc")
+	}
+
+	grpcServer := grpc.NewServer(serverOpts...)
 
 	api.RegisterTelegramServiceServer(grpcServer, telegramHandler)
+	if opts.Health != nil {
+		healthpb.RegisterHealthServer(grpcServer, opts.Health)
+	}
 
 	// Enable reflection for grpcurl
 	reflection.Register(grpcServer)
 
 	return &Server{
 		grpcServer: grpcServer,
+		health:     opts.Health,
 		logger:     logger,
 		port:       port,
 	}
 }
 
-func (s *Server) Start(ctx context.Context) error {
+// Start serves RPCs until Shutdown is called. Once it returns, the
+// health service reports liveness as not serving.
+func (s *Server) Start() (err error) {
+	defer func() {
+		reason := err
+		if reason == nil {
+			reason = errors.New("grpc server stopped")
+		}
+		s.Fail(reason)
+	}()
+
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
 	if err != nil {
 		return fmt.Errorf("failed to listen: %w", err)
 	}
 
+	s.logger.Info("grpc server started", zap.Int("port", s.port))
+	return s.grpcServer.Serve(lis)
+}
+
+// Fail reports the server as not serving to health checks, for a
+// failure of the process that keeps it from working.
+func (s *Server) Fail(err error) {
+	if s.health != nil {
+		s.health.Fail(err)
+	}
+}
+
+// Shutdown stops accepting new RPCs and waits for running ones to
+// finish. Connections still open when ctx is done are closed.
+func (s *Server) Shutdown(ctx context.Context) {
+	s.logger.Info("shutting down grpc server")
+
+	// probes see the server going away before connections are closed
+	if s.health != nil {
+		s.health.Shutdown()
+	}
+
+	stopped := make(chan struct{})
 	go func() {
-		<-ctx.Done()
-		s.logger.Info("shutting down grpc server")
 		s.grpcServer.GracefulStop()
+		close(stopped)
 	}()
 
-	s.logger.Info("grpc server started", zap.Int("port", s.port))
-	return s.grpcServer.Serve(lis)
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.logger.Warn("grpc server did not stop in time, closing connections")
+		s.grpcServer.Stop()
+	}
 }
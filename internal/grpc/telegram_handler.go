@@ -3,7 +3,15 @@ package grpc
 import (
 	"context"
 	"errors"
+	"maps"
+	"sync"
+
+	"github.com/zen-flo/telegram-service/internal/broker"
+	"github.com/zen-flo/telegram-service/internal/outbox"
+	"github.com/zen-flo/telegram-service/internal/ratelimit"
 	"github.com/zen-flo/telegram-service/internal/session"
+	"github.com/zen-flo/telegram-service/internal/telegram"
+	"github.com/zen-flo/telegram-service/internal/webhook"
 	api "github.com/zen-flo/telegram-service/pkg/api/proto"
 	"go.uber.org/zap"
 
@@ -14,27 +22,56 @@ import (
 type TelegramHandler struct {
 	api.UnimplementedTelegramServiceServer
 
-	manager *session.Manager
-	logger  *zap.Logger
+	manager  *session.Manager
+	webhooks *webhook.Service
+	logger   *zap.Logger
+
+	done      chan struct{} // closed by Close to end open streams
+	closeOnce sync.Once
 }
 
 func NewTelegramHandler(
 	manager *session.Manager,
+	webhooks *webhook.Service,
 	logger *zap.Logger,
 ) *TelegramHandler {
 	return &TelegramHandler{
-		manager: manager,
-		logger:  logger,
+		manager:  manager,
+		webhooks: webhooks,
+		logger:   logger,
+		done:     make(chan struct{}),
 	}
 }
 
+// Close ends all open streams with UNAVAILABLE, so that a graceful
+// server stop does not wait for subscribers.
+func (h *TelegramHandler) Close() {
+	h.closeOnce.Do(func() {
+		close(h.done)
+	})
+}
+
 func (h *TelegramHandler) CreateSession(
 	ctx context.Context,
 	req *api.CreateSessionRequest,
 ) (*api.CreateSessionResponse, error) {
 
-	s, err := h.manager.Create()
+	owner := ownerFrom(ctx)
+
+	tenant := owner.Tenant
+	if owner.All {
+		// administrators create sessions on behalf of a tenant
+		tenant = req.GetLabels()[session.TenantLabel]
+	}
+
+	s, err := h.manager.Create(session.CreateOptions{
+		Tenant: tenant,
+		Labels: req.GetLabels(),
+	})
 	if err != nil {
+		if errors.Is(err, session.ErrSessionLimit) {
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		}
 		h.logger.Error("failed to create session", zap.Error(err))
 		return nil, status.Error(codes.Internal, "failed to create session")
 	}
@@ -47,6 +84,9 @@ func (h *TelegramHandler) CreateSession(
 		h.logger.Error("failed to start qr auth",
 			zap.Error(err),
 		)
+		if st, ok := telegramStatus(err); ok {
+			return nil, st.Err()
+		}
 		return nil, status.Error(codes.Internal, "failed to start qr auth")
 	}
 
@@ -65,7 +105,11 @@ func (h *TelegramHandler) DeleteSession(
 	req *api.DeleteSessionRequest,
 ) (*api.DeleteSessionResponse, error) {
 
-	err := h.manager.Delete(req.GetSessionId())
+	if _, err := h.getSession(ctx, req.GetSessionId()); err != nil {
+		return nil, status.Error(codes.NotFound, "session not found")
+	}
+
+	err := h.manager.Delete(ctx, req.GetSessionId(), toDeleteMode(req.GetMode()))
 	if err != nil {
 		if errors.Is(err, session.ErrSessionNotFound) {
 			return nil, status.Error(codes.NotFound, "session not found")
@@ -81,51 +125,255 @@ func (h *TelegramHandler) DeleteSession(
 	h.logger.Info(
 		"session deleted",
 		zap.String("session_id", req.GetSessionId()),
+		zap.Stringer("mode", req.GetMode()),
 	)
 
 	return &api.DeleteSessionResponse{}, nil
 }
 
+func (h *TelegramHandler) ResumeSession(
+	ctx context.Context,
+	req *api.ResumeSessionRequest,
+) (*api.ResumeSessionResponse, error) {
+
+	s, err := h.manager.Resume(req.GetSessionId(), ownerFrom(ctx))
+	if err != nil {
+		switch {
+		case errors.Is(err, session.ErrInvalidSessionID):
+			return nil, status.Error(codes.InvalidArgument, "invalid session id")
+		case errors.Is(err, session.ErrSessionNotFound):
+			return nil, status.Error(codes.NotFound, "no stored session to resume")
+		case errors.Is(err, session.ErrSessionActive):
+			return nil, status.Error(codes.AlreadyExists, "session is already active")
+		case errors.Is(err, session.ErrSessionLimit):
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		}
+
+		h.logger.Error(
+			"failed to resume session",
+			zap.String("session_id", req.GetSessionId()),
+			zap.Error(err),
+		)
+		return nil, status.Error(codes.Internal, "failed to resume session")
+	}
+
+	h.logger.Info(
+		"session resumed",
+		zap.String("session_id", s.ID()),
+	)
+
+	return &api.ResumeSessionResponse{
+		State: toSessionState(s.State()),
+	}, nil
+}
+
+func (h *TelegramHandler) SubmitPassword(
+	ctx context.Context,
+	req *api.SubmitPasswordRequest,
+) (*api.SubmitPasswordResponse, error) {
+
+	s, err := h.getSession(ctx, req.GetSessionId())
+	if err != nil {
+		return nil, status.Error(codes.NotFound, "session not found")
+	}
+	s.Touch()
+
+	if err := s.SubmitPassword(ctx, req.GetPassword()); err != nil {
+		switch {
+		case errors.Is(err, telegram.ErrPasswordNotRequired):
+			return nil, status.Error(codes.FailedPrecondition, "session does not wait for a password")
+		case errors.Is(err, telegram.ErrPasswordInvalid):
+			return nil, status.Error(codes.InvalidArgument, "invalid password")
+		case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+			return nil, status.FromContextError(err).Err()
+		}
+		if st, ok := telegramStatus(err); ok {
+			return nil, st.Err()
+		}
+
+		h.logger.Error("failed to check password",
+			zap.String("session_id", s.ID()),
+			zap.Error(err),
+		)
+		return nil, status.Error(codes.Internal, "failed to check password")
+	}
+
+	h.logger.Info("2FA password accepted", zap.String("session_id", s.ID()))
+
+	return &api.SubmitPasswordResponse{}, nil
+}
+
 func (h *TelegramHandler) SendMessage(
 	ctx context.Context,
 	req *api.SendMessageRequest,
 ) (*api.SendMessageResponse, error) {
 
-	s, err := h.manager.Get(req.GetSessionId())
+	s, err := h.getSession(ctx, req.GetSessionId())
 	if err != nil {
 		return nil, status.Error(codes.NotFound, "session not found")
 	}
+	s.Touch()
 
-	if !s.IsReady() {
-		return nil, status.Error(codes.FailedPrecondition, "session not authorized")
+	if err := readyStatus(s); err != nil {
+		return nil, err
 	}
 
-	msgID, err := s.SendMessage(
+	sent, err := s.SendMessage(
+		ctx,
 		req.GetPeer(),
 		req.GetText(),
+		req.GetIdempotencyKey(),
 	)
 
 	if err != nil {
+		if errors.Is(err, session.ErrIdempotencyKeyReused) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		var limitErr *ratelimit.ExceededError
+		if errors.As(err, &limitErr) {
+			return nil, rateLimitStatus(limitErr)
+		}
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, status.FromContextError(err).Err()
+		}
+		if st, ok := telegramStatus(err); ok {
+			h.logger.Warn("failed to send message",
+				zap.String("session_id", s.ID()),
+				zap.Error(err),
+			)
+			return nil, st.Err()
+		}
+
 		h.logger.Error("failed to send message", zap.Error(err))
 		return nil, status.Error(codes.Internal, "failed to send message")
 	}
 
 	return &api.SendMessageResponse{
-		MessageId: int64Ptr(msgID),
+		MessageId: int64Ptr(sent.ID),
+		Peer:      stringPtr(sent.Peer),
+		Date:      int64Ptr(sent.Date),
+	}, nil
+}
+
+func (h *TelegramHandler) EnqueueMessage(
+	ctx context.Context,
+	req *api.EnqueueMessageRequest,
+) (*api.EnqueueMessageResponse, error) {
+
+	s, err := h.getSession(ctx, req.GetSessionId())
+	if err != nil {
+		return nil, status.Error(codes.NotFound, "session not found")
+	}
+	s.Touch()
+
+	if err := readyStatus(s); err != nil {
+		return nil, err
+	}
+
+	job, err := s.EnqueueMessage(req.GetPeer(), req.GetText(), req.GetIdempotencyKey())
+	if err != nil {
+		if errors.Is(err, session.ErrIdempotencyKeyReused) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, outbox.ErrQueueFull) {
+			return nil, status.Error(codes.ResourceExhausted, "outbound queue is full")
+		}
+
+		h.logger.Error("failed to enqueue message", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to enqueue message")
+	}
+
+	return &api.EnqueueMessageResponse{
+		JobId: stringPtr(job.ID),
 	}, nil
 }
 
+func (h *TelegramHandler) GetSendStatus(
+	ctx context.Context,
+	req *api.GetSendStatusRequest,
+) (*api.GetSendStatusResponse, error) {
+
+	s, err := h.getSession(ctx, req.GetSessionId())
+	if err != nil {
+		return nil, status.Error(codes.NotFound, "session not found")
+	}
+	s.Touch()
+
+	job, err := s.SendStatus(req.GetJobId())
+	if err != nil {
+		if errors.Is(err, outbox.ErrJobNotFound) {
+			return nil, status.Error(codes.NotFound, "job not found")
+		}
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	return &api.GetSendStatusResponse{
+		Job: toSendJob(job),
+	}, nil
+}
+
+func (h *TelegramHandler) SubscribeDeliveries(
+	req *api.SubscribeDeliveriesRequest,
+	stream api.TelegramService_SubscribeDeliveriesServer,
+) error {
+
+	s, err := h.getSession(stream.Context(), req.GetSessionId())
+	if err != nil {
+		return status.Error(codes.NotFound, "session not found")
+	}
+	s.Touch()
+	defer s.KeepAlive()()
+
+	sub := s.SubscribeDeliveries()
+	defer s.UnsubscribeDeliveries(sub)
+
+	// Headers tell the client the subscription is live before the
+	// first message arrives.
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+
+	for {
+		select {
+
+		case <-stream.Context().Done():
+			return nil
+
+		case <-h.done:
+			return status.Error(codes.Unavailable, "server is shutting down")
+
+		case job, ok := <-sub:
+			if !ok {
+				return nil
+			}
+
+			if err := stream.Send(toSendJob(job)); err != nil {
+				h.logger.Error("stream send error", zap.Error(err))
+				return err
+			}
+		}
+	}
+}
+
 func (h *TelegramHandler) SubscribeMessages(
 	req *api.SubscribeMessagesRequest,
 	stream api.TelegramService_SubscribeMessagesServer,
 ) error {
 
-	s, err := h.manager.Get(req.GetSessionId())
+	s, err := h.getSession(stream.Context(), req.GetSessionId())
 	if err != nil {
 		return status.Error(codes.NotFound, "session not found")
 	}
+	s.Touch()
+	defer s.KeepAlive()()
 
 	sub := s.SubscribeMessages()
+	defer s.Unsubscribe(sub)
+
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
 
 	for {
 		select {
@@ -133,19 +381,69 @@ func (h *TelegramHandler) SubscribeMessages(
 		case <-stream.Context().Done():
 			return nil
 
+		case <-h.done:
+			return status.Error(codes.Unavailable, "server is shutting down")
+
 		case msg, ok := <-sub:
 			if !ok {
 				return nil
 			}
 
-			update := &api.MessageUpdate{
-				MessageId: int64Ptr(msg.ID),
-				From:      stringPtr(msg.From),
-				Text:      stringPtr(msg.Text),
-				Timestamp: int64Ptr(msg.Timestamp),
+			if err := stream.Send(toMessageUpdate(msg)); err != nil {
+				h.logger.Error("stream send error", zap.Error(err))
+				return err
 			}
+		}
+	}
+}
+
+func (h *TelegramHandler) SubscribeAll(
+	req *api.SubscribeAllRequest,
+	stream api.TelegramService_SubscribeAllServer,
+) error {
 
-			if err := stream.Send(update); err != nil {
+	ids := make(map[string]struct{}, len(req.GetSessionIds()))
+	for _, id := range req.GetSessionIds() {
+		ids[id] = struct{}{}
+	}
+	selector := req.GetLabelSelector()
+	owner := ownerFrom(stream.Context())
+
+	sub := h.manager.SubscribeAll()
+	defer h.manager.UnsubscribeAll(sub)
+
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+
+	for {
+		select {
+
+		case <-stream.Context().Done():
+			return nil
+
+		case <-h.done:
+			return status.Error(codes.Unavailable, "server is shutting down")
+
+		case msg, ok := <-sub:
+			if !ok {
+				return nil
+			}
+
+			if len(ids) > 0 {
+				if _, ok := ids[msg.SessionID]; !ok {
+					continue
+				}
+			}
+
+			if len(selector) > 0 || !owner.All {
+				scope, ok := h.scopeOf(msg)
+				if !ok || !owner.Owns(scope.Tenant) || !broker.MatchLabels(scope.Labels, selector) {
+					continue
+				}
+			}
+
+			if err := stream.Send(toMessageUpdate(msg)); err != nil {
 				h.logger.Error("stream send error", zap.Error(err))
 				return err
 			}
@@ -153,24 +451,329 @@ func (h *TelegramHandler) SubscribeMessages(
 	}
 }
 
+// scopeOf returns the tenant and labels of the session of msg. Events
+// of removed sessions carry them.
+func (h *TelegramHandler) scopeOf(msg *broker.Message) (*broker.Scope, bool) {
+	if msg.Scope != nil {
+		return msg.Scope, true
+	}
+
+	s, err := h.manager.Get(msg.SessionID)
+	if err != nil {
+		return nil, false
+	}
+	return &broker.Scope{Tenant: s.Tenant(), Labels: s.Labels()}, true
+}
+
+func (h *TelegramHandler) GetHistory(
+	ctx context.Context,
+	req *api.GetHistoryRequest,
+) (*api.GetHistoryResponse, error) {
+
+	s, err := h.getSession(ctx, req.GetSessionId())
+	if err != nil {
+		return nil, status.Error(codes.NotFound, "session not found")
+	}
+	s.Touch()
+
+	if err := readyStatus(s); err != nil {
+		return nil, err
+	}
+	if req.GetLimit() < 0 || req.GetOffsetId() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit and offset_id must not be negative")
+	}
+
+	messages, err := s.History(ctx, req.GetPeer(), req.GetOffsetId(), int(req.GetLimit()))
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, status.FromContextError(err).Err()
+		}
+		if st, ok := telegramStatus(err); ok {
+			h.logger.Warn("failed to get history",
+				zap.String("session_id", s.ID()),
+				zap.Error(err),
+			)
+			return nil, st.Err()
+		}
+
+		h.logger.Error("failed to get history", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to get history")
+	}
+
+	resp := &api.GetHistoryResponse{
+		Messages: make([]*api.HistoryMessage, 0, len(messages)),
+	}
+	for _, m := range messages {
+		resp.Messages = append(resp.Messages, &api.HistoryMessage{
+			MessageId: int64Ptr(m.ID),
+			From:      stringPtr(m.From),
+			Text:      stringPtr(m.Text),
+			Date:      int64Ptr(m.Date),
+			Out:       boolPtr(m.Out),
+		})
+	}
+	return resp, nil
+}
+
 func (h *TelegramHandler) GetSessionStatus(
 	ctx context.Context,
 	req *api.GetSessionStatusRequest,
 ) (*api.GetSessionStatusResponse, error) {
 
-	s, err := h.manager.Get(req.GetSessionId())
+	s, err := h.getSession(ctx, req.GetSessionId())
 	if err != nil {
 		if errors.Is(err, session.ErrSessionNotFound) {
 			return nil, status.Error(codes.NotFound, "session not found")
 		}
 		return nil, status.Error(codes.Internal, "internal error")
 	}
+	s.Touch()
+
+	lastError, lastErrorTime := s.LastError()
+
+	var lastErrorAt int64
+	if !lastErrorTime.IsZero() {
+		lastErrorAt = lastErrorTime.Unix()
+	}
 
 	return &api.GetSessionStatusResponse{
-		Ready: boolPtr(s.IsReady()),
+		Ready:            boolPtr(s.IsReady()),
+		State:            toSessionState(s.State()),
+		LogoutReason:     stringPtr(s.LogoutReason()),
+		Connected:        boolPtr(s.Connected()),
+		RestartCount:     int32Ptr(int32(s.Restarts())),
+		LastError:        stringPtr(lastError),
+		LastErrorAt:      int64Ptr(lastErrorAt),
+		CreatedAt:        int64Ptr(s.CreatedAt().Unix()),
+		LastActivityAt:   int64Ptr(s.LastActivity().Unix()),
+		PasswordRequired: boolPtr(s.PasswordRequired()),
 	}, nil
 }
 
+func (h *TelegramHandler) ListSessions(
+	ctx context.Context,
+	req *api.ListSessionsRequest,
+) (*api.ListSessionsResponse, error) {
+
+	sessions := h.manager.List(ownerFrom(ctx), req.GetLabelSelector())
+
+	resp := &api.ListSessionsResponse{
+		Sessions: make([]*api.SessionInfo, 0, len(sessions)),
+	}
+	for _, s := range sessions {
+		resp.Sessions = append(resp.Sessions, &api.SessionInfo{
+			SessionId: stringPtr(s.ID()),
+			Tenant:    stringPtr(s.Tenant()),
+			State:     toSessionState(s.State()),
+			Labels:    s.Labels(),
+			Connected: boolPtr(s.Connected()),
+			CreatedAt: int64Ptr(s.CreatedAt().Unix()),
+		})
+	}
+
+	return resp, nil
+}
+
+func (h *TelegramHandler) GetServiceStatus(
+	ctx context.Context,
+	req *api.GetServiceStatusRequest,
+) (*api.GetServiceStatusResponse, error) {
+
+	tenant := req.GetTenant()
+	if owner := ownerFrom(ctx); !owner.All {
+		tenant = owner.Tenant
+	}
+
+	usage := h.manager.Usage(tenant)
+
+	return &api.GetServiceStatusResponse{
+		Sessions:          int32Ptr(int32(usage.Sessions)),
+		MaxSessions:       int32Ptr(int32(usage.MaxSessions)),
+		Tenant:            stringPtr(usage.Tenant),
+		TenantSessions:    int32Ptr(int32(usage.TenantSessions)),
+		MaxTenantSessions: int32Ptr(int32(usage.MaxTenantSessions)),
+	}, nil
+}
+
+// readyStatus returns a gRPC error if the session cannot send messages.
+func readyStatus(s *session.Session) error {
+	switch s.State() {
+	case session.StateLoggedOut:
+		return status.Error(codes.Unauthenticated, "session logged out: "+s.LogoutReason())
+	case session.StateFailed:
+		return status.Error(codes.Unavailable, "session client failed")
+	case session.StatePending:
+		return status.Error(codes.FailedPrecondition, "session not authorized")
+	}
+	return nil
+}
+
+func (h *TelegramHandler) RegisterWebhook(
+	ctx context.Context,
+	req *api.RegisterWebhookRequest,
+) (*api.RegisterWebhookResponse, error) {
+
+	if req.GetSessionId() != "" {
+		if _, err := h.getSession(ctx, req.GetSessionId()); err != nil {
+			return nil, status.Error(codes.NotFound, "session not found")
+		}
+	}
+
+	selector := req.GetLabelSelector()
+	owner := ownerFrom(ctx)
+
+	var tenant string
+	if !owner.All {
+		tenant = owner.Tenant
+		if len(selector) > 0 {
+			// a tenant only receives events of its own sessions
+			selector = maps.Clone(selector)
+			selector[session.TenantLabel] = tenant
+		}
+	}
+
+	hook, err := h.webhooks.Register(webhook.Webhook{
+		URL:           req.GetUrl(),
+		Secret:        req.GetSecret(),
+		SessionID:     req.GetSessionId(),
+		LabelSelector: selector,
+		Tenant:        tenant,
+	})
+	if err != nil {
+		if errors.Is(err, webhook.ErrInvalidWebhook) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		h.logger.Error("failed to register webhook", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to register webhook")
+	}
+
+	h.logger.Info("webhook registered",
+		zap.String("webhook_id", hook.ID),
+		zap.String("url", hook.URL),
+	)
+
+	return &api.RegisterWebhookResponse{
+		WebhookId: stringPtr(hook.ID),
+		Secret:    stringPtr(hook.Secret),
+	}, nil
+}
+
+func (h *TelegramHandler) DeleteWebhook(
+	ctx context.Context,
+	req *api.DeleteWebhookRequest,
+) (*api.DeleteWebhookResponse, error) {
+
+	hook, err := h.webhooks.Get(req.GetWebhookId())
+	if err != nil || !ownerFrom(ctx).Owns(hook.Tenant) {
+		return nil, status.Error(codes.NotFound, "webhook not found")
+	}
+
+	if err := h.webhooks.Delete(req.GetWebhookId()); err != nil {
+		if errors.Is(err, webhook.ErrWebhookNotFound) {
+			return nil, status.Error(codes.NotFound, "webhook not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to delete webhook")
+	}
+
+	h.logger.Info("webhook deleted", zap.String("webhook_id", req.GetWebhookId()))
+
+	return &api.DeleteWebhookResponse{}, nil
+}
+
+func (h *TelegramHandler) ListWebhooks(
+	ctx context.Context,
+	req *api.ListWebhooksRequest,
+) (*api.ListWebhooksResponse, error) {
+
+	hooks := h.webhooks.List()
+	owner := ownerFrom(ctx)
+
+	resp := &api.ListWebhooksResponse{
+		Webhooks: make([]*api.Webhook, 0, len(hooks)),
+	}
+	for _, hook := range hooks {
+		if !owner.Owns(hook.Tenant) {
+			continue
+		}
+		resp.Webhooks = append(resp.Webhooks, &api.Webhook{
+			WebhookId:     stringPtr(hook.ID),
+			Url:           stringPtr(hook.URL),
+			SessionId:     stringPtr(hook.SessionID),
+			LabelSelector: hook.LabelSelector,
+		})
+	}
+
+	return resp, nil
+}
+
+func toMessageUpdate(msg *broker.Message) *api.MessageUpdate {
+	return &api.MessageUpdate{
+		MessageId: int64Ptr(msg.ID),
+		From:      stringPtr(msg.From),
+		Text:      stringPtr(msg.Text),
+		Timestamp: int64Ptr(msg.Timestamp),
+		SessionId: stringPtr(msg.SessionID),
+	}
+}
+
+func toSendJob(job outbox.Job) *api.SendJob {
+	var jobStatus api.SendJobStatus
+	switch job.Status {
+	case outbox.StatusQueued:
+		jobStatus = api.SendJobStatus_SEND_JOB_STATUS_QUEUED
+	case outbox.StatusSending:
+		jobStatus = api.SendJobStatus_SEND_JOB_STATUS_SENDING
+	case outbox.StatusSent:
+		jobStatus = api.SendJobStatus_SEND_JOB_STATUS_SENT
+	case outbox.StatusFailed:
+		jobStatus = api.SendJobStatus_SEND_JOB_STATUS_FAILED
+	}
+
+	var nextAttemptAt int64
+	if !job.NextAttemptAt.IsZero() {
+		nextAttemptAt = job.NextAttemptAt.Unix()
+	}
+
+	return &api.SendJob{
+		JobId:         stringPtr(job.ID),
+		SessionId:     stringPtr(job.SessionID),
+		Peer:          stringPtr(job.Peer),
+		Status:        &jobStatus,
+		MessageId:     int64Ptr(job.MessageID),
+		Attempts:      int32Ptr(int32(job.Attempts)),
+		LastError:     stringPtr(job.LastError),
+		NextAttemptAt: int64Ptr(nextAttemptAt),
+		CreatedAt:     int64Ptr(job.CreatedAt.Unix()),
+		UpdatedAt:     int64Ptr(job.UpdatedAt.Unix()),
+	}
+}
+
+func toDeleteMode(mode api.DeleteMode) session.DeleteMode {
+	switch mode {
+	case api.DeleteMode_DELETE_MODE_DETACH:
+		return session.DeleteDetach
+	case api.DeleteMode_DELETE_MODE_PURGE_LOCAL:
+		return session.DeletePurgeLocal
+	}
+	return session.DeleteLogout
+}
+
+func toSessionState(state session.State) *api.SessionState {
+	var st api.SessionState
+	switch state {
+	case session.StatePending:
+		st = api.SessionState_SESSION_STATE_PENDING
+	case session.StateAuthorized:
+		st = api.SessionState_SESSION_STATE_AUTHORIZED
+	case session.StateLoggedOut:
+		st = api.SessionState_SESSION_STATE_LOGGED_OUT
+	case session.StateFailed:
+		st = api.SessionState_SESSION_STATE_FAILED
+	}
+	return &st
+}
+
 func stringPtr(s string) *string {
 	return &s
 }
@@ -179,6 +782,10 @@ func int64Ptr(i int64) *int64 {
 	return &i
 }
 
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
 func boolPtr(b bool) *bool {
 	return &b
 }
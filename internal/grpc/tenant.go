@@ -0,0 +1,39 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/zen-flo/telegram-service/internal/auth"
+	"github.com/zen-flo/telegram-service/internal/session"
+	"github.com/zen-flo/telegram-service/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ownerFrom returns the sessions the caller may access: those of the
+// tenant of its API key. Keys with the "*" scope, and every caller when
+// authentication is disabled, access all sessions.
+func ownerFrom(ctx context.Context) session.Owner {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return session.Owner{All: true}
+	}
+	return session.Owner{
+		Tenant: p.Tenant,
+		All:    p.Allowed(auth.ScopeAll),
+	}
+}
+
+// getSession returns the session if the caller owns it. Sessions of
+// other tenants are reported as not found.
+func (h *TelegramHandler) getSession(ctx context.Context, id string) (*session.Session, error) {
+	trace.SpanFromContext(ctx).SetAttributes(tracing.AttrSessionID.String(id))
+
+	s, err := h.manager.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if !ownerFrom(ctx).Owns(s.Tenant()) {
+		return nil, session.ErrSessionNotFound
+	}
+	return s, nil
+}
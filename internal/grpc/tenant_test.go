@@ -0,0 +1,82 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/zen-flo/telegram-service/internal/auth"
+	"github.com/zen-flo/telegram-service/internal/broker"
+	"github.com/zen-flo/telegram-service/internal/session"
+	api "github.com/zen-flo/telegram-service/pkg/api/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestTelegramHandler_TenantIsolation(t *testing.T) {
+	manager := session.NewManager(0, "", zap.NewNop(), broker.NewDispatcher(), session.Options{})
+	h := NewTelegramHandler(manager, nil, zap.NewNop())
+
+	s, err := manager.Create(session.CreateOptions{Tenant: "acme"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	caller := func(tenant string, scopes ...auth.Scope) context.Context {
+		return auth.NewContext(context.Background(), auth.Principal{Name: tenant, Tenant: tenant, Scopes: scopes})
+	}
+	acme := caller("acme", auth.ScopeSessionsRead)
+	globex := caller("globex", auth.ScopeSessionsRead)
+	admin := caller("admin", auth.ScopeAll)
+
+	req := &api.GetSessionStatusRequest{SessionId: stringPtr(s.ID())}
+
+	if _, err := h.GetSessionStatus(acme, req); err != nil {
+		t.Fatalf("expected the owner to access the session, got %v", err)
+	}
+	if _, err := h.GetSessionStatus(admin, req); err != nil {
+		t.Fatalf("expected an administrator to access the session, got %v", err)
+	}
+	if _, err := h.GetSessionStatus(globex, req); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NOT_FOUND for another tenant, got %v", err)
+	}
+
+	_, err = h.DeleteSession(globex, &api.DeleteSessionRequest{SessionId: stringPtr(s.ID())})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NOT_FOUND deleting another tenant's session, got %v", err)
+	}
+
+	list, err := h.ListSessions(globex, &api.ListSessionsRequest{})
+	if err != nil || len(list.GetSessions()) != 0 {
+		t.Fatalf("expected no sessions for another tenant, got %v, %v", list, err)
+	}
+	list, err = h.ListSessions(acme, &api.ListSessionsRequest{})
+	if err != nil || len(list.GetSessions()) != 1 || list.GetSessions()[0].GetTenant() != "acme" {
+		t.Fatalf("expected the tenant's session, got %v, %v", list, err)
+	}
+}
+
+func TestTelegramHandler_ScopeOf(t *testing.T) {
+	manager := session.NewManager(0, "", zap.NewNop(), broker.NewDispatcher(), session.Options{})
+	h := NewTelegramHandler(manager, nil, zap.NewNop())
+
+	s, err := manager.Create(session.CreateOptions{Tenant: "acme", Labels: map[string]string{"team": "sales"}})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	scope, ok := h.scopeOf(&broker.Message{SessionID: s.ID()})
+	if !ok || scope.Tenant != "acme" || scope.Labels["team"] != "sales" {
+		t.Fatalf("expected the scope of the registered session, got %+v", scope)
+	}
+
+	if _, ok := h.scopeOf(&broker.Message{SessionID: "removed"}); ok {
+		t.Fatal("expected no scope for an unknown session")
+	}
+
+	// e.g. the expiry event of a session that was removed
+	msg := &broker.Message{SessionID: "removed", Scope: &broker.Scope{Tenant: "globex"}}
+	if scope, ok := h.scopeOf(msg); !ok || scope.Tenant != "globex" {
+		t.Fatalf("expected the scope carried by the event, got %+v", scope)
+	}
+}
@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/zen-flo/telegram-service/internal/broker"
+)
+
+const namespace = "telegram"
+
+// Metrics updated by the components directly.
+var (
+	MessagesSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_sent_total",
+		Help:      "Messages sent, by session.",
+	}, []string{"session_id"})
+
+	MessagesReceived = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_received_total",
+		Help:      "Incoming messages dispatched, by session.",
+	}, []string{"session_id"})
+
+	SendDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "send_duration_seconds",
+		Help:      "Latency of messages.sendMessage calls, by result (ok or error).",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"result"})
+
+	RPCErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_errors_total",
+		Help:      "Telegram RPC errors, by error type, e.g. FLOOD_WAIT or PEER_ID_INVALID.",
+	}, []string{"type"})
+
+	FloodWaitSeconds = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "flood_wait_seconds_total",
+		Help:      "Seconds of FLOOD_WAIT requested by Telegram.",
+	})
+
+	Reconnects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconnects_total",
+		Help:      "Reconnections to Telegram after a lost connection, by session.",
+	}, []string{"session_id"})
+)
+
+// ForgetSession drops the series of a deleted session.
+func ForgetSession(sessionID string) {
+	MessagesSent.DeleteLabelValues(sessionID)
+	MessagesReceived.DeleteLabelValues(sessionID)
+	Reconnects.DeleteLabelValues(sessionID)
+}
+
+// Sources provide the metrics computed on scrape.
+type Sources struct {
+	// SessionStates returns the number of sessions by state.
+	SessionStates func() map[string]int
+	// Dispatcher returns the subscription stats of the dispatcher.
+	Dispatcher func() broker.Stats
+}
+
+// NewRegistry returns a registry of the service metrics, the metrics
+// of sources and the Go runtime and process metrics.
+func NewRegistry(sources Sources) *prometheus.Registry {
+	reg := prometheus.NewRegistry()
+
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		MessagesSent,
+		MessagesReceived,
+		SendDuration,
+		RPCErrors,
+		FloodWaitSeconds,
+		Reconnects,
+	)
+
+	if sources.SessionStates != nil {
+		reg.MustRegister(&sessionsCollector{states: sources.SessionStates})
+	}
+
+	if sources.Dispatcher != nil {
+		stats := sources.Dispatcher
+		reg.MustRegister(
+			prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "dispatcher_queue_depth",
+				Help:      "Messages buffered for subscribers and not received yet.",
+			}, func() float64 { return float64(stats().QueueDepth) }),
+			prometheus.NewCounterFunc(prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "dispatcher_dropped_total",
+				Help:      "Messages dropped because a subscriber buffer was full.",
+			}, func() float64 { return float64(stats().Dropped) }),
+			prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "subscribers",
+				Help:      "Active message subscribers: streams, webhooks and sinks.",
+			}, func() float64 { return float64(stats().Subscribers) }),
+		)
+	}
+
+	return reg
+}
+
+var sessionsDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "sessions"),
+	"Active sessions, by state.",
+	[]string{"state"},
+	nil,
+)
+
+// sessionsCollector reports a gauge per session state.
+type sessionsCollector struct {
+	states func() map[string]int
+}
+
+func (c *sessionsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- sessionsDesc
+}
+
+func (c *sessionsCollector) Collect(ch chan<- prometheus.Metric) {
+	for state, n := range c.states() {
+		ch <- prometheus.MustNewConstMetric(sessionsDesc, prometheus.GaugeValue, float64(n), state)
+	}
+}
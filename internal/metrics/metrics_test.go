@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/zen-flo/telegram-service/internal/broker"
+)
+
+func TestRegistry_Scrape(t *testing.T) {
+	reg := NewRegistry(Sources{
+		SessionStates: func() map[string]int {
+			return map[string]int{"authorized": 2, "pending": 0}
+		},
+		Dispatcher: func() broker.Stats {
+			return broker.Stats{Subscribers: 3, QueueDepth: 5, Dropped: 7}
+		},
+	})
+
+	MessagesSent.WithLabelValues("s1").Inc()
+	FloodWaitSeconds.Add(30)
+
+	rec := httptest.NewRecorder()
+	promhttp.HandlerFor(reg, promhttp.HandlerOpts{}).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+
+	for _, want := range []string{
+		`telegram_sessions{state="authorized"} 2`,
+		`telegram_sessions{state="pending"} 0`,
+		`telegram_subscribers 3`,
+		`telegram_dispatcher_queue_depth 5`,
+		`telegram_dispatcher_dropped_total 7`,
+		`telegram_messages_sent_total{session_id="s1"} 1`,
+		`telegram_flood_wait_seconds_total 30`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("expected %q in the scrape", want)
+		}
+	}
+
+	ForgetSession("s1")
+	if n := MessagesSent.DeleteLabelValues("s1"); n {
+		t.Fatal("expected the session series to be deleted")
+	}
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+)
+
+// Server serves the registry at /metrics.
+type Server struct {
+	httpServer *http.Server
+	logger     *zap.Logger
+	port       int
+}
+
+func NewServer(port int, reg *prometheus.Registry, logger *zap.Logger) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{
+		Registry: reg,
+	}))
+
+	return &Server{
+		httpServer: &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+		logger: logger,
+		port:   port,
+	}
+}
+
+// Start serves until Shutdown is called.
+func (s *Server) Start() error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	s.logger.Info("metrics server started", zap.Int("port", s.port))
+
+	if err := s.httpServer.Serve(lis); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) Shutdown(ctx context.Context) {
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		s.logger.Warn("metrics server did not stop in time", zap.Error(err))
+	}
+}
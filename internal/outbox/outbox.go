@@ -0,0 +1,564 @@
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gotd/td/tgerr"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrJobNotFound          = errors.New("job not found")
+	ErrQueueFull            = errors.New("outbound queue is full")
+	ErrIdempotencyKeyReused = errors.New("idempotency key reused with different parameters")
+)
+
+type Status string
+
+const (
+	StatusQueued  Status = "queued"
+	StatusSending Status = "sending"
+	StatusSent    Status = "sent"
+	StatusFailed  Status = "failed"
+)
+
+// Job is a message waiting to be sent. Values returned by the Queue are
+// snapshots and are safe to use after the job changes.
+type Job struct {
+	ID        string `json:"id"`
+	SessionID string `json:"session_id"`
+	Peer      string `json:"peer"`
+	Text      string `json:"text"`
+
+	// RandomID is the Telegram random_id used for every attempt,
+	// so a retry of an ambiguous failure is not sent twice.
+	RandomID       int64  `json:"random_id"`
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+
+	Status        Status    `json:"status"`
+	MessageID     int64     `json:"message_id,omitempty"`
+	Attempts      int       `json:"attempts,omitempty"`
+	LastError     string    `json:"last_error,omitempty"`
+	NextAttemptAt time.Time `json:"next_attempt_at,omitzero"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (j *Job) done() bool {
+	return j.Status == StatusSent || j.Status == StatusFailed
+}
+
+// SendFunc sends a single message and returns its ID. since is when
+// the job was created, no attempt with randomID was made before it.
+type SendFunc func(ctx context.Context, peer, text string, randomID int64, since time.Time) (int64, error)
+
+// Request describes a message to enqueue.
+type Request struct {
+	Peer string
+	Text string
+
+	// IdempotencyKey deduplicates enqueues: while the job is retained,
+	// enqueueing the same key returns the existing job.
+	IdempotencyKey string
+	// RandomID is generated when zero.
+	RandomID int64
+}
+
+type Options struct {
+	// MaxAttempts limits attempts for transient errors.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// MaxFloodWait is the longest FLOOD_WAIT the queue waits out.
+	// Jobs receiving a longer one fail.
+	MaxFloodWait time.Duration
+	// QueueSize limits the number of pending jobs.
+	QueueSize int
+	// Retention is how long finished jobs can be queried.
+	Retention time.Duration
+}
+
+func (o *Options) setDefaults() {
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 5
+	}
+	if o.InitialBackoff <= 0 {
+		o.InitialBackoff = time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = time.Minute
+	}
+	if o.MaxFloodWait <= 0 {
+		o.MaxFloodWait = time.Hour
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = 10000
+	}
+	if o.Retention <= 0 {
+		o.Retention = 24 * time.Hour
+	}
+}
+
+// Queue sends messages of a single session one by one, in order.
+//
+// FLOOD_WAIT errors pause the whole queue for the requested duration,
+// since Telegram applies them to the account. Other transient errors
+// are retried with exponential backoff.
+//
+// With a Store every job is persisted before Enqueue returns and on
+// every status change, so Load can restore the queue after a restart.
+type Queue struct {
+	sessionID string
+	send      SendFunc
+	store     Store
+	logger    *zap.Logger
+	opts      Options
+
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	keys    map[string]*Job
+	pending []*Job
+	notify  chan struct{}
+
+	subsMu sync.RWMutex
+	subs   map[chan Job]struct{}
+}
+
+// New returns an empty queue. store may be nil to keep jobs in memory
+// only.
+func New(
+	sessionID string,
+	send SendFunc,
+	store Store,
+	logger *zap.Logger,
+	opts Options,
+) *Queue {
+	opts.setDefaults()
+
+	return &Queue{
+		sessionID: sessionID,
+		send:      send,
+		store:     store,
+		logger:    logger,
+		opts:      opts,
+		jobs:      make(map[string]*Job),
+		keys:      make(map[string]*Job),
+		notify:    make(chan struct{}, 1),
+		subs:      make(map[chan Job]struct{}),
+	}
+}
+
+// Enqueue adds a message to the queue and returns immediately.
+func (q *Queue) Enqueue(req Request) (Job, error) {
+	now := time.Now()
+
+	randomID := req.RandomID
+	if randomID == 0 {
+		var err error
+		if randomID, err = newRandomID(); err != nil {
+			return Job{}, err
+		}
+	}
+
+	job := &Job{
+		ID:             ulid.Make().String(),
+		SessionID:      q.sessionID,
+		Peer:           req.Peer,
+		Text:           req.Text,
+		RandomID:       randomID,
+		IdempotencyKey: req.IdempotencyKey,
+		Status:         StatusQueued,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	q.mu.Lock()
+	q.pruneLocked(now)
+
+	if req.IdempotencyKey != "" {
+		if existing, ok := q.keys[req.IdempotencyKey]; ok {
+			defer q.mu.Unlock()
+
+			if existing.Peer != req.Peer || existing.Text != req.Text {
+				return Job{}, ErrIdempotencyKeyReused
+			}
+			return *existing, nil
+		}
+	}
+
+	if len(q.pending) >= q.opts.QueueSize {
+		q.mu.Unlock()
+		return Job{}, ErrQueueFull
+	}
+	if q.store != nil {
+		if err := q.store.Put(*job); err != nil {
+			q.mu.Unlock()
+			return Job{}, fmt.Errorf("store job: %w", err)
+		}
+	}
+	q.jobs[job.ID] = job
+	if job.IdempotencyKey != "" {
+		q.keys[job.IdempotencyKey] = job
+	}
+	q.pending = append(q.pending, job)
+	snapshot := *job
+	q.mu.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+
+	q.publish(snapshot)
+	return snapshot, nil
+}
+
+// Load restores the jobs of the store: finished jobs can be queried
+// again and unfinished ones are queued in their original order. A job
+// interrupted while sending is retried with the same random_id. Load
+// must be called before Run.
+func (q *Queue) Load() error {
+	if q.store == nil {
+		return nil
+	}
+
+	jobs, err := q.store.Load()
+	if err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	for _, stored := range jobs {
+		job := &stored
+		if !job.done() {
+			job.Status = StatusQueued
+			job.NextAttemptAt = time.Time{}
+			q.pending = append(q.pending, job)
+		}
+		q.jobs[job.ID] = job
+		if job.IdempotencyKey != "" {
+			q.keys[job.IdempotencyKey] = job
+		}
+	}
+	q.pruneLocked(time.Now())
+	pending := len(q.pending)
+	q.mu.Unlock()
+
+	if pending > 0 {
+		q.logger.Info("restored queued messages",
+			zap.String("session_id", q.sessionID),
+			zap.Int("pending", pending),
+		)
+		select {
+		case q.notify <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+func (q *Queue) Get(id string) (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	return *job, nil
+}
+
+// Pending returns the number of jobs not sent yet.
+func (q *Queue) Pending() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.pending)
+}
+
+// FailPending fails every job not sent yet with err. It is meant for
+// a stopped queue whose jobs will never be sent.
+func (q *Queue) FailPending(err error) {
+	q.mu.Lock()
+	pending := q.pending
+	q.pending = nil
+	q.mu.Unlock()
+
+	for _, job := range pending {
+		q.update(job, func(j *Job) {
+			j.Status = StatusFailed
+			j.LastError = err.Error()
+			j.NextAttemptAt = time.Time{}
+		})
+	}
+}
+
+// Drain waits until every queued job is sent or failed. It returns
+// ctx.Err() if jobs are still pending when ctx is done.
+func (q *Queue) Drain(ctx context.Context) error {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for q.Pending() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// Subscribe returns a channel receiving every job status change.
+func (q *Queue) Subscribe() <-chan Job {
+	ch := make(chan Job, 64)
+
+	q.subsMu.Lock()
+	q.subs[ch] = struct{}{}
+	q.subsMu.Unlock()
+
+	return ch
+}
+
+func (q *Queue) Unsubscribe(ch <-chan Job) {
+	q.subsMu.Lock()
+	defer q.subsMu.Unlock()
+
+	for c := range q.subs {
+		if c == ch {
+			delete(q.subs, c)
+			close(c)
+		}
+	}
+}
+
+// Run processes jobs until ctx is cancelled. Jobs left in the queue
+// stay queued.
+func (q *Queue) Run(ctx context.Context) {
+	for {
+		job := q.next()
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.notify:
+				continue
+			}
+		}
+
+		if !q.process(ctx, job) {
+			return
+		}
+	}
+}
+
+func (q *Queue) next() *Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.pending) == 0 {
+		return nil
+	}
+	return q.pending[0]
+}
+
+// process sends the job, retrying until it is sent or failed.
+// It returns false if ctx was cancelled.
+func (q *Queue) process(ctx context.Context, job *Job) bool {
+	backoff := q.opts.InitialBackoff
+	transientAttempts := 0
+
+	for {
+		q.update(job, func(j *Job) {
+			j.Status = StatusSending
+			j.Attempts++
+			j.NextAttemptAt = time.Time{}
+		})
+
+		msgID, err := q.send(ctx, job.Peer, job.Text, job.RandomID, job.CreatedAt)
+		if err == nil {
+			q.finish(job, func(j *Job) {
+				j.Status = StatusSent
+				j.MessageID = msgID
+				j.LastError = ""
+			})
+			return true
+		}
+
+		if ctx.Err() != nil {
+			// shutdown, the job stays in the queue
+			q.update(job, func(j *Job) { j.Status = StatusQueued })
+			return false
+		}
+
+		var wait time.Duration
+
+		if d, ok := tgerr.AsFloodWait(err); ok {
+			if d > q.opts.MaxFloodWait {
+				q.fail(job, err)
+				return true
+			}
+			// FLOOD_WAIT does not count as a failed attempt
+			wait = d + time.Second
+		} else {
+			transientAttempts++
+			if !retryable(err) || transientAttempts >= q.opts.MaxAttempts {
+				q.fail(job, err)
+				return true
+			}
+			wait = backoff
+			backoff = min(backoff*2, q.opts.MaxBackoff)
+		}
+
+		q.logger.Debug("send failed, retrying",
+			zap.String("session_id", q.sessionID),
+			zap.String("job_id", job.ID),
+			zap.Duration("wait", wait),
+			zap.Error(err),
+		)
+
+		q.update(job, func(j *Job) {
+			j.Status = StatusQueued
+			j.LastError = err.Error()
+			j.NextAttemptAt = time.Now().Add(wait)
+		})
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+	}
+}
+
+func (q *Queue) fail(job *Job, err error) {
+	q.logger.Warn("send failed permanently",
+		zap.String("session_id", q.sessionID),
+		zap.String("job_id", job.ID),
+		zap.Error(err),
+	)
+
+	q.finish(job, func(j *Job) {
+		j.Status = StatusFailed
+		j.LastError = err.Error()
+	})
+}
+
+// finish applies the final update and removes the job from the pending list.
+func (q *Queue) finish(job *Job, fn func(j *Job)) {
+	q.mu.Lock()
+	if len(q.pending) > 0 && q.pending[0] == job {
+		q.pending = q.pending[1:]
+	}
+	q.mu.Unlock()
+
+	q.update(job, fn)
+}
+
+func (q *Queue) update(job *Job, fn func(j *Job)) {
+	q.mu.Lock()
+	fn(job)
+	job.UpdatedAt = time.Now()
+	snapshot := *job
+	q.storeLocked(snapshot)
+	q.mu.Unlock()
+
+	q.publish(snapshot)
+}
+
+// storeLocked persists a job change. Must be called with mu held, so
+// that changes are stored in the order they were made.
+func (q *Queue) storeLocked(job Job) {
+	if q.store == nil {
+		return
+	}
+	if err := q.store.Put(job); err != nil {
+		q.logger.Error("failed to store job",
+			zap.String("session_id", q.sessionID),
+			zap.String("job_id", job.ID),
+			zap.Error(err),
+		)
+	}
+}
+
+func (q *Queue) publish(job Job) {
+	q.subsMu.RLock()
+	defer q.subsMu.RUnlock()
+
+	for ch := range q.subs {
+		select {
+		case ch <- job:
+		default:
+		}
+	}
+}
+
+// pruneLocked forgets finished jobs older than the retention period.
+func (q *Queue) pruneLocked(now time.Time) {
+	for id, job := range q.jobs {
+		if job.done() && now.Sub(job.UpdatedAt) > q.opts.Retention {
+			delete(q.jobs, id)
+			if job.IdempotencyKey != "" && q.keys[job.IdempotencyKey] == job {
+				delete(q.keys, job.IdempotencyKey)
+			}
+			if q.store != nil {
+				if err := q.store.Remove(id); err != nil {
+					q.logger.Warn("failed to remove stored job",
+						zap.String("job_id", id),
+						zap.Error(err),
+					)
+				}
+			}
+		}
+	}
+}
+
+func newRandomID() (int64, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(b[:])), nil
+}
+
+// permanentError marks errors that must not be retried.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so that the queue fails the job without retrying.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// retryable reports whether a failed send may succeed later. Telegram
+// RPC errors are retried only for server-side failures; other errors
+// (network, connection restarts) are assumed transient.
+func retryable(err error) bool {
+	var perm *permanentError
+	if errors.As(err, &perm) {
+		return false
+	}
+
+	if rpcErr, ok := tgerr.As(err); ok {
+		return rpcErr.Code >= 500
+	}
+
+	return true
+}
@@ -0,0 +1,181 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gotd/td/tgerr"
+	"go.uber.org/zap"
+)
+
+func runQueue(t *testing.T, send SendFunc) *Queue {
+	t.Helper()
+
+	q := New("s1", send, nil, zap.NewNop(), Options{
+		MaxAttempts:    3,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     20 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go q.Run(ctx)
+	t.Cleanup(cancel)
+
+	return q
+}
+
+func waitDone(t *testing.T, q *Queue, id string) Job {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := q.Get(id)
+		if err != nil {
+			t.Fatalf("get job: %v", err)
+		}
+		if job.done() {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("job did not finish in time")
+	return Job{}
+}
+
+func TestQueue_FloodWaitThenSent(t *testing.T) {
+	var calls atomic.Int32
+	q := runQueue(t, func(ctx context.Context, peer, text string, randomID int64, since time.Time) (int64, error) {
+		if calls.Add(1) == 1 {
+			return 0, tgerr.New(420, "FLOOD_WAIT_0")
+		}
+		return 42, nil
+	})
+
+	deliveries := q.Subscribe()
+	defer q.Unsubscribe(deliveries)
+
+	job, err := q.Enqueue(Request{Peer: "@durov", Text: "hello"})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if job.Status != StatusQueued {
+		t.Fatalf("expected queued job, got %s", job.Status)
+	}
+
+	done := waitDone(t, q, job.ID)
+	if done.Status != StatusSent || done.MessageID != 42 {
+		t.Fatalf("unexpected job: %+v", done)
+	}
+	if done.Attempts != 2 {
+		t.Fatalf("expected 2 attempts, got %d", done.Attempts)
+	}
+
+	var sawRetry bool
+	for len(deliveries) > 0 {
+		if ev := <-deliveries; ev.Status == StatusQueued && !ev.NextAttemptAt.IsZero() {
+			sawRetry = true
+		}
+	}
+	if !sawRetry {
+		t.Fatal("expected a delivery event for the scheduled retry")
+	}
+}
+
+func TestQueue_TransientErrorsExhaustAttempts(t *testing.T) {
+	var calls atomic.Int32
+	q := runQueue(t, func(ctx context.Context, peer, text string, randomID int64, since time.Time) (int64, error) {
+		calls.Add(1)
+		return 0, errors.New("connection reset")
+	})
+
+	job, _ := q.Enqueue(Request{Peer: "@durov", Text: "hello"})
+
+	done := waitDone(t, q, job.ID)
+	if done.Status != StatusFailed {
+		t.Fatalf("expected failed job, got %s", done.Status)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Fatalf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestQueue_PermanentErrorNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	q := runQueue(t, func(ctx context.Context, peer, text string, randomID int64, since time.Time) (int64, error) {
+		calls.Add(1)
+		return 0, tgerr.New(400, "PEER_ID_INVALID")
+	})
+
+	first, _ := q.Enqueue(Request{Peer: "@nobody", Text: "hello"})
+	second, _ := q.Enqueue(Request{Peer: "@nobody", Text: "again"})
+
+	if done := waitDone(t, q, first.ID); done.Status != StatusFailed {
+		t.Fatalf("expected failed job, got %s", done.Status)
+	}
+	waitDone(t, q, second.ID)
+
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("expected one attempt per job, got %d", got)
+	}
+}
+
+func TestQueue_IdempotencyKey(t *testing.T) {
+	var randomIDs []int64
+	var mu sync.Mutex
+	q := runQueue(t, func(ctx context.Context, peer, text string, randomID int64, since time.Time) (int64, error) {
+		mu.Lock()
+		randomIDs = append(randomIDs, randomID)
+		mu.Unlock()
+		return 42, nil
+	})
+
+	first, err := q.Enqueue(Request{Peer: "@durov", Text: "hello", IdempotencyKey: "k", RandomID: 99})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	waitDone(t, q, first.ID)
+
+	second, err := q.Enqueue(Request{Peer: "@durov", Text: "hello", IdempotencyKey: "k"})
+	if err != nil {
+		t.Fatalf("enqueue duplicate: %v", err)
+	}
+	if second.ID != first.ID || second.MessageID != 42 {
+		t.Fatalf("expected the original job, got %+v", second)
+	}
+
+	if _, err := q.Enqueue(Request{Peer: "@durov", Text: "other", IdempotencyKey: "k"}); !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Fatalf("expected ErrIdempotencyKeyReused, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(randomIDs) != 1 || randomIDs[0] != 99 {
+		t.Fatalf("expected one send with random id 99, got %v", randomIDs)
+	}
+}
+
+func TestQueue_Drain(t *testing.T) {
+	release := make(chan struct{})
+	q := runQueue(t, func(ctx context.Context, peer, text string, randomID int64, since time.Time) (int64, error) {
+		<-release
+		return 1, nil
+	})
+
+	_, _ = q.Enqueue(Request{Peer: "@durov", Text: "hello"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := q.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded while the job is pending, got %v", err)
+	}
+
+	close(release)
+	if err := q.Drain(context.Background()); err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+}
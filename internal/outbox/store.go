@@ -0,0 +1,215 @@
+package outbox
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Store persists the jobs of a queue.
+type Store interface {
+	// Load returns the stored jobs in the order they were enqueued.
+	Load() ([]Job, error)
+	// Put stores the current state of a job.
+	Put(job Job) error
+	// Remove forgets a job.
+	Remove(id string) error
+}
+
+// FileStore keeps jobs in a JSON Lines file with one line per change.
+// The file is compacted when it is loaded and whenever it holds more
+// than twice as many lines as jobs.
+type FileStore struct {
+	mu     sync.Mutex
+	path   string
+	jobs   map[string]Job // latest state, read from the file on first use
+	lines  int
+	loaded bool
+}
+
+// storeEntry is a line of the file: a job state or a removal.
+type storeEntry struct {
+	Job     *Job   `json:"job,omitempty"`
+	Removed string `json:"removed,omitempty"`
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path, jobs: make(map[string]Job)}
+}
+
+func (s *FileStore) Load() ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadLocked(); err != nil {
+		return nil, err
+	}
+	if err := s.compactLocked(); err != nil {
+		return nil, err
+	}
+	return s.sortedLocked(), nil
+}
+
+func (s *FileStore) Put(job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.appendLocked(storeEntry{Job: &job}); err != nil {
+		return err
+	}
+	s.jobs[job.ID] = job
+	return s.maybeCompactLocked()
+}
+
+func (s *FileStore) Remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadLocked(); err != nil {
+		return err
+	}
+	if _, ok := s.jobs[id]; !ok {
+		return nil
+	}
+
+	if err := s.appendLocked(storeEntry{Removed: id}); err != nil {
+		return err
+	}
+	delete(s.jobs, id)
+	return s.maybeCompactLocked()
+}
+
+// loadLocked reads the file once. Must be called with mu held.
+func (s *FileStore) loadLocked() error {
+	if s.loaded {
+		return nil
+	}
+
+	raw, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(raw))
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		line := sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var e storeEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			// a torn write at the end of the file, nothing was acknowledged for it
+			continue
+		}
+		switch {
+		case e.Job != nil:
+			s.jobs[e.Job.ID] = *e.Job
+		case e.Removed != "":
+			delete(s.jobs, e.Removed)
+		}
+		s.lines++
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+
+	s.loaded = true
+	return nil
+}
+
+// appendLocked writes a line and syncs it. Must be called with mu held.
+func (s *FileStore) appendLocked(e storeEntry) error {
+	if err := s.loadLocked(); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(raw, '\n')); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+
+	s.lines++
+	return nil
+}
+
+func (s *FileStore) maybeCompactLocked() error {
+	if s.lines <= 2*len(s.jobs)+64 {
+		return nil
+	}
+	return s.compactLocked()
+}
+
+// compactLocked rewrites the file with one line per job, atomically.
+// Must be called with mu held.
+func (s *FileStore) compactLocked() error {
+	if len(s.jobs) == 0 {
+		s.lines = 0
+		err := os.Remove(s.path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, job := range s.sortedLocked() {
+		raw, err := json.Marshal(storeEntry{Job: &job})
+		if err != nil {
+			return err
+		}
+		buf.Write(raw)
+		buf.WriteByte('\n')
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+
+	s.lines = len(s.jobs)
+	return nil
+}
+
+// sortedLocked returns the jobs in enqueue order. Job IDs are ULIDs,
+// which sort by creation time.
+func (s *FileStore) sortedLocked() []Job {
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	slices.SortFunc(jobs, func(a, b Job) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return jobs
+}
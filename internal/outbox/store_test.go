@@ -0,0 +1,141 @@
+package outbox
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestQueue_RestoredFromFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s1.outbox.jsonl")
+	send := func(ctx context.Context, peer, text string, randomID int64, since time.Time) (int64, error) {
+		return 7, nil
+	}
+
+	// accepted jobs of a queue that never ran, e.g. before a crash
+	q := New("s1", send, NewFileStore(path), zap.NewNop(), Options{})
+	first, err := q.Enqueue(Request{Peer: "@durov", Text: "first", IdempotencyKey: "k"})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	second, err := q.Enqueue(Request{Peer: "@durov", Text: "second"})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	restored := New("s1", send, NewFileStore(path), zap.NewNop(), Options{})
+	if err := restored.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if restored.Pending() != 2 {
+		t.Fatalf("expected 2 restored jobs, got %d", restored.Pending())
+	}
+	if job, err := restored.Get(first.ID); err != nil || job.RandomID != first.RandomID {
+		t.Fatalf("expected restored job with the same random id, got %+v, %v", job, err)
+	}
+	if job, err := restored.Enqueue(Request{Peer: "@durov", Text: "first", IdempotencyKey: "k"}); err != nil || job.ID != first.ID {
+		t.Fatalf("expected the idempotency key to survive a restart, got %+v, %v", job, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go restored.Run(ctx)
+
+	if done := waitDone(t, restored, second.ID); done.Status != StatusSent {
+		t.Fatalf("unexpected job: %+v", done)
+	}
+	cancel()
+
+	// finished jobs can still be queried after another restart
+	again := New("s1", send, NewFileStore(path), zap.NewNop(), Options{})
+	if err := again.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if again.Pending() != 0 {
+		t.Fatalf("expected no pending jobs, got %d", again.Pending())
+	}
+	if job, err := again.Get(second.ID); err != nil || job.Status != StatusSent || job.MessageID != 7 {
+		t.Fatalf("expected sent job after restart, got %+v, %v", job, err)
+	}
+}
+
+func TestFileStore_Compacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+	store := NewFileStore(path)
+
+	job := Job{ID: "01J0000000000000000000000A", Status: StatusQueued}
+	for range 200 {
+		if err := store.Put(job); err != nil {
+			t.Fatalf("put: %v", err)
+		}
+	}
+	if store.lines > 2+64 {
+		t.Fatalf("expected the file to be compacted, got %d lines", store.lines)
+	}
+
+	if err := store.Remove(job.ID); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	jobs, err := NewFileStore(path).Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(jobs) != 0 {
+		t.Fatalf("expected removed job to stay removed, got %+v", jobs)
+	}
+}
+
+func TestQueue_InterruptedJobRestored(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s1.outbox.jsonl")
+
+	sending := make(chan struct{})
+	q := New("s1", func(ctx context.Context, peer, text string, randomID int64, since time.Time) (int64, error) {
+		close(sending)
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}, NewFileStore(path), zap.NewNop(), Options{})
+
+	job, err := q.Enqueue(Request{Peer: "@durov", Text: "hello"})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	// shutdown while the job is being sent
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		q.Run(ctx)
+	}()
+	<-sending
+	cancel()
+	<-stopped
+
+	var randomIDs []int64
+	restored := New("s1", func(ctx context.Context, peer, text string, randomID int64, since time.Time) (int64, error) {
+		randomIDs = append(randomIDs, randomID)
+		return 1, nil
+	}, NewFileStore(path), zap.NewNop(), Options{})
+	if err := restored.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	got, err := restored.Get(job.ID)
+	if err != nil || got.Status != StatusQueued || got.Attempts != 1 {
+		t.Fatalf("expected the interrupted job to be queued again, got %+v, %v", got, err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go restored.Run(ctx)
+
+	if done := waitDone(t, restored, job.ID); done.Status != StatusSent {
+		t.Fatalf("unexpected job: %+v", done)
+	}
+	if len(randomIDs) != 1 || randomIDs[0] != job.RandomID {
+		t.Fatalf("expected a resend with random id %d, got %v", job.RandomID, randomIDs)
+	}
+}
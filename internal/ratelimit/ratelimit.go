@@ -0,0 +1,275 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+var ErrLimitExceeded = errors.New("rate limit exceeded")
+
+type Scope string
+
+const (
+	ScopeGlobal     Scope = "global"
+	ScopeSession    Scope = "session"
+	ScopePeer       Scope = "peer"
+	ScopeNewContact Scope = "new_contact"
+)
+
+// Limit is a token bucket: Events tokens refilled every Per, with a
+// bucket size of Events. The zero Limit is unlimited.
+type Limit struct {
+	Events int
+	Per    time.Duration
+}
+
+func (l Limit) enabled() bool {
+	return l.Events > 0 && l.Per > 0
+}
+
+func (l Limit) rate() rate.Limit {
+	return rate.Limit(float64(l.Events) / l.Per.Seconds())
+}
+
+func (l Limit) String() string {
+	if !l.enabled() {
+		return "unlimited"
+	}
+	return strconv.Itoa(l.Events) + "/" + l.Per.String()
+}
+
+// ParseLimit parses "<events>/<duration>", e.g. "30/1s" or "20/1m".
+// An empty string is the unlimited Limit.
+func ParseLimit(s string) (Limit, error) {
+	if s == "" {
+		return Limit{}, nil
+	}
+
+	events, per, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid limit %q: expected <events>/<duration>", s)
+	}
+
+	n, err := strconv.Atoi(events)
+	if err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("invalid limit %q: events must be a positive integer", s)
+	}
+
+	d, err := time.ParseDuration(per)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid limit %q: duration must be positive", s)
+	}
+
+	return Limit{Events: n, Per: d}, nil
+}
+
+type Config struct {
+	Global     Limit // all sends of the process
+	Session    Limit // sends of a single session
+	Peer       Limit // sends of a session to a single peer
+	NewContact Limit // sends of a session to peers it has not messaged before
+}
+
+// ExceededError is returned when a send would exceed a limit.
+type ExceededError struct {
+	Scope      Scope
+	RetryAfter time.Duration
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("%s rate limit exceeded, retry after %s", e.Scope, e.RetryAfter)
+}
+
+func (e *ExceededError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// Limiter enforces the token buckets of all scopes at once: a send
+// takes a token from every applicable bucket or from none of them.
+type Limiter struct {
+	mu  sync.Mutex
+	cfg Config
+
+	global      *rate.Limiter
+	sessions    map[string]*rate.Limiter
+	peers       map[string]*rate.Limiter
+	newContacts map[string]*rate.Limiter
+	contacted   map[string]map[string]struct{}
+}
+
+func New(cfg Config) *Limiter {
+	l := &Limiter{
+		cfg:         cfg,
+		sessions:    make(map[string]*rate.Limiter),
+		peers:       make(map[string]*rate.Limiter),
+		newContacts: make(map[string]*rate.Limiter),
+		contacted:   make(map[string]map[string]struct{}),
+	}
+	if cfg.Global.enabled() {
+		l.global = newBucket(cfg.Global)
+	}
+	return l
+}
+
+func newBucket(limit Limit) *rate.Limiter {
+	return rate.NewLimiter(limit.rate(), limit.Events)
+}
+
+// Acquire takes a token for a send of sessionID to peer. With wait set,
+// it blocks until the send is allowed or ctx is done; otherwise it
+// returns an *ExceededError if the send is not allowed right now.
+func (l *Limiter) Acquire(ctx context.Context, sessionID, peer string, wait bool) error {
+	if l == nil {
+		return nil
+	}
+
+	now := time.Now()
+	peer = normalizePeer(peer)
+
+	l.mu.Lock()
+
+	type reservation struct {
+		scope Scope
+		r     *rate.Reservation
+	}
+	var reservations []reservation
+
+	reserve := func(scope Scope, lim *rate.Limiter) {
+		if lim != nil {
+			reservations = append(reservations, reservation{scope, lim.ReserveN(now, 1)})
+		}
+	}
+
+	reserve(ScopeGlobal, l.global)
+	reserve(ScopeSession, l.bucket(l.sessions, sessionID, l.cfg.Session))
+	reserve(ScopePeer, l.bucket(l.peers, sessionID+"\x00"+peer, l.cfg.Peer))
+
+	_, known := l.contacted[sessionID][peer]
+	if !known {
+		reserve(ScopeNewContact, l.bucket(l.newContacts, sessionID, l.cfg.NewContact))
+	}
+
+	var (
+		delay time.Duration
+		scope Scope
+	)
+	for _, res := range reservations {
+		if d := res.r.DelayFrom(now); d > delay {
+			delay, scope = d, res.scope
+		}
+	}
+
+	if delay > 0 && !wait {
+		for _, res := range reservations {
+			res.r.CancelAt(now)
+		}
+		l.mu.Unlock()
+		return &ExceededError{Scope: scope, RetryAfter: delay}
+	}
+
+	if !known {
+		if l.contacted[sessionID] == nil {
+			l.contacted[sessionID] = make(map[string]struct{})
+		}
+		l.contacted[sessionID][peer] = struct{}{}
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		for _, res := range reservations {
+			res.r.Cancel()
+		}
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// bucket returns the limiter of key, creating it on first use.
+// Must be called with mu held.
+func (l *Limiter) bucket(buckets map[string]*rate.Limiter, key string, limit Limit) *rate.Limiter {
+	if !limit.enabled() {
+		return nil
+	}
+
+	lim, ok := buckets[key]
+	if !ok {
+		lim = newBucket(limit)
+		buckets[key] = lim
+	}
+	return lim
+}
+
+// Update applies new limits. Existing buckets keep their tokens.
+func (l *Limiter) Update(cfg Config) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	switch {
+	case !cfg.Global.enabled():
+		l.global = nil
+	case l.global == nil:
+		l.global = newBucket(cfg.Global)
+	default:
+		l.global.SetLimit(cfg.Global.rate())
+		l.global.SetBurst(cfg.Global.Events)
+	}
+
+	update := func(buckets map[string]*rate.Limiter, limit Limit) {
+		for key, lim := range buckets {
+			if !limit.enabled() {
+				delete(buckets, key)
+				continue
+			}
+			lim.SetLimit(limit.rate())
+			lim.SetBurst(limit.Events)
+		}
+	}
+	update(l.sessions, cfg.Session)
+	update(l.peers, cfg.Peer)
+	update(l.newContacts, cfg.NewContact)
+
+	l.cfg = cfg
+}
+
+// Forget drops the state of a deleted session.
+func (l *Limiter) Forget(sessionID string) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.sessions, sessionID)
+	delete(l.newContacts, sessionID)
+	delete(l.contacted, sessionID)
+
+	prefix := sessionID + "\x00"
+	for key := range l.peers {
+		if strings.HasPrefix(key, prefix) {
+			delete(l.peers, key)
+		}
+	}
+}
+
+func normalizePeer(peer string) string {
+	return strings.ToLower(strings.TrimPrefix(peer, "@"))
+}
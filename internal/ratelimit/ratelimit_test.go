@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	l, err := ParseLimit("30/1s")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if l.Events != 30 || l.Per != time.Second {
+		t.Fatalf("unexpected limit: %+v", l)
+	}
+
+	for _, bad := range []string{"30", "0/1s", "x/1s", "1/0s", "1/abc"} {
+		if _, err := ParseLimit(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestLimiter_RejectPerPeer(t *testing.T) {
+	l := New(Config{Peer: Limit{Events: 1, Per: time.Hour}})
+	ctx := context.Background()
+
+	if err := l.Acquire(ctx, "s1", "@durov", false); err != nil {
+		t.Fatalf("first send: %v", err)
+	}
+
+	err := l.Acquire(ctx, "s1", "durov", false)
+	var exceeded *ExceededError
+	if !errors.As(err, &exceeded) || !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("expected ExceededError, got %v", err)
+	}
+	if exceeded.Scope != ScopePeer || exceeded.RetryAfter <= 0 {
+		t.Fatalf("unexpected error: %+v", exceeded)
+	}
+
+	// other peers and sessions are not affected
+	if err := l.Acquire(ctx, "s1", "@other", false); err != nil {
+		t.Fatalf("other peer: %v", err)
+	}
+	if err := l.Acquire(ctx, "s2", "@durov", false); err != nil {
+		t.Fatalf("other session: %v", err)
+	}
+}
+
+func TestLimiter_RejectedSendTakesNoTokens(t *testing.T) {
+	l := New(Config{
+		Session:    Limit{Events: 2, Per: time.Hour},
+		NewContact: Limit{Events: 1, Per: time.Hour},
+	})
+	ctx := context.Background()
+
+	if err := l.Acquire(ctx, "s1", "a", false); err != nil {
+		t.Fatalf("first send: %v", err)
+	}
+	// second new contact is rejected by the new contact bucket...
+	if err := l.Acquire(ctx, "s1", "b", false); err == nil {
+		t.Fatal("expected new contact limit")
+	}
+	// ...without using the session token, so a known peer still passes
+	if err := l.Acquire(ctx, "s1", "a", false); err != nil {
+		t.Fatalf("known peer: %v", err)
+	}
+}
+
+func TestLimiter_WaitQueues(t *testing.T) {
+	l := New(Config{Global: Limit{Events: 1, Per: 50 * time.Millisecond}})
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Acquire(ctx, "s1", "a", true); err != nil {
+			t.Fatalf("acquire: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("expected sends to be spread out, took %s", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := l.Acquire(ctx, "s1", "a", true); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context error, got %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/zen-flo/telegram-service/internal/broker"
+	"go.uber.org/zap"
+)
+
+// Expiry reasons, published as "expired: <reason>" system events.
+const (
+	ExpiredPendingTimeout = "pending_timeout"
+	ExpiredTTL            = "ttl"
+	ExpiredIdleTimeout    = "idle_timeout"
+)
+
+// ExpiryOptions configures when the reaper removes sessions.
+// A zero duration disables the policy.
+type ExpiryOptions struct {
+	// PendingTimeout expires sessions whose QR login was not completed
+	// in time after they were created. Resumed sessions already hold an
+	// auth key and are pending only until they reconnect, so it does
+	// not apply to them.
+	PendingTimeout time.Duration
+	// TTL expires sessions this long after they were created.
+	TTL time.Duration
+	// IdleTimeout expires sessions with no RPC activity and no open
+	// streams for this long.
+	IdleTimeout time.Duration
+	// ReapInterval is how often sessions are checked. Defaults to 30s.
+	ReapInterval time.Duration
+}
+
+func (o *ExpiryOptions) setDefaults() {
+	if o.ReapInterval <= 0 {
+		o.ReapInterval = 30 * time.Second
+	}
+}
+
+func (o *ExpiryOptions) enabled() bool {
+	return o.PendingTimeout > 0 || o.TTL > 0 || o.IdleTimeout > 0
+}
+
+// expiryReason returns why the session is expired at now, or "" if
+// it is not. Pending sessions only expire by PendingTimeout, so TTL
+// and idle periods count for authorized sessions. Resumed sessions
+// count as authorized while they reconnect.
+func (o *ExpiryOptions) expiryReason(s *Session, now time.Time) string {
+	if s.State() == StatePending && !s.resumed {
+		if o.PendingTimeout > 0 && now.Sub(s.startedAt) >= o.PendingTimeout {
+			return ExpiredPendingTimeout
+		}
+		return ""
+	}
+
+	switch {
+	case o.TTL > 0 && now.Sub(s.CreatedAt()) >= o.TTL:
+		return ExpiredTTL
+	case o.IdleTimeout > 0 && s.idle(now, o.IdleTimeout):
+		return ExpiredIdleTimeout
+	}
+	return ""
+}
+
+// RunReaper removes expired sessions until ctx is cancelled. It returns
+// immediately if no expiry policy is configured.
+func (m *Manager) RunReaper(ctx context.Context) {
+	opts := m.opts.Expiry
+	opts.setDefaults()
+
+	if !opts.enabled() {
+		return
+	}
+
+	ticker := time.NewTicker(opts.ReapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.reap(ctx, now)
+		}
+	}
+}
+
+// reap deletes the sessions expired at now. Authorized sessions are
+// logged out, pending ones are removed without contacting Telegram.
+// Each expiry is published to the session subscribers, with the scope
+// of the removed session.
+func (m *Manager) reap(ctx context.Context, now time.Time) {
+	type expired struct {
+		session *Session
+		reason  string
+	}
+
+	var list []expired
+
+	m.mu.RLock()
+	for _, s := range m.sessions {
+		if reason := m.opts.Expiry.expiryReason(s, now); reason != "" {
+			list = append(list, expired{session: s, reason: reason})
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, e := range list {
+		mode := DeleteLogout
+		if e.reason == ExpiredPendingTimeout {
+			mode = DeletePurgeLocal
+		}
+
+		err := m.Delete(ctx, e.session.ID(), mode)
+		if errors.Is(err, ErrSessionNotFound) {
+			// deleted concurrently
+			continue
+		}
+		if err != nil {
+			m.logger.Warn("failed to clean up expired session",
+				zap.String("session_id", e.session.ID()),
+				zap.Error(err),
+			)
+		}
+
+		m.logger.Info("session expired",
+			zap.String("session_id", e.session.ID()),
+			zap.String("reason", e.reason),
+		)
+
+		if m.dispatcher != nil {
+			// the session is gone, subscribers cannot look it up
+			msg := broker.SystemMessage("expired: " + e.reason)
+			msg.Scope = &broker.Scope{Tenant: e.session.Tenant(), Labels: e.session.Labels()}
+			m.dispatcher.Publish(e.session.ID(), msg)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/zen-flo/telegram-service/internal/broker"
+	"github.com/zen-flo/telegram-service/internal/telegram"
+	"go.uber.org/zap"
+)
+
+func TestManager_ReapPendingTimeout(t *testing.T) {
+	dispatcher := broker.NewDispatcher()
+	manager := NewManager(0, "", zap.NewNop(), dispatcher, Options{
+		Expiry: ExpiryOptions{PendingTimeout: time.Minute},
+	})
+
+	pending, _ := manager.Create(CreateOptions{Tenant: "acme", Labels: map[string]string{"team": "sales"}})
+	authorized, _ := manager.Create(CreateOptions{})
+	authorized.MarkReady()
+
+	events := dispatcher.Subscribe(pending.ID())
+	defer dispatcher.Unsubscribe(pending.ID(), events)
+
+	manager.reap(context.Background(), time.Now().Add(2*time.Minute))
+
+	if _, err := manager.Get(pending.ID()); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("expected pending session to expire, got %v", err)
+	}
+	if _, err := manager.Get(authorized.ID()); err != nil {
+		t.Fatalf("expected authorized session to stay, got %v", err)
+	}
+
+	select {
+	case msg := <-events:
+		if msg.From != broker.SystemSender || msg.Text != "expired: "+ExpiredPendingTimeout {
+			t.Fatalf("unexpected event: %+v", msg)
+		}
+		// subscribers routing by tenant or labels cannot look up the removed session
+		if msg.Scope == nil || msg.Scope.Tenant != "acme" || msg.Scope.Labels["team"] != "sales" {
+			t.Fatalf("expected the event to carry the scope of the session, got %+v", msg.Scope)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected an expiry event")
+	}
+}
+
+func TestManager_ReapKeepsResumedSession(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	manager := NewManager(0, "", zap.NewNop(), broker.NewDispatcher(), Options{
+		Expiry: ExpiryOptions{PendingTimeout: time.Minute, TTL: time.Hour},
+	})
+
+	id, err := generateID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir("sessions", 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(telegram.SessionPath(id), []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	resumed, err := manager.Resume(id, Owner{All: true})
+	if err != nil {
+		t.Fatalf("resume error: %v", err)
+	}
+	if resumed.State() != StatePending {
+		t.Fatalf("expected resumed session to be pending until it connects, got %s", resumed.State())
+	}
+
+	manager.reap(context.Background(), time.Now().Add(2*time.Minute))
+
+	if _, err := manager.Get(id); err != nil {
+		t.Fatalf("expected resumed session to outlive the pending timeout, got %v", err)
+	}
+	if _, err := os.Stat(telegram.SessionPath(id)); err != nil {
+		t.Fatalf("expected auth key to be kept: %v", err)
+	}
+
+	manager.reap(context.Background(), time.Now().Add(2*time.Hour))
+
+	if _, err := manager.Get(id); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("expected resumed session to expire by ttl, got %v", err)
+	}
+}
+
+func TestExpiryOptions_ExpiryReason(t *testing.T) {
+	opts := ExpiryOptions{TTL: time.Hour, IdleTimeout: 10 * time.Minute}
+
+	s := New("s1", nil, nil, nil)
+	s.MarkReady()
+	now := time.Now()
+
+	if got := opts.expiryReason(s, now); got != "" {
+		t.Fatalf("expected fresh session to stay, got %q", got)
+	}
+	if got := opts.expiryReason(s, now.Add(15*time.Minute)); got != ExpiredIdleTimeout {
+		t.Fatalf("expected idle timeout, got %q", got)
+	}
+
+	release := s.KeepAlive()
+	if got := opts.expiryReason(s, now.Add(15*time.Minute)); got != "" {
+		t.Fatalf("expected session with an open stream to stay, got %q", got)
+	}
+	release()
+
+	if got := opts.expiryReason(s, now.Add(2*time.Hour)); got != ExpiredTTL {
+		t.Fatalf("expected ttl, got %q", got)
+	}
+}
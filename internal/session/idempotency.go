@@ -0,0 +1,164 @@
+package session
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/zen-flo/telegram-service/internal/outbox"
+	"github.com/zen-flo/telegram-service/internal/telegram"
+)
+
+// ErrIdempotencyKeyReused is returned when an idempotency key is sent
+// again with a different peer or text.
+var ErrIdempotencyKeyReused = outbox.ErrIdempotencyKeyReused
+
+// randomIDFromKey derives the Telegram random_id of a send from its
+// idempotency key, so a retried send is also dropped by Telegram itself.
+func randomIDFromKey(sessionID, key string) int64 {
+	sum := sha256.Sum256([]byte(sessionID + "\x00" + key))
+
+	id := int64(binary.BigEndian.Uint64(sum[:8]))
+	if id == 0 {
+		id = 1
+	}
+	return id
+}
+
+func fingerprint(peer, text string) [32]byte {
+	return sha256.Sum256([]byte(peer + "\x00" + text))
+}
+
+// idempotencyCache remembers results of sends by idempotency key for
+// a time window. Concurrent sends with the same key wait for the first
+// one and share its result. It is kept in memory only, so a resumed
+// session starts with an empty cache.
+type idempotencyCache struct {
+	mu      sync.Mutex
+	window  time.Duration
+	entries map[string]*idempotencyEntry
+
+	// attempts holds when keys whose sends failed were first sent.
+	attempts map[string]time.Time
+	// restored is set for the cache of a resumed session: a key may
+	// have been sent before the resume, within the window.
+	restored bool
+}
+
+type idempotencyEntry struct {
+	fingerprint [32]byte
+	done        chan struct{}
+	expires     time.Time
+
+	sent telegram.SentMessage
+	err  error
+}
+
+func newIdempotencyCache(window time.Duration) *idempotencyCache {
+	if window <= 0 {
+		window = 24 * time.Hour
+	}
+
+	return &idempotencyCache{
+		window:   window,
+		entries:  make(map[string]*idempotencyEntry),
+		attempts: make(map[string]time.Time),
+	}
+}
+
+// do runs send once per key within the window and returns the message
+// of the first successful call. Failed calls are not remembered; when
+// one was delivered anyway, the retry reuses its random_id and gets
+// the delivered message back from telegram.Client.SendMessage. send
+// is passed the time of the first attempt with the key, which bounds
+// the lookup of that message.
+func (c *idempotencyCache) do(
+	ctx context.Context,
+	key string,
+	fp [32]byte,
+	send func(since time.Time) (telegram.SentMessage, error),
+) (telegram.SentMessage, error) {
+
+	for {
+		now := time.Now()
+
+		c.mu.Lock()
+		c.pruneLocked(now)
+
+		entry, ok := c.entries[key]
+		if !ok {
+			entry = &idempotencyEntry{
+				fingerprint: fp,
+				done:        make(chan struct{}),
+			}
+			c.entries[key] = entry
+			since := c.firstAttemptLocked(key, now)
+			c.mu.Unlock()
+
+			sent, err := send(since)
+
+			c.mu.Lock()
+			entry.sent, entry.err = sent, err
+			entry.expires = time.Now().Add(c.window)
+			if err != nil {
+				delete(c.entries, key)
+			} else {
+				delete(c.attempts, key)
+			}
+			c.mu.Unlock()
+			close(entry.done)
+
+			return sent, err
+		}
+		c.mu.Unlock()
+
+		if entry.fingerprint != fp {
+			return telegram.SentMessage{}, ErrIdempotencyKeyReused
+		}
+
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return telegram.SentMessage{}, ctx.Err()
+		}
+
+		if entry.err == nil {
+			return entry.sent, nil
+		}
+		// the first send failed, try again
+	}
+}
+
+// firstAttemptLocked returns when key was first sent, recording now
+// for a new key. A new key of a resumed session may have been sent
+// before the resume, so the start of the window is used instead. Must
+// be called with mu held.
+func (c *idempotencyCache) firstAttemptLocked(key string, now time.Time) time.Time {
+	if since, ok := c.attempts[key]; ok {
+		return since
+	}
+
+	since := now
+	if c.restored {
+		since = now.Add(-c.window)
+	}
+	c.attempts[key] = since
+	return since
+}
+
+// pruneLocked drops expired entries and attempts older than the
+// window. Must be called with mu held.
+func (c *idempotencyCache) pruneLocked(now time.Time) {
+	for key, entry := range c.entries {
+		if !entry.expires.IsZero() && now.After(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+	for key, since := range c.attempts {
+		if now.Sub(since) > c.window {
+			delete(c.attempts, key)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zen-flo/telegram-service/internal/telegram"
+)
+
+func TestIdempotencyCache_DuplicateReturnsOriginalID(t *testing.T) {
+	c := newIdempotencyCache(time.Minute)
+	fp := fingerprint("@durov", "hello")
+
+	var calls atomic.Int32
+	send := func(time.Time) (telegram.SentMessage, error) {
+		return telegram.SentMessage{ID: int64(100 + calls.Add(1))}, nil
+	}
+
+	var wg sync.WaitGroup
+	ids := make([]int64, 5)
+	for i := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sent, err := c.do(context.Background(), "key-1", fp, send)
+			if err != nil {
+				t.Errorf("send: %v", err)
+			}
+			ids[i] = sent.ID
+		}()
+	}
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("expected a single send, got %d", got)
+	}
+	for _, id := range ids {
+		if id != 101 {
+			t.Fatalf("expected original message id 101, got %d", id)
+		}
+	}
+}
+
+func TestIdempotencyCache_FailedSendIsRetried(t *testing.T) {
+	c := newIdempotencyCache(time.Minute)
+	fp := fingerprint("@durov", "hello")
+
+	if _, err := c.do(context.Background(), "key-1", fp, func(time.Time) (telegram.SentMessage, error) {
+		return telegram.SentMessage{}, errors.New("connection reset")
+	}); err == nil {
+		t.Fatal("expected the first send to fail")
+	}
+
+	sent, err := c.do(context.Background(), "key-1", fp, func(time.Time) (telegram.SentMessage, error) {
+		return telegram.SentMessage{ID: 7}, nil
+	})
+	if err != nil || sent.ID != 7 {
+		t.Fatalf("expected retry to send, got %d, %v", sent.ID, err)
+	}
+}
+
+func TestIdempotencyCache_RetryKeepsFirstAttempt(t *testing.T) {
+	c := newIdempotencyCache(time.Minute)
+	fp := fingerprint("@durov", "hello")
+
+	var first time.Time
+	before := time.Now()
+	_, _ = c.do(context.Background(), "key-1", fp, func(since time.Time) (telegram.SentMessage, error) {
+		first = since
+		return telegram.SentMessage{}, errors.New("connection reset")
+	})
+	if first.Before(before) {
+		t.Fatalf("expected the first attempt at the time of the send, got %v", first)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	_, _ = c.do(context.Background(), "key-1", fp, func(since time.Time) (telegram.SentMessage, error) {
+		if !since.Equal(first) {
+			t.Errorf("expected the retry to keep the first attempt %v, got %v", first, since)
+		}
+		return telegram.SentMessage{ID: 7}, nil
+	})
+}
+
+func TestIdempotencyCache_RestoredCoversWindow(t *testing.T) {
+	c := newIdempotencyCache(time.Hour)
+	c.restored = true
+
+	_, _ = c.do(context.Background(), "key-1", fingerprint("@durov", "hello"), func(since time.Time) (telegram.SentMessage, error) {
+		if since.After(time.Now().Add(-time.Hour)) {
+			t.Errorf("expected a send of a resumed session to cover the window, got %v", since)
+		}
+		return telegram.SentMessage{ID: 7}, nil
+	})
+}
+
+func TestIdempotencyCache_KeyReusedWithOtherMessage(t *testing.T) {
+	c := newIdempotencyCache(time.Minute)
+	send := func(time.Time) (telegram.SentMessage, error) { return telegram.SentMessage{ID: 1}, nil }
+
+	if _, err := c.do(context.Background(), "key-1", fingerprint("@durov", "hello"), send); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	_, err := c.do(context.Background(), "key-1", fingerprint("@durov", "bye"), send)
+	if !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Fatalf("expected ErrIdempotencyKeyReused, got %v", err)
+	}
+}
+
+func TestIdempotencyCache_Expires(t *testing.T) {
+	c := newIdempotencyCache(10 * time.Millisecond)
+	fp := fingerprint("@durov", "hello")
+
+	var calls atomic.Int32
+	send := func(time.Time) (telegram.SentMessage, error) {
+		return telegram.SentMessage{ID: int64(calls.Add(1))}, nil
+	}
+
+	_, _ = c.do(context.Background(), "key-1", fp, send)
+	time.Sleep(20 * time.Millisecond)
+	sent, _ := c.do(context.Background(), "key-1", fp, send)
+
+	if sent.ID != 2 {
+		t.Fatalf("expected a new send after the window, got id %d", sent.ID)
+	}
+}
+
+func TestRandomIDFromKey(t *testing.T) {
+	a := randomIDFromKey("s1", "key-1")
+	if a == 0 || a != randomIDFromKey("s1", "key-1") {
+		t.Fatal("random id must be stable and non-zero")
+	}
+	if a == randomIDFromKey("s2", "key-1") {
+		t.Fatal("random id must depend on the session")
+	}
+}
@@ -0,0 +1,91 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrSessionLimit = errors.New("session limit reached")
+
+// LimitError is returned by Create and Resume when a session limit is
+// reached. It matches ErrSessionLimit.
+type LimitError struct {
+	// Tenant is empty when the global limit is reached.
+	Tenant string
+	Limit  int
+}
+
+func (e *LimitError) Error() string {
+	if e.Tenant == "" {
+		return fmt.Sprintf("%s: at most %d sessions", ErrSessionLimit, e.Limit)
+	}
+	return fmt.Sprintf("%s: at most %d sessions for tenant %q", ErrSessionLimit, e.Limit, e.Tenant)
+}
+
+func (e *LimitError) Is(target error) bool {
+	return target == ErrSessionLimit
+}
+
+// Limits bounds the number of active sessions. Zero means no limit.
+type Limits struct {
+	MaxSessions          int
+	MaxSessionsPerTenant int
+	// TenantMaxSessions overrides MaxSessionsPerTenant for some tenants.
+	TenantMaxSessions map[string]int
+}
+
+func (l *Limits) tenantMax(tenant string) int {
+	if n, ok := l.TenantMaxSessions[tenant]; ok {
+		return n
+	}
+	return l.MaxSessionsPerTenant
+}
+
+// Usage reports the active sessions against the configured limits.
+type Usage struct {
+	Sessions    int
+	MaxSessions int
+
+	Tenant            string
+	TenantSessions    int
+	MaxTenantSessions int
+}
+
+// Usage returns the current number of sessions, in total and of tenant.
+func (m *Manager) Usage(tenant string) Usage {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return Usage{
+		Sessions:          len(m.sessions),
+		MaxSessions:       m.opts.Limits.MaxSessions,
+		Tenant:            tenant,
+		TenantSessions:    m.tenantSessionsLocked(tenant),
+		MaxTenantSessions: m.opts.Limits.tenantMax(tenant),
+	}
+}
+
+// admitLocked checks that one more session of tenant fits the limits.
+// Must be called with mu held for writing, in the same critical section
+// that adds the session.
+func (m *Manager) admitLocked(tenant string) error {
+	limits := m.opts.Limits
+
+	if limits.MaxSessions > 0 && len(m.sessions) >= limits.MaxSessions {
+		return &LimitError{Limit: limits.MaxSessions}
+	}
+	if max := limits.tenantMax(tenant); max > 0 && m.tenantSessionsLocked(tenant) >= max {
+		return &LimitError{Tenant: tenant, Limit: max}
+	}
+	return nil
+}
+
+func (m *Manager) tenantSessionsLocked(tenant string) int {
+	n := 0
+	for _, s := range m.sessions {
+		if s.Tenant() == tenant {
+			n++
+		}
+	}
+	return n
+}
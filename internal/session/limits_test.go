@@ -0,0 +1,67 @@
+package session
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/zen-flo/telegram-service/internal/broker"
+	"go.uber.org/zap"
+)
+
+func TestManager_GlobalLimitIsAtomic(t *testing.T) {
+	manager := NewManager(0, "", zap.NewNop(), broker.NewDispatcher(), Options{
+		Limits: Limits{MaxSessions: 10},
+	})
+
+	var created, rejected atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := manager.Create(CreateOptions{})
+			switch {
+			case err == nil:
+				created.Add(1)
+			case errors.Is(err, ErrSessionLimit):
+				rejected.Add(1)
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if created.Load() != 10 || rejected.Load() != 40 {
+		t.Fatalf("expected 10 created and 40 rejected, got %d and %d", created.Load(), rejected.Load())
+	}
+	if usage := manager.Usage(""); usage.Sessions != 10 || usage.MaxSessions != 10 {
+		t.Fatalf("unexpected usage: %+v", usage)
+	}
+}
+
+func TestManager_TenantLimit(t *testing.T) {
+	manager := NewManager(0, "", zap.NewNop(), broker.NewDispatcher(), Options{
+		Limits: Limits{MaxSessionsPerTenant: 1},
+	})
+
+	if _, err := manager.Create(CreateOptions{Tenant: "acme"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	_, err := manager.Create(CreateOptions{Tenant: "acme"})
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.Tenant != "acme" {
+		t.Fatalf("expected a tenant limit error, got %v", err)
+	}
+
+	if _, err := manager.Create(CreateOptions{Tenant: "other"}); err != nil {
+		t.Fatalf("expected another tenant to be admitted, got %v", err)
+	}
+
+	if usage := manager.Usage("acme"); usage.TenantSessions != 1 || usage.Sessions != 2 {
+		t.Fatalf("unexpected usage: %+v", usage)
+	}
+}
@@ -1,11 +1,17 @@
 package session
 
 import (
+	"context"
 	"crypto/rand"
 	"errors"
+	"fmt"
 	"github.com/zen-flo/telegram-service/internal/broker"
+	"github.com/zen-flo/telegram-service/internal/metrics"
+	"github.com/zen-flo/telegram-service/internal/outbox"
+	"github.com/zen-flo/telegram-service/internal/ratelimit"
 	"github.com/zen-flo/telegram-service/internal/telegram"
 	"go.uber.org/zap"
+	"os"
 	"sync"
 	"time"
 
@@ -14,6 +20,10 @@ import (
 
 var (
 	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionActive   = errors.New("session is already active")
+	// ErrInvalidSessionID is returned for IDs that are not ULIDs, so
+	// that they are never joined into a path under the session directory.
+	ErrInvalidSessionID = errors.New("invalid session id")
 )
 
 type Manager struct {
@@ -25,37 +35,188 @@ type Manager struct {
 	appHash string
 
 	dispatcher *broker.Dispatcher
+	limiter    *ratelimit.Limiter
+	opts       Options
 }
 
-func NewManager(appID int, appHash string, logger *zap.Logger, dispatcher *broker.Dispatcher) *Manager {
+type Options struct {
+	Outbox outbox.Options
+	// DeleteDrainTimeout bounds how long Delete waits for the outbound
+	// queue of a detached session. Defaults to 30s.
+	DeleteDrainTimeout time.Duration
+
+	RateLimit ratelimit.Config
+	// RejectOverLimit makes SendMessage fail with *ratelimit.ExceededError
+	// instead of waiting for a token. Queued sends always wait.
+	RejectOverLimit bool
+
+	// IdempotencyWindow is how long idempotency keys of SendMessage
+	// are remembered. Defaults to 24h.
+	IdempotencyWindow time.Duration
+
+	// DeleteRevokedAuthKeys removes the stored auth key and update state
+	// of sessions logged out remotely.
+	DeleteRevokedAuthKeys bool
+
+	Supervisor SupervisorOptions
+
+	Expiry ExpiryOptions
+
+	Limits Limits
+	// TenantLabels are added to every session of the tenant.
+	TenantLabels map[string]map[string]string
+}
+
+func (o *Options) setDefaults() {
+	if o.DeleteDrainTimeout <= 0 {
+		o.DeleteDrainTimeout = 30 * time.Second
+	}
+}
+
+func NewManager(appID int, appHash string, logger *zap.Logger, dispatcher *broker.Dispatcher, opts Options) *Manager {
+	opts.setDefaults()
+
 	return &Manager{
 		logger:     logger,
 		sessions:   make(map[string]*Session),
 		appID:      appID,
 		appHash:    appHash,
 		dispatcher: dispatcher,
+		limiter:    ratelimit.New(opts.RateLimit),
+		opts:       opts,
 	}
 }
 
-func (m *Manager) Create() (*Session, error) {
+// Limiter returns the send rate limiter shared by all sessions.
+func (m *Manager) Limiter() *ratelimit.Limiter {
+	return m.limiter
+}
+
+type CreateOptions struct {
+	// Tenant owns the session.
+	Tenant string
+	Labels map[string]string
+}
+
+func (m *Manager) Create(opts CreateOptions) (*Session, error) {
 	id, err := generateID()
 	if err != nil {
 		return nil, err
 	}
 
-	tgClient := telegram.NewClient(m.appID, m.appHash, m.logger, m.dispatcher, id)
+	labels := m.sessionLabels(opts.Tenant, opts.Labels)
+
+	session := m.newSession(id, opts.Tenant, labels)
+	stored := !session.telegramClient.Noop()
+
+	if stored {
+		meta := metadata{Tenant: opts.Tenant, Labels: labels, CreatedAt: session.CreatedAt().UTC()}
+		if err := saveMetadata(id, meta); err != nil {
+			return nil, fmt.Errorf("save session metadata: %w", err)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	session := New(id, tgClient, m.dispatcher)
+	if err := m.admitLocked(session.Tenant()); err != nil {
+		session.cancel()
+		if stored {
+			_ = telegram.RemoveSessionFiles(id)
+		}
+		return nil, err
+	}
 
+	m.sessions[id] = session
 	session.Start()
 
+	return session, nil
+}
+
+// Resume restarts a detached session of owner from its stored auth key.
+// The session becomes ready once the client has connected.
+func (m *Manager) Resume(id string, owner Owner) (*Session, error) {
+	if _, err := ulid.ParseStrict(id); err != nil {
+		return nil, ErrInvalidSessionID
+	}
+
+	if _, err := os.Stat(telegram.SessionPath(id)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+
+	meta, err := loadMetadata(id)
+	if err != nil {
+		return nil, fmt.Errorf("load session metadata: %w", err)
+	}
+	if !owner.Owns(meta.Tenant) {
+		return nil, ErrSessionNotFound
+	}
+
 	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.sessions[id]; ok {
+		return nil, ErrSessionActive
+	}
+	session := m.newSession(id, meta.Tenant, meta.Labels)
+	if !meta.CreatedAt.IsZero() {
+		session.createdAt = meta.CreatedAt
+	}
+	session.resumed = true
+	session.idempotency.restored = true
+
+	if err := session.outbox.Load(); err != nil {
+		session.cancel()
+		return nil, fmt.Errorf("load outbound queue: %w", err)
+	}
+
+	if err := m.admitLocked(session.Tenant()); err != nil {
+		session.cancel()
+		return nil, err
+	}
+
 	m.sessions[id] = session
-	m.mu.Unlock()
+	session.Start()
 
 	return session, nil
 }
 
+func (m *Manager) newSession(id, tenant string, labels map[string]string) *Session {
+	tgClient := telegram.NewClient(m.appID, m.appHash, m.logger, m.dispatcher, id)
+
+	session := New(id, tgClient, m.dispatcher, labels)
+	session.tenant = tenant
+	session.limiter = m.limiter
+	session.rejectOverLimit = m.opts.RejectOverLimit
+	session.idempotency = newIdempotencyCache(m.opts.IdempotencyWindow)
+	session.logger = m.logger.Named("session")
+	session.supervisorOpts = m.opts.Supervisor
+	var store outbox.Store
+	if !tgClient.Noop() {
+		store = outbox.NewFileStore(telegram.OutboxPath(id))
+	}
+	session.outbox = outbox.New(id, session.sendQueued, store, m.logger.Named("outbox"), m.opts.Outbox)
+
+	tgClient.OnAuthorized(session.MarkReady)
+	tgClient.OnRevoked(func(reason string) {
+		session.MarkLoggedOut(reason)
+
+		if m.opts.DeleteRevokedAuthKeys {
+			if err := telegram.RemoveSessionFiles(id); err != nil {
+				m.logger.Warn("failed to delete revoked auth key",
+					zap.String("session_id", id),
+					zap.Error(err),
+				)
+			}
+		}
+	})
+
+	return session
+}
+
 func (m *Manager) Get(id string) (*Session, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -68,19 +229,91 @@ func (m *Manager) Get(id string) (*Session, error) {
 	return session, nil
 }
 
-func (m *Manager) Delete(id string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+type DeleteMode int
 
+const (
+	// DeleteLogout logs out on Telegram and wipes the stored session.
+	DeleteLogout DeleteMode = iota
+	// DeleteDetach stops the client but keeps the stored auth key,
+	// so the session can be resumed with Resume.
+	DeleteDetach
+	// DeletePurgeLocal wipes the stored session without contacting
+	// Telegram. The authorization stays listed in the account's
+	// active sessions until it expires or is terminated there, and
+	// queued messages fail instead of being sent.
+	DeletePurgeLocal
+)
+
+// Delete removes the session. Draining the outbound queue of a detached
+// session is bounded by ctx and Options.DeleteDrainTimeout; jobs not
+// sent by then stay stored for Resume.
+func (m *Manager) Delete(ctx context.Context, id string, mode DeleteMode) error {
+	m.mu.Lock()
 	session, ok := m.sessions[id]
 	if !ok {
+		m.mu.Unlock()
 		return ErrSessionNotFound
 	}
+	delete(m.sessions, id)
+	m.mu.Unlock()
 
-	session.Close()
+	ctx, cancel := context.WithTimeout(ctx, m.opts.DeleteDrainTimeout)
+	defer cancel()
 
-	delete(m.sessions, id)
-	return nil
+	switch mode {
+	case DeleteDetach:
+		session.Shutdown(ctx)
+	case DeletePurgeLocal:
+		session.Purge(ctx)
+	default:
+		session.Close()
+		session.wait(ctx)
+	}
+
+	// after the client and the outbound queue stopped, so that no late
+	// update or queued send recreates them
+	metrics.ForgetSession(id)
+	m.limiter.Forget(id)
+
+	if mode == DeleteDetach {
+		return nil
+	}
+
+	if session.telegramClient != nil && session.telegramClient.Noop() {
+		return nil
+	}
+	return telegram.RemoveSessionFiles(id)
+}
+
+// Shutdown drains and closes every session concurrently without
+// logging out. It returns when all sessions are closed or ctx is done.
+func (m *Manager) Shutdown(ctx context.Context) {
+	m.mu.RLock()
+	sessions := make([]*Session, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		sessions = append(sessions, s)
+	}
+	m.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, s := range sessions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Shutdown(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// SubscribeAll returns a channel with messages of every session,
+// including sessions created after the call.
+func (m *Manager) SubscribeAll() <-chan *broker.Message {
+	return m.dispatcher.SubscribeAll()
+}
+
+func (m *Manager) UnsubscribeAll(ch <-chan *broker.Message) {
+	m.dispatcher.UnsubscribeAll(ch)
 }
 
 func generateID() (string, error) {
@@ -94,3 +327,22 @@ func generateID() (string, error) {
 
 	return id.String(), nil
 }
+
+// StateCounts returns the number of sessions in every state, zero
+// counts included.
+func (m *Manager) StateCounts() map[string]int {
+	counts := map[string]int{
+		string(StatePending):    0,
+		string(StateAuthorized): 0,
+		string(StateLoggedOut):  0,
+		string(StateFailed):     0,
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, s := range m.sessions {
+		counts[string(s.State())]++
+	}
+	return counts
+}
@@ -1,23 +1,27 @@
 package session
 
 import (
+	"context"
 	"errors"
 	"github.com/zen-flo/telegram-service/internal/broker"
+	"github.com/zen-flo/telegram-service/internal/outbox"
 	"go.uber.org/zap"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
 )
 
 func TestManager_CreateAndDelete(t *testing.T) {
-	manager := NewManager(0, "", zap.NewNop(), broker.NewDispatcher())
+	manager := NewManager(0, "", zap.NewNop(), broker.NewDispatcher(), Options{})
 
-	s, err := manager.Create()
+	s, err := manager.Create(CreateOptions{})
 	if err != nil {
 		t.Fatalf("create error: %v", err)
 	}
 
-	if err := manager.Delete(s.ID()); err != nil {
+	if err := manager.Delete(context.Background(), s.ID(), DeleteLogout); err != nil {
 		t.Fatalf("delete error: %v", err)
 	}
 
@@ -29,8 +33,194 @@ func TestManager_CreateAndDelete(t *testing.T) {
 	}
 }
 
+func TestManager_DeleteDetachAndResume(t *testing.T) {
+	manager := NewManager(0, "", zap.NewNop(), broker.NewDispatcher(), Options{})
+
+	s, err := manager.Create(CreateOptions{})
+	if err != nil {
+		t.Fatalf("create error: %v", err)
+	}
+
+	if _, err := manager.Resume(s.ID(), Owner{All: true}); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("expected ErrSessionNotFound without a stored session, got %v", err)
+	}
+
+	if err := manager.Delete(context.Background(), s.ID(), DeleteDetach); err != nil {
+		t.Fatalf("delete error: %v", err)
+	}
+
+	if _, err := manager.Get(s.ID()); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("expected detached session to be unregistered, got %v", err)
+	}
+
+	select {
+	case <-s.Context().Done():
+		// OK
+	case <-time.After(time.Second):
+		t.Fatal("expected session context to be cancelled")
+	}
+}
+
+func TestManager_ResumeRejectsInvalidID(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	if err := os.Mkdir("sessions", 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("victim.json", []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewManager(0, "", zap.NewNop(), broker.NewDispatcher(), Options{})
+
+	for _, id := range []string{"../victim", "../../x", "", "01ARZ3NDEKTSV4RRFFQ69G5FAV/.."} {
+		if _, err := manager.Resume(id, Owner{All: true}); !errors.Is(err, ErrInvalidSessionID) {
+			t.Fatalf("Resume(%q): expected ErrInvalidSessionID, got %v", id, err)
+		}
+	}
+
+	if _, err := os.Stat("victim.json"); err != nil {
+		t.Fatalf("expected file outside the session directory to be untouched: %v", err)
+	}
+	if _, err := manager.Get("../victim"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("expected no session to be registered, got %v", err)
+	}
+}
+
+// startBlocked registers a session whose outbound queue never manages
+// to send, with one queued job.
+func startBlocked(t *testing.T, manager *Manager) (*Session, outbox.Job) {
+	t.Helper()
+
+	id, err := generateID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := manager.newSession(id, "", nil)
+	s.outbox = outbox.New(id, func(ctx context.Context, peer, text string, randomID int64, since time.Time) (int64, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}, nil, zap.NewNop(), outbox.Options{})
+
+	manager.mu.Lock()
+	manager.sessions[id] = s
+	manager.mu.Unlock()
+	s.Start()
+
+	job, err := s.outbox.Enqueue(outbox.Request{Peer: "@durov", Text: "hello"})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	return s, job
+}
+
+func TestManager_DeleteDrainTimeout(t *testing.T) {
+	manager := NewManager(0, "", zap.NewNop(), broker.NewDispatcher(), Options{
+		DeleteDrainTimeout: 50 * time.Millisecond,
+	})
+
+	detached, job := startBlocked(t, manager)
+
+	start := time.Now()
+	if err := manager.Delete(context.Background(), detached.ID(), DeleteDetach); err != nil {
+		t.Fatalf("delete error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected the drain to be bounded, took %s", elapsed)
+	}
+	if got, _ := detached.outbox.Get(job.ID); got.Status != outbox.StatusQueued {
+		t.Fatalf("expected the job of a detached session to stay queued, got %s", got.Status)
+	}
+
+	purged, job := startBlocked(t, manager)
+
+	if err := manager.Delete(context.Background(), purged.ID(), DeletePurgeLocal); err != nil {
+		t.Fatalf("delete error: %v", err)
+	}
+	got, _ := purged.outbox.Get(job.ID)
+	if got.Status != outbox.StatusFailed || got.LastError != ErrSessionPurged.Error() {
+		t.Fatalf("expected the job of a purged session to fail, got %+v", got)
+	}
+}
+
+func TestSession_ResumeSendsRestoredJobs(t *testing.T) {
+	manager := NewManager(0, "", zap.NewNop(), broker.NewDispatcher(), Options{})
+
+	id, err := generateID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a job interrupted while sending before the session was detached
+	store := outbox.NewFileStore(filepath.Join(t.TempDir(), "outbox.jsonl"))
+	if err := store.Put(outbox.Job{
+		ID:        "job1",
+		SessionID: id,
+		Peer:      "@durov",
+		Text:      "hello",
+		RandomID:  7,
+		Status:    outbox.StatusSending,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := manager.newSession(id, "", nil)
+	s.resumed = true
+
+	// fails like sendQueued while the session is not authorized, but
+	// sends without a Telegram connection
+	sent := make(chan int64, 1)
+	s.outbox = outbox.New(id, func(ctx context.Context, peer, text string, randomID int64, since time.Time) (int64, error) {
+		if err := s.checkReady(); err != nil {
+			return 0, outbox.Permanent(err)
+		}
+		sent <- randomID
+		return 42, nil
+	}, store, zap.NewNop(), outbox.Options{})
+
+	if err := s.outbox.Load(); err != nil {
+		t.Fatal(err)
+	}
+	manager.mu.Lock()
+	manager.sessions[id] = s
+	manager.mu.Unlock()
+	s.Start()
+	defer manager.Delete(context.Background(), id, DeleteDetach)
+
+	// the client has not connected yet
+	time.Sleep(100 * time.Millisecond)
+	if job, _ := s.outbox.Get("job1"); job.Status != outbox.StatusQueued || job.Attempts != 0 {
+		t.Fatalf("expected the job to wait for the authorization, got %+v", job)
+	}
+
+	s.MarkReady()
+
+	select {
+	case randomID := <-sent:
+		if randomID != 7 {
+			t.Fatalf("expected the stored random_id, got %d", randomID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the restored job to be sent once the session is authorized")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		job, _ := s.outbox.Get("job1")
+		if job.Status == outbox.StatusSent && job.MessageID == 42 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the job to be sent, got %+v", job)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
 func TestManager_ConcurrentCreate(t *testing.T) {
-	manager := NewManager(0, "", zap.NewNop(), broker.NewDispatcher())
+	manager := NewManager(0, "", zap.NewNop(), broker.NewDispatcher(), Options{})
 
 	const n = 100
 	var wg sync.WaitGroup
@@ -39,7 +229,7 @@ func TestManager_ConcurrentCreate(t *testing.T) {
 	for i := 0; i < n; i++ {
 		go func() {
 			defer wg.Done()
-			if _, err := manager.Create(); err != nil {
+			if _, err := manager.Create(CreateOptions{}); err != nil {
 				t.Errorf("unexpected error: %v", err)
 			}
 		}()
@@ -57,10 +247,60 @@ func TestManager_ConcurrentCreate(t *testing.T) {
 }
 
 func TestManager_DeleteNotFound(t *testing.T) {
-	manager := NewManager(0, "", zap.NewNop(), broker.NewDispatcher())
+	manager := NewManager(0, "", zap.NewNop(), broker.NewDispatcher(), Options{})
 
-	err := manager.Delete("not-exist")
+	err := manager.Delete(context.Background(), "not-exist", DeleteLogout)
 	if !errors.Is(err, ErrSessionNotFound) {
 		t.Fatalf("expected ErrSessionNotFound")
 	}
 }
+
+func TestSession_LoggedOut(t *testing.T) {
+	manager := NewManager(0, "", zap.NewNop(), broker.NewDispatcher(), Options{})
+
+	s, err := manager.Create(CreateOptions{})
+	if err != nil {
+		t.Fatalf("create error: %v", err)
+	}
+	defer manager.Delete(context.Background(), s.ID(), DeleteLogout)
+
+	if s.State() != StatePending {
+		t.Fatalf("expected pending state, got %s", s.State())
+	}
+
+	s.MarkReady()
+	s.MarkLoggedOut("AUTH_KEY_UNREGISTERED")
+
+	if s.State() != StateLoggedOut || s.IsReady() {
+		t.Fatalf("expected logged out state, got %s", s.State())
+	}
+	if s.LogoutReason() != "AUTH_KEY_UNREGISTERED" {
+		t.Fatalf("unexpected logout reason %q", s.LogoutReason())
+	}
+
+	if _, err := s.SendMessage(context.Background(), "@durov", "hi", ""); !errors.Is(err, ErrSessionLoggedOut) {
+		t.Fatalf("expected ErrSessionLoggedOut, got %v", err)
+	}
+}
+
+func TestManager_ShutdownKeepsSessions(t *testing.T) {
+	manager := NewManager(0, "", zap.NewNop(), broker.NewDispatcher(), Options{})
+
+	s, err := manager.Create(CreateOptions{})
+	if err != nil {
+		t.Fatalf("create error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	manager.Shutdown(ctx)
+
+	select {
+	case <-s.stopped:
+	default:
+		t.Fatal("expected the session client to be stopped")
+	}
+	if _, err := manager.Get(s.ID()); err != nil {
+		t.Fatalf("shutdown must not delete sessions: %v", err)
+	}
+}
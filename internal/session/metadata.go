@@ -0,0 +1,56 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/zen-flo/telegram-service/internal/telegram"
+)
+
+// metadata is stored next to the auth key of a session, so that a
+// detached session can be resumed with the same settings.
+type metadata struct {
+	Tenant    string            `json:"tenant,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+func saveMetadata(sessionID string, meta metadata) error {
+	path := telegram.MetaPath(sessionID)
+
+	raw, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadMetadata reads the metadata of a stored session. Sessions stored
+// without metadata get empty metadata.
+func loadMetadata(sessionID string) (metadata, error) {
+	raw, err := os.ReadFile(telegram.MetaPath(sessionID))
+	if errors.Is(err, os.ErrNotExist) {
+		return metadata{}, nil
+	}
+	if err != nil {
+		return metadata{}, err
+	}
+
+	var meta metadata
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return metadata{}, err
+	}
+	return meta, nil
+}
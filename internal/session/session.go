@@ -3,17 +3,38 @@ package session
 import (
 	"context"
 	"errors"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/zen-flo/telegram-service/internal/broker"
+	"github.com/zen-flo/telegram-service/internal/outbox"
+	"github.com/zen-flo/telegram-service/internal/ratelimit"
 	"github.com/zen-flo/telegram-service/internal/telegram"
+	"github.com/zen-flo/telegram-service/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrSessionNotAuthorized = errors.New("session not authorized")
+	ErrSessionLoggedOut     = errors.New("session logged out")
+	ErrSessionPurged        = errors.New("session purged before the message was sent")
+)
+
+type State string
+
+const (
+	StatePending    State = "pending" // waiting for the QR login
+	StateAuthorized State = "authorized"
+	StateLoggedOut  State = "logged_out" // authorization revoked remotely
+	StateFailed     State = "failed"     // the client kept failing and is not restarted
 )
 
 type TelegramClient interface {
 	Start(ctx context.Context) error
 	StartQR(ctx context.Context, onReady func()) (string, error)
-	SendMessage(ctx context.Context, peer, text string) (int64, error)
+	SendMessage(ctx context.Context, peer, text string, randomID int64, since time.Time) (telegram.SentMessage, error)
 	Messages() <-chan *telegram.IncomingMessage
 }
 
@@ -24,27 +45,115 @@ type Session struct {
 
 	telegramClient *telegram.Client
 	dispatcher     *broker.Dispatcher
+	outbox         *outbox.Queue
+
+	limiter         *ratelimit.Limiter
+	rejectOverLimit bool
+	idempotency     *idempotencyCache
+
+	tenant string
+	labels map[string]string
 
-	authReady atomic.Bool
-	qrCode    string
+	authReady  atomic.Bool
+	authOnce   sync.Once
+	authorized chan struct{} // closed when the session is first authorized
+	qrCode     string
+
+	mu           sync.Mutex
+	loggedOut    bool
+	logoutReason string
+
+	createdAt    time.Time    // when the session was created, kept across resumes
+	startedAt    time.Time    // when the session was created or resumed
+	resumed      bool         // started from a stored auth key, never waits for a QR login
+	lastActivity atomic.Int64 // unix nanoseconds of the last RPC using the session
+	streams      atomic.Int32 // open streams, the session is not idle while > 0
+
+	logger         *zap.Logger
+	supervisorOpts SupervisorOptions
+	supervisor     supervisorStatus
+	stopped        chan struct{} // closed when the supervisor returns
+	outboxStopped  chan struct{} // closed when the outbound queue stops, nil if it never ran
 }
 
-func New(id string, client *telegram.Client, dispatcher *broker.Dispatcher) *Session {
+func New(id string, client *telegram.Client, dispatcher *broker.Dispatcher, labels map[string]string) *Session {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &Session{
+	copied := make(map[string]string, len(labels))
+	for k, v := range labels {
+		copied[k] = v
+	}
+
+	now := time.Now()
+
+	session := &Session{
 		id:             id,
 		ctx:            ctx,
 		cancel:         cancel,
 		telegramClient: client,
 		dispatcher:     dispatcher,
+		idempotency:    newIdempotencyCache(0),
+		labels:         copied,
+		createdAt:      now,
+		startedAt:      now,
+		authorized:     make(chan struct{}),
+		logger:         zap.NewNop(),
+		stopped:        make(chan struct{}),
 	}
+	session.lastActivity.Store(now.UnixNano())
+
+	return session
 }
 
 func (s *Session) ID() string {
 	return s.id
 }
 
+// Labels returns the labels the session was created with.
+// The returned map must not be modified.
+func (s *Session) Labels() map[string]string {
+	return s.labels
+}
+
+// MatchLabels reports whether the session carries every label of the selector.
+func (s *Session) MatchLabels(selector map[string]string) bool {
+	return broker.MatchLabels(s.labels, selector)
+}
+
+// CreatedAt returns when the session was created. A resumed session
+// keeps the time of its original creation.
+func (s *Session) CreatedAt() time.Time {
+	return s.createdAt
+}
+
+// Touch records RPC activity for the idle timeout.
+func (s *Session) Touch() {
+	s.lastActivity.Store(time.Now().UnixNano())
+}
+
+// KeepAlive marks the session as in use by a stream until the returned
+// function is called, so it does not expire by the idle timeout.
+func (s *Session) KeepAlive() (release func()) {
+	s.streams.Add(1)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.streams.Add(-1)
+			s.Touch()
+		})
+	}
+}
+
+// LastActivity returns the time of the last RPC using the session.
+func (s *Session) LastActivity() time.Time {
+	return time.Unix(0, s.lastActivity.Load())
+}
+
+func (s *Session) idle(now time.Time, timeout time.Duration) bool {
+	return s.streams.Load() == 0 && now.Sub(s.LastActivity()) >= timeout
+}
+
 func (s *Session) Context() context.Context {
 	return s.ctx
 }
@@ -55,12 +164,59 @@ func (s *Session) QR() string {
 
 func (s *Session) MarkReady() {
 	s.authReady.Store(true)
+	s.authOnce.Do(func() { close(s.authorized) })
+}
+
+// authorizedOnce reports whether the session has been authorized since
+// it was started, even if it was logged out later.
+func (s *Session) authorizedOnce() bool {
+	select {
+	case <-s.authorized:
+		return true
+	default:
+		return false
+	}
 }
 
 func (s *Session) IsReady() bool {
 	return s.authReady.Load()
 }
 
+// MarkLoggedOut moves the session to StateLoggedOut. The session stays
+// registered so its state can be inspected until it is deleted.
+func (s *Session) MarkLoggedOut(reason string) {
+	s.mu.Lock()
+	s.loggedOut = true
+	s.logoutReason = reason
+	s.mu.Unlock()
+
+	s.authReady.Store(false)
+}
+
+func (s *Session) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case s.loggedOut:
+		return StateLoggedOut
+	case s.clientFailed():
+		return StateFailed
+	case s.IsReady():
+		return StateAuthorized
+	}
+	return StatePending
+}
+
+// LogoutReason returns the Telegram error that revoked the session,
+// e.g. AUTH_KEY_UNREGISTERED.
+func (s *Session) LogoutReason() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.logoutReason
+}
+
 func (s *Session) Close() {
 	if s.telegramClient != nil {
 		s.telegramClient.LogOut()
@@ -70,13 +226,92 @@ func (s *Session) Close() {
 	}
 }
 
+// Shutdown drains the outbound queue and closes the Telegram
+// connection without logging out, so the authorization survives a
+// restart. Jobs still queued when ctx is done stay in the stored queue
+// and are sent again once the session is resumed.
+func (s *Session) Shutdown(ctx context.Context) {
+	// the queue of a session that was never authorized does not run
+	if s.outbox != nil && s.authorizedOnce() {
+		if err := s.outbox.Drain(ctx); err != nil {
+			s.logger.Warn("outbound queue not drained, keeping the rest for resume",
+				zap.String("session_id", s.id),
+				zap.Int("pending", s.outbox.Pending()),
+				zap.Error(err),
+			)
+		}
+	}
+
+	s.cancel()
+	s.wait(ctx)
+}
+
+// Purge stops the session without sending its queued messages: they
+// fail with ErrSessionPurged.
+func (s *Session) Purge(ctx context.Context) {
+	s.cancel()
+	s.wait(ctx)
+
+	if s.outbox != nil {
+		s.outbox.FailPending(ErrSessionPurged)
+	}
+}
+
+// outboxStopTimeout is how long a cancelled session waits for its
+// outbound queue to stop. It is not bounded by the shutdown context,
+// which a drain that timed out has already used up.
+const outboxStopTimeout = 5 * time.Second
+
+// wait waits until the outbound queue and the Telegram client of a
+// cancelled session stop, the client at most until ctx is done.
+func (s *Session) wait(ctx context.Context) {
+	// an interrupted send is stored as queued when the queue stops
+	if s.outboxStopped != nil {
+		timer := time.NewTimer(outboxStopTimeout)
+		defer timer.Stop()
+
+		select {
+		case <-s.outboxStopped:
+		case <-timer.C:
+			s.logger.Warn("outbound queue did not stop in time",
+				zap.String("session_id", s.id),
+			)
+		}
+	}
+
+	select {
+	case <-s.stopped:
+	case <-ctx.Done():
+		s.logger.Warn("telegram client did not stop in time",
+			zap.String("session_id", s.id),
+		)
+	}
+}
+
 func (s *Session) Start() {
 	if s.telegramClient == nil {
+		close(s.stopped)
 		return
 	}
 	go func() {
-		_ = s.telegramClient.Start(s.ctx)
+		defer close(s.stopped)
+		s.supervise(s.telegramClient.Start)
 	}()
+	if s.outbox != nil {
+		s.outboxStopped = make(chan struct{})
+		go func() {
+			defer close(s.outboxStopped)
+
+			// Jobs restored on resume wait until the client has
+			// connected, sendQueued would fail them as not authorized.
+			select {
+			case <-s.authorized:
+			case <-s.ctx.Done():
+				return
+			}
+			s.outbox.Run(s.ctx)
+		}()
+	}
 }
 
 func (s *Session) StartQR(onReady func()) (string, error) {
@@ -96,22 +331,149 @@ func (s *Session) StartQR(onReady func()) (string, error) {
 	return qr, nil
 }
 
+// PasswordRequired reports whether the QR login waits for the 2FA
+// password.
+func (s *Session) PasswordRequired() bool {
+	return s.telegramClient != nil && s.telegramClient.PasswordRequired()
+}
+
+// SubmitPassword passes the 2FA password to the QR login waiting for
+// it. A wrong password returns telegram.ErrPasswordInvalid.
+func (s *Session) SubmitPassword(ctx context.Context, password string) error {
+	if s.telegramClient == nil {
+		return telegram.ErrPasswordNotRequired
+	}
+	return s.telegramClient.SubmitPassword(ctx, password)
+}
+
 func (s *Session) SubscribeMessages() <-chan *broker.Message {
 	return s.dispatcher.Subscribe(s.id)
 }
 
-func (s *Session) Unsubscribe(ch chan *broker.Message) {
+func (s *Session) Unsubscribe(ch <-chan *broker.Message) {
 	s.dispatcher.Unsubscribe(s.id, ch)
 }
 
-func (s *Session) SendMessage(peer, text string) (int64, error) {
-	if !s.IsReady() {
-		return 0, errors.New("session not authorized")
+// SendMessage sends the message right away. ctx bounds the wait for
+// the rate limiter.
+//
+// A non-empty idempotencyKey makes the send idempotent within the
+// idempotency window: repeating it returns the message sent first
+// instead of sending it again. The keys are kept in memory and do not
+// survive a resume; a key repeated after it is still dropped by
+// Telegram as a duplicate random_id, and the message sent first is
+// then looked up in the chat history among the messages of the
+// idempotency window.
+func (s *Session) SendMessage(ctx context.Context, peer, text, idempotencyKey string) (_ telegram.SentMessage, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "session.SendMessage",
+		trace.WithAttributes(tracing.AttrSessionID.String(s.id)),
+	)
+	defer func() {
+		tracing.Fail(span, err)
+		span.End()
+	}()
+
+	if err := s.checkReady(); err != nil {
+		return telegram.SentMessage{}, err
 	}
 
-	return s.telegramClient.SendMessage(
-		s.ctx,
-		peer,
-		text,
-	)
+	send := func(randomID int64, since time.Time) (telegram.SentMessage, error) {
+		waitCtx, wait := tracing.Tracer().Start(ctx, "ratelimit.Acquire")
+		err := tracing.Fail(wait, s.limiter.Acquire(waitCtx, s.id, peer, !s.rejectOverLimit))
+		wait.End()
+		if err != nil {
+			return telegram.SentMessage{}, err
+		}
+
+		// The send is bound to the session rather than the call, but
+		// stays in the trace of the call.
+		return s.telegramClient.SendMessage(
+			trace.ContextWithSpan(s.ctx, span),
+			peer,
+			text,
+			randomID,
+			since,
+		)
+	}
+
+	if idempotencyKey == "" {
+		return send(0, time.Now())
+	}
+
+	return s.idempotency.do(ctx, idempotencyKey, fingerprint(peer, text), func(since time.Time) (telegram.SentMessage, error) {
+		return send(randomIDFromKey(s.id, idempotencyKey), since)
+	})
+}
+
+// History returns messages of the chat with peer older than offsetID,
+// newest first.
+func (s *Session) History(ctx context.Context, peer string, offsetID int64, limit int) ([]telegram.HistoryMessage, error) {
+	if err := s.checkReady(); err != nil {
+		return nil, err
+	}
+	return s.telegramClient.History(ctx, peer, offsetID, limit)
+}
+
+// EnqueueMessage adds the message to the outbound queue and returns
+// the job without waiting for it to be sent. Enqueueing an idempotency
+// key again returns the job created first.
+func (s *Session) EnqueueMessage(peer, text, idempotencyKey string) (outbox.Job, error) {
+	if err := s.checkReady(); err != nil {
+		return outbox.Job{}, err
+	}
+
+	req := outbox.Request{
+		Peer:           peer,
+		Text:           text,
+		IdempotencyKey: idempotencyKey,
+	}
+	if idempotencyKey != "" {
+		req.RandomID = randomIDFromKey(s.id, idempotencyKey)
+	}
+
+	return s.outbox.Enqueue(req)
+}
+
+func (s *Session) SendStatus(jobID string) (outbox.Job, error) {
+	return s.outbox.Get(jobID)
+}
+
+// SubscribeDeliveries returns a channel with status changes of queued jobs.
+func (s *Session) SubscribeDeliveries() <-chan outbox.Job {
+	return s.outbox.Subscribe()
+}
+
+func (s *Session) UnsubscribeDeliveries(ch <-chan outbox.Job) {
+	s.outbox.Unsubscribe(ch)
+}
+
+func (s *Session) checkReady() error {
+	switch s.State() {
+	case StateLoggedOut:
+		return ErrSessionLoggedOut
+	case StatePending, StateFailed:
+		return ErrSessionNotAuthorized
+	}
+	return nil
+}
+
+// sendQueued is the outbox send function. Errors that cannot be fixed
+// by retrying are marked permanent.
+func (s *Session) sendQueued(ctx context.Context, peer, text string, randomID int64, since time.Time) (int64, error) {
+	if err := s.checkReady(); err != nil {
+		return 0, outbox.Permanent(err)
+	}
+
+	if err := s.limiter.Acquire(ctx, s.id, peer, true); err != nil {
+		return 0, err
+	}
+
+	sent, err := s.telegramClient.SendMessage(ctx, peer, text, randomID, since)
+	if errors.Is(err, telegram.ErrInvalidPeer) ||
+		errors.Is(err, telegram.ErrPeerNotFound) ||
+		errors.Is(err, telegram.ErrNoopMode) ||
+		errors.Is(err, telegram.ErrMessageIDUnknown) {
+		return 0, outbox.Permanent(err)
+	}
+	return sent.ID, err
 }
@@ -0,0 +1,150 @@
+package session
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/zen-flo/telegram-service/internal/broker"
+	"go.uber.org/zap"
+)
+
+// stableRun is how long a client must run for its restart backoff and
+// restart budget to be reset.
+const stableRun = time.Minute
+
+type SupervisorOptions struct {
+	// MaxRestarts limits consecutive restarts of a failing client.
+	// After that the session moves to StateFailed.
+	MaxRestarts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+func (o *SupervisorOptions) setDefaults() {
+	if o.MaxRestarts <= 0 {
+		o.MaxRestarts = 10
+	}
+	if o.InitialBackoff <= 0 {
+		o.InitialBackoff = time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = 5 * time.Minute
+	}
+}
+
+// supervisorStatus is the restart history of a session client.
+type supervisorStatus struct {
+	mu          sync.Mutex
+	restarts    int
+	lastError   string
+	lastErrorAt time.Time
+	failed      bool
+}
+
+// supervise runs start, the Telegram client, and restarts it with
+// exponential backoff and jitter when it fails. It returns when the session is
+// closed, the client is stopped on purpose, or the restart budget is
+// exhausted.
+func (s *Session) supervise(start func(ctx context.Context) error) {
+	opts := s.supervisorOpts
+	opts.setDefaults()
+	logger := s.logger
+
+	backoff := opts.InitialBackoff
+	consecutive := 0
+
+	for {
+		started := time.Now()
+		err := start(s.ctx)
+
+		if s.ctx.Err() != nil || err == nil {
+			return
+		}
+
+		if time.Since(started) >= stableRun {
+			backoff = opts.InitialBackoff
+			consecutive = 0
+		}
+
+		s.supervisor.mu.Lock()
+		s.supervisor.lastError = err.Error()
+		s.supervisor.lastErrorAt = time.Now()
+		s.supervisor.mu.Unlock()
+
+		if consecutive >= opts.MaxRestarts {
+			logger.Error("telegram client failed, giving up",
+				zap.String("session_id", s.id),
+				zap.Int("restarts", consecutive),
+				zap.Error(err),
+			)
+
+			s.supervisor.mu.Lock()
+			s.supervisor.failed = true
+			s.supervisor.mu.Unlock()
+
+			s.dispatcher.Publish(s.id, broker.SystemMessage("failed: "+err.Error()))
+			return
+		}
+
+		wait := jitter(backoff)
+		logger.Warn("telegram client failed, restarting",
+			zap.String("session_id", s.id),
+			zap.Duration("wait", wait),
+			zap.Error(err),
+		)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		backoff = min(backoff*2, opts.MaxBackoff)
+		consecutive++
+
+		s.supervisor.mu.Lock()
+		s.supervisor.restarts++
+		s.supervisor.mu.Unlock()
+	}
+}
+
+// jitter returns a random duration in [d/2, d).
+func jitter(d time.Duration) time.Duration {
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + rand.N(half)
+}
+
+// Restarts returns how many times the Telegram client was restarted.
+func (s *Session) Restarts() int {
+	s.supervisor.mu.Lock()
+	defer s.supervisor.mu.Unlock()
+
+	return s.supervisor.restarts
+}
+
+// LastError returns the last error of the Telegram client and its time.
+func (s *Session) LastError() (string, time.Time) {
+	s.supervisor.mu.Lock()
+	defer s.supervisor.mu.Unlock()
+
+	return s.supervisor.lastError, s.supervisor.lastErrorAt
+}
+
+// Connected reports whether the Telegram client is connected.
+func (s *Session) Connected() bool {
+	return s.telegramClient != nil && s.telegramClient.Connected()
+}
+
+func (s *Session) clientFailed() bool {
+	s.supervisor.mu.Lock()
+	defer s.supervisor.mu.Unlock()
+
+	return s.supervisor.failed
+}
@@ -0,0 +1,80 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zen-flo/telegram-service/internal/broker"
+)
+
+func TestSupervisor_RestartsUntilCap(t *testing.T) {
+	d := broker.NewDispatcher()
+	events := d.Subscribe("s1")
+
+	s := New("s1", nil, d, nil)
+	s.supervisorOpts = SupervisorOptions{
+		MaxRestarts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     2 * time.Millisecond,
+	}
+
+	var starts atomic.Int32
+	s.supervise(func(ctx context.Context) error {
+		starts.Add(1)
+		return errors.New("connection refused")
+	})
+
+	if got := starts.Load(); got != 4 {
+		t.Fatalf("expected 4 starts, got %d", got)
+	}
+	if s.Restarts() != 3 {
+		t.Fatalf("expected 3 restarts, got %d", s.Restarts())
+	}
+	if lastErr, at := s.LastError(); lastErr != "connection refused" || at.IsZero() {
+		t.Fatalf("unexpected last error %q at %v", lastErr, at)
+	}
+	if s.State() != StateFailed {
+		t.Fatalf("expected failed state, got %s", s.State())
+	}
+
+	select {
+	case msg := <-events:
+		if !strings.HasPrefix(msg.Text, "failed: ") {
+			t.Fatalf("unexpected event %+v", msg)
+		}
+	default:
+		t.Fatal("expected a failed event")
+	}
+}
+
+func TestSupervisor_StopsOnCleanExit(t *testing.T) {
+	s := New("s1", nil, broker.NewDispatcher(), nil)
+	s.supervisorOpts = SupervisorOptions{InitialBackoff: time.Millisecond}
+
+	var starts atomic.Int32
+	s.supervise(func(ctx context.Context) error {
+		if starts.Add(1) == 1 {
+			return errors.New("boom")
+		}
+		return nil // logged out
+	})
+
+	if got := starts.Load(); got != 2 {
+		t.Fatalf("expected 2 starts, got %d", got)
+	}
+	if s.State() == StateFailed {
+		t.Fatal("a client stopped on purpose must not be failed")
+	}
+}
+
+func TestJitter(t *testing.T) {
+	for range 100 {
+		if d := jitter(time.Second); d < 500*time.Millisecond || d >= time.Second {
+			t.Fatalf("jitter out of range: %s", d)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package session
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// TenantLabel is the label carrying the tenant of a session. It is set
+// by the manager and cannot be chosen by tenants themselves.
+const TenantLabel = "tenant"
+
+// Owner selects the sessions a caller may access: those of Tenant, or
+// every session if All is set.
+type Owner struct {
+	Tenant string
+	All    bool
+}
+
+// Owns reports whether the owner may access sessions of tenant.
+func (o Owner) Owns(tenant string) bool {
+	return o.All || o.Tenant == tenant
+}
+
+// Tenant returns the tenant owning the session.
+func (s *Session) Tenant() string {
+	return s.tenant
+}
+
+// sessionLabels returns the labels of a new session of tenant: the
+// requested labels, overridden by the labels configured for the tenant
+// and by TenantLabel.
+func (m *Manager) sessionLabels(tenant string, requested map[string]string) map[string]string {
+	labels := maps.Clone(requested)
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	delete(labels, TenantLabel)
+
+	maps.Copy(labels, m.opts.TenantLabels[tenant])
+	if tenant != "" {
+		labels[TenantLabel] = tenant
+	}
+	return labels
+}
+
+// List returns the sessions of owner carrying every label of selector,
+// ordered by ID.
+func (m *Manager) List(owner Owner, selector map[string]string) []*Session {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var list []*Session
+	for _, s := range m.sessions {
+		if owner.Owns(s.Tenant()) && s.MatchLabels(selector) {
+			list = append(list, s)
+		}
+	}
+
+	slices.SortFunc(list, func(a, b *Session) int {
+		return strings.Compare(a.ID(), b.ID())
+	})
+	return list
+}
@@ -0,0 +1,40 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/zen-flo/telegram-service/internal/broker"
+	"go.uber.org/zap"
+)
+
+func TestManager_TenantLabelsAndList(t *testing.T) {
+	manager := NewManager(0, "", zap.NewNop(), broker.NewDispatcher(), Options{
+		TenantLabels: map[string]map[string]string{"acme": {"plan": "pro"}},
+	})
+
+	acme, err := manager.Create(CreateOptions{
+		Tenant: "acme",
+		Labels: map[string]string{TenantLabel: "globex", "plan": "free", "team": "sales"},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := manager.Create(CreateOptions{Tenant: "globex"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	labels := acme.Labels()
+	if labels[TenantLabel] != "acme" || labels["plan"] != "pro" || labels["team"] != "sales" {
+		t.Fatalf("unexpected labels: %v", labels)
+	}
+
+	if got := manager.List(Owner{Tenant: "acme"}, nil); len(got) != 1 || got[0] != acme {
+		t.Fatalf("expected only the acme session, got %d sessions", len(got))
+	}
+	if got := manager.List(Owner{All: true}, nil); len(got) != 2 {
+		t.Fatalf("expected every session, got %d", len(got))
+	}
+	if got := manager.List(Owner{All: true}, map[string]string{"team": "sales"}); len(got) != 1 {
+		t.Fatalf("expected the selector to apply, got %d sessions", len(got))
+	}
+}
@@ -0,0 +1,209 @@
+package sink
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Record is a single event waiting to be published.
+type Record struct {
+	SessionID string `json:"session_id,omitempty"`
+	Subject   string `json:"subject"`
+	Payload   []byte `json:"payload"`
+	// Trace is the traceparent of the update the event came from.
+	Trace string `json:"trace,omitempty"`
+}
+
+// DefaultBufferSize is the number of records a DiskBuffer holds by
+// default.
+const DefaultBufferSize = 100000
+
+// DiskBuffer is a JSON Lines file holding records that were not
+// published yet. The records are kept in memory as well, so draining
+// never reads the file. Records are drained in insertion order, and
+// may be appended while a drain is in progress.
+type DiskBuffer struct {
+	mu      sync.Mutex
+	drainMu sync.Mutex // serializes Drain
+	path    string
+	max     int
+	records []Record
+}
+
+// NewDiskBuffer opens the buffer at path holding at most maxRecords
+// records, DefaultBufferSize if maxRecords is 0. Records left over from
+// a previous run are kept even beyond the limit.
+func NewDiskBuffer(path string, maxRecords int) (*DiskBuffer, error) {
+	if maxRecords <= 0 {
+		maxRecords = DefaultBufferSize
+	}
+	b := &DiskBuffer{path: path, max: maxRecords}
+
+	records, err := b.read()
+	if err != nil {
+		return nil, err
+	}
+	b.records = records
+
+	return b, nil
+}
+
+// Len returns the number of buffered records.
+func (b *DiskBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.records)
+}
+
+// Append writes records behind the buffered ones and syncs the file
+// once. Records that do not fit in the buffer are dropped; it returns
+// how many.
+func (b *DiskBuffer) Append(records ...Record) (dropped int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	free := max(b.max-len(b.records), 0)
+	if len(records) > free {
+		dropped = len(records) - free
+		records = records[:free]
+	}
+	if len(records) == 0 {
+		return dropped, nil
+	}
+
+	var buf bytes.Buffer
+	for _, rec := range records {
+		raw, err := json.Marshal(rec)
+		if err != nil {
+			return dropped, err
+		}
+		buf.Write(raw)
+		buf.WriteByte('\n')
+	}
+
+	if err := os.MkdirAll(filepath.Dir(b.path), 0o700); err != nil {
+		return dropped, err
+	}
+
+	f, err := os.OpenFile(b.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return dropped, err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		return dropped, err
+	}
+	if err := f.Sync(); err != nil {
+		return dropped, err
+	}
+
+	b.records = append(b.records, records...)
+	return dropped, nil
+}
+
+// Drain calls fn for buffered records in order until fn fails.
+// Records handled successfully are removed from the buffer.
+// A crash during Drain may redeliver records, never lose them.
+//
+// fn is called without holding the buffer, so Append does not wait for
+// a slow drain. Records appended meanwhile are kept for the next one.
+// The file is removed once it is drained and rewritten only when a
+// drain stops halfway.
+func (b *DiskBuffer) Drain(fn func(rec Record) error) error {
+	b.drainMu.Lock()
+	defer b.drainMu.Unlock()
+
+	b.mu.Lock()
+	records := b.records
+	b.mu.Unlock()
+
+	done := 0
+	var fnErr error
+	for _, rec := range records {
+		if fnErr = fn(rec); fnErr != nil {
+			break
+		}
+		done++
+	}
+	if done == 0 {
+		return fnErr
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Only Drain removes records, so the first done records are still
+	// the ones handled above.
+	b.records = b.records[done:]
+	if len(b.records) == 0 {
+		b.records = nil
+	}
+	if err := b.rewrite(b.records); err != nil {
+		return err
+	}
+
+	return fnErr
+}
+
+func (b *DiskBuffer) read() ([]Record, error) {
+	raw, err := os.ReadFile(b.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	sc := bufio.NewScanner(bytes.NewReader(raw))
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		line := sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var rec Record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			// a torn write at the end of the file, nothing was acknowledged for it
+			continue
+		}
+		records = append(records, rec)
+	}
+
+	return records, sc.Err()
+}
+
+// rewrite replaces the buffer contents atomically. Must be called with mu held.
+func (b *DiskBuffer) rewrite(records []Record) error {
+	if len(records) == 0 {
+		err := os.Remove(b.path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, rec := range records {
+		raw, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		buf.Write(raw)
+		buf.WriteByte('\n')
+	}
+
+	tmp := b.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, b.path)
+}
@@ -0,0 +1,40 @@
+package sink
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSPublisher publishes to core NATS subjects. Every publish is
+// followed by a flush, so an error is returned unless the server
+// received the message.
+type NATSPublisher struct {
+	conn *nats.Conn
+}
+
+func NewNATSPublisher(url string) (*NATSPublisher, error) {
+	conn, err := nats.Connect(url,
+		nats.Name("telegram-service"),
+		nats.MaxReconnects(-1),
+		nats.RetryOnFailedConnect(true),
+		// fail publishes while disconnected, the DiskBuffer takes over
+		nats.ReconnectBufSize(-1),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NATSPublisher{conn: conn}, nil
+}
+
+func (p *NATSPublisher) Publish(ctx context.Context, subject string, payload []byte) error {
+	if err := p.conn.Publish(subject, payload); err != nil {
+		return err
+	}
+	return p.conn.FlushWithContext(ctx)
+}
+
+func (p *NATSPublisher) Close() error {
+	return p.conn.Drain()
+}
@@ -0,0 +1,45 @@
+package sink
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStreamsPublisher appends payloads to Redis Streams with XADD.
+// The subject is used as the stream key.
+type RedisStreamsPublisher struct {
+	client *redis.Client
+	maxLen int64
+}
+
+// NewRedisStreamsPublisher connects to a redis:// URL. When maxLen is
+// positive, streams are approximately trimmed to that length.
+func NewRedisStreamsPublisher(url string, maxLen int64) (*RedisStreamsPublisher, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RedisStreamsPublisher{
+		client: redis.NewClient(opts),
+		maxLen: maxLen,
+	}, nil
+}
+
+func (p *RedisStreamsPublisher) Publish(ctx context.Context, subject string, payload []byte) error {
+	args := &redis.XAddArgs{
+		Stream: subject,
+		Values: map[string]any{"payload": payload},
+	}
+	if p.maxLen > 0 {
+		args.MaxLen = p.maxLen
+		args.Approx = true
+	}
+
+	return p.client.XAdd(ctx, args).Err()
+}
+
+func (p *RedisStreamsPublisher) Close() error {
+	return p.client.Close()
+}
@@ -0,0 +1,297 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zen-flo/telegram-service/internal/broker"
+	"github.com/zen-flo/telegram-service/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Publisher writes payloads to an external message broker.
+// Publish must return only after the broker accepted the payload.
+type Publisher interface {
+	Publish(ctx context.Context, subject string, payload []byte) error
+	Close() error
+}
+
+// Subject renders a subject/topic template. The {session_id}
+// placeholder is replaced with the ID of the session.
+func Subject(template, sessionID string) string {
+	return strings.ReplaceAll(template, "{session_id}", sessionID)
+}
+
+type ForwarderOptions struct {
+	// SubjectTemplate is the subject (NATS) or stream key (Redis),
+	// see Subject.
+	SubjectTemplate string
+	// RetryInterval is how often buffered records are retried.
+	RetryInterval time.Duration
+	// PublishTimeout bounds a single publish call.
+	PublishTimeout time.Duration
+	// QueueSize limits the records waiting in memory to be published
+	// or spilled to the disk buffer. Further records are dropped.
+	QueueSize int
+}
+
+func (o *ForwarderOptions) setDefaults() {
+	if o.SubjectTemplate == "" {
+		o.SubjectTemplate = "telegram.sessions.{session_id}.messages"
+	}
+	if o.RetryInterval <= 0 {
+		o.RetryInterval = 5 * time.Second
+	}
+	if o.PublishTimeout <= 0 {
+		o.PublishTimeout = 5 * time.Second
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = 10000
+	}
+}
+
+// Forwarder publishes every dispatcher message to a Publisher.
+//
+// Messages are queued in memory on the publish path and published in
+// order by Run. While the broker fails, queued records are spilled to
+// a DiskBuffer and retried from there, so delivery is at-least-once
+// across broker outages and restarts. Records that find the memory
+// queue or the disk buffer full are dropped and logged.
+type Forwarder struct {
+	dispatcher *broker.Dispatcher
+	publisher  Publisher
+	buffer     *DiskBuffer
+	logger     *zap.Logger
+	opts       ForwarderOptions
+
+	mu      sync.Mutex
+	queue   []Record     // records neither published nor spilled, in order
+	dropped atomic.Int64 // records dropped since the last report
+
+	pending  chan struct{} // signalled when a record was queued
+	shutdown chan context.Context
+	done     chan struct{} // closed when Run returns
+}
+
+func NewForwarder(
+	dispatcher *broker.Dispatcher,
+	publisher Publisher,
+	buffer *DiskBuffer,
+	logger *zap.Logger,
+	opts ForwarderOptions,
+) *Forwarder {
+	opts.setDefaults()
+
+	return &Forwarder{
+		dispatcher: dispatcher,
+		publisher:  publisher,
+		buffer:     buffer,
+		logger:     logger,
+		opts:       opts,
+		pending:    make(chan struct{}, 1),
+		shutdown:   make(chan context.Context),
+		done:       make(chan struct{}),
+	}
+}
+
+// Run forwards messages until ctx is cancelled or Shutdown is called.
+// Records not published by then are spilled to the disk buffer.
+func (f *Forwarder) Run(ctx context.Context) {
+	defer close(f.done)
+
+	remove := f.dispatcher.HandleAll(f.enqueue)
+	defer remove()
+
+	ticker := time.NewTicker(f.opts.RetryInterval)
+	defer ticker.Stop()
+
+	// records left over from a previous run
+	failing := !f.flush(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			remove()
+			f.spill()
+			f.reportDropped()
+			return
+
+		case <-f.pending:
+			// while the broker fails, new records wait on disk for the next retry
+			if failing {
+				f.spill()
+			} else {
+				failing = !f.flush(ctx)
+			}
+
+		case <-ticker.C:
+			f.reportDropped()
+			failing = !f.flush(ctx)
+
+		case shutdownCtx := <-f.shutdown:
+			remove()
+			f.flush(shutdownCtx)
+			f.spill()
+			f.reportDropped()
+			return
+		}
+	}
+}
+
+// Shutdown stops accepting messages and makes Run publish the queued
+// and buffered records, then return. Records not published before ctx
+// is done stay in the disk buffer for the next start.
+func (f *Forwarder) Shutdown(ctx context.Context) {
+	select {
+	case f.shutdown <- ctx:
+	case <-f.done:
+		return
+	case <-ctx.Done():
+		return
+	}
+
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+	}
+}
+
+// enqueue queues msg in memory. The dispatcher calls it on the publish
+// path, so it never touches the disk and Run is only signalled.
+func (f *Forwarder) enqueue(msg *broker.Message) {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		f.logger.Error("failed to encode sink event", zap.Error(err))
+		return
+	}
+
+	rec := Record{
+		SessionID: msg.SessionID,
+		Subject:   Subject(f.opts.SubjectTemplate, msg.SessionID),
+		Payload:   payload,
+		Trace:     tracing.Traceparent(msg.SpanContext),
+	}
+
+	f.mu.Lock()
+	if len(f.queue) >= f.opts.QueueSize {
+		f.mu.Unlock()
+		f.dropped.Add(1)
+		return
+	}
+	f.queue = append(f.queue, rec)
+	f.mu.Unlock()
+
+	select {
+	case f.pending <- struct{}{}:
+	default:
+	}
+}
+
+// flush publishes the records of the disk buffer, then the queued
+// ones, in order. It reports whether everything was published; if the
+// broker fails, the queued records are spilled to the disk buffer.
+func (f *Forwarder) flush(ctx context.Context) bool {
+	if f.buffer.Len() > 0 {
+		// queued records go behind the buffered ones to keep the order
+		f.spill()
+
+		err := f.buffer.Drain(func(rec Record) error {
+			return f.publishRecord(ctx, rec)
+		})
+		if err != nil {
+			f.logger.Warn("sink publish failed, keeping records buffered",
+				zap.Int("pending", f.buffer.Len()),
+				zap.Error(err),
+			)
+			return false
+		}
+	}
+
+	for {
+		f.mu.Lock()
+		if len(f.queue) == 0 {
+			f.mu.Unlock()
+			return true
+		}
+		rec := f.queue[0]
+		f.mu.Unlock()
+
+		if err := f.publishRecord(ctx, rec); err != nil {
+			f.spill()
+			f.logger.Warn("sink publish failed, buffering records on disk",
+				zap.Int("pending", f.buffer.Len()),
+				zap.Error(err),
+			)
+			return false
+		}
+
+		f.mu.Lock()
+		f.queue[0] = Record{}
+		f.queue = f.queue[1:]
+		f.mu.Unlock()
+	}
+}
+
+// spill moves the queued records to the disk buffer. Only Run removes
+// queued records, so they are still at the front of the queue after
+// the write. On a write error they stay queued.
+func (f *Forwarder) spill() {
+	f.mu.Lock()
+	records := f.queue
+	f.mu.Unlock()
+
+	if len(records) == 0 {
+		return
+	}
+
+	dropped, err := f.buffer.Append(records...)
+	if err != nil {
+		f.logger.Error("failed to buffer sink events", zap.Error(err))
+		return
+	}
+	f.dropped.Add(int64(dropped))
+
+	f.mu.Lock()
+	f.queue = f.queue[len(records):]
+	if len(f.queue) == 0 {
+		f.queue = nil
+	}
+	f.mu.Unlock()
+}
+
+func (f *Forwarder) reportDropped() {
+	if n := f.dropped.Swap(0); n > 0 {
+		f.logger.Warn("sink buffer full, dropped events", zap.Int64("dropped", n))
+	}
+}
+
+func (f *Forwarder) publishRecord(ctx context.Context, rec Record) error {
+	ctx, span := tracing.Tracer().Start(ctx, "sink.publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		tracing.LinkTo(tracing.ParseTraceparent(rec.Trace)),
+		trace.WithAttributes(
+			tracing.AttrSessionID.String(rec.SessionID),
+			tracing.AttrSinkSubject.String(rec.Subject),
+		),
+	)
+	defer span.End()
+
+	return tracing.Fail(span, f.publish(ctx, rec))
+}
+
+func (f *Forwarder) publish(ctx context.Context, rec Record) error {
+	ctx, cancel := context.WithTimeout(ctx, f.opts.PublishTimeout)
+	defer cancel()
+
+	return f.publisher.Publish(ctx, rec.Subject, rec.Payload)
+}
+
+// Close closes the underlying publisher.
+func (f *Forwarder) Close() error {
+	return f.publisher.Close()
+}
@@ -0,0 +1,381 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	natsserver "github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/zen-flo/telegram-service/internal/broker"
+	"go.uber.org/zap"
+)
+
+func startForwarder(t *testing.T, d *broker.Dispatcher, p Publisher, opts ForwarderOptions) *DiskBuffer {
+	t.Helper()
+
+	buf, err := NewDiskBuffer(filepath.Join(t.TempDir(), "buffer.jsonl"), 0)
+	if err != nil {
+		t.Fatalf("open buffer: %v", err)
+	}
+
+	f := NewForwarder(d, p, buf, zap.NewNop(), opts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		_ = f.Close()
+	})
+
+	// wait for Run to subscribe
+	waitFor(t, func() bool { return d.Stats().Subscribers > 0 })
+	return buf
+}
+
+func TestForwarder_NATS(t *testing.T) {
+	srv, err := natsserver.NewServer(&natsserver.Options{Host: "127.0.0.1", Port: -1})
+	if err != nil {
+		t.Fatalf("start nats: %v", err)
+	}
+	go srv.Start()
+	defer srv.Shutdown()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server not ready")
+	}
+
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer nc.Close()
+
+	msgs := make(chan *nats.Msg, 1)
+	if _, err := nc.ChanSubscribe("tg.*.messages", msgs); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	if err := nc.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	pub, err := NewNATSPublisher(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("publisher: %v", err)
+	}
+
+	d := broker.NewDispatcher()
+	startForwarder(t, d, pub, ForwarderOptions{SubjectTemplate: "tg.{session_id}.messages"})
+
+	d.Publish("s1", &broker.Message{ID: 7, Text: "hello"})
+
+	select {
+	case m := <-msgs:
+		if m.Subject != "tg.s1.messages" {
+			t.Fatalf("unexpected subject %q", m.Subject)
+		}
+
+		var got broker.Message
+		if err := json.Unmarshal(m.Data, &got); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if got.SessionID != "s1" || got.ID != 7 {
+			t.Fatalf("unexpected payload: %+v", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("message not published to nats")
+	}
+}
+
+func TestForwarder_RedisStreamsBuffersWhileUnavailable(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	pub, err := NewRedisStreamsPublisher("redis://"+mr.Addr(), 0)
+	if err != nil {
+		t.Fatalf("publisher: %v", err)
+	}
+
+	d := broker.NewDispatcher()
+	buf := startForwarder(t, d, pub, ForwarderOptions{
+		SubjectTemplate: "tg:{session_id}",
+		RetryInterval:   20 * time.Millisecond,
+	})
+
+	mr.SetError("LOADING redis is loading the dataset")
+	d.Publish("s1", &broker.Message{ID: 1, Text: "first"})
+	d.Publish("s1", &broker.Message{ID: 2, Text: "second"})
+
+	waitFor(t, func() bool { return buf.Len() == 2 })
+
+	mr.SetError("")
+
+	waitFor(t, func() bool { return buf.Len() == 0 })
+
+	entries, err := mr.Stream("tg:s1")
+	if err != nil {
+		t.Fatalf("read stream: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 stream entries, got %d", len(entries))
+	}
+
+	var first broker.Message
+	if err := json.Unmarshal([]byte(entries[0].Values[1]), &first); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if first.ID != 1 {
+		t.Fatalf("expected buffered order to be preserved, got %+v", first)
+	}
+}
+
+func TestDiskBuffer_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "buffer.jsonl")
+
+	buf, err := NewDiskBuffer(path, 0)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	for _, s := range []string{"a", "b"} {
+		if _, err := buf.Append(Record{Subject: s, Payload: []byte(s)}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+
+	reopened, err := NewDiskBuffer(path, 0)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if reopened.Len() != 2 {
+		t.Fatalf("expected 2 records after reopen, got %d", reopened.Len())
+	}
+}
+
+func TestDiskBuffer_Limit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "buffer.jsonl")
+
+	buf, err := NewDiskBuffer(path, 3)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	dropped, err := buf.Append(
+		Record{Subject: "a"}, Record{Subject: "b"}, Record{Subject: "c"}, Record{Subject: "d"},
+	)
+	if err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if dropped != 1 || buf.Len() != 3 {
+		t.Fatalf("expected the newest record to be dropped, dropped %d, kept %d", dropped, buf.Len())
+	}
+
+	var drained []string
+	if err := buf.Drain(func(rec Record) error {
+		drained = append(drained, rec.Subject)
+		return nil
+	}); err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+	if strings.Join(drained, "") != "abc" || buf.Len() != 0 {
+		t.Fatalf("unexpected drain %v", drained)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected a drained buffer to remove its file, got %v", err)
+	}
+}
+
+func TestForwarder_HealthyBrokerSkipsDisk(t *testing.T) {
+	pub := &memoryPublisher{}
+	d := broker.NewDispatcher()
+	buf := startForwarder(t, d, pub, ForwarderOptions{SubjectTemplate: "{session_id}"})
+
+	for i := range 10 {
+		d.Publish(fmt.Sprint(i), &broker.Message{ID: int64(i)})
+	}
+
+	waitFor(t, func() bool {
+		pub.mu.Lock()
+		defer pub.mu.Unlock()
+		return len(pub.subjects) == 10
+	})
+	if _, err := os.Stat(buf.path); !os.IsNotExist(err) {
+		t.Fatalf("expected no disk writes while the broker accepts events, got %v", err)
+	}
+}
+
+// failingPublisher rejects every publish.
+type failingPublisher struct{}
+
+func (failingPublisher) Publish(context.Context, string, []byte) error {
+	return errors.New("broker unavailable")
+}
+
+func (failingPublisher) Close() error { return nil }
+
+func TestForwarder_DropsWhenBufferFull(t *testing.T) {
+	buf, err := NewDiskBuffer(filepath.Join(t.TempDir(), "buffer.jsonl"), 5)
+	if err != nil {
+		t.Fatalf("open buffer: %v", err)
+	}
+
+	d := broker.NewDispatcher()
+	f := NewForwarder(d, failingPublisher{}, buf, zap.NewNop(), ForwarderOptions{
+		SubjectTemplate: "{session_id}",
+		RetryInterval:   time.Hour,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f.Run(ctx)
+	}()
+	waitFor(t, func() bool { return d.Stats().Subscribers > 0 })
+
+	for i := range 20 {
+		d.Publish(fmt.Sprint(i), &broker.Message{ID: int64(i)})
+	}
+	cancel()
+	<-done
+
+	if buf.Len() != 5 {
+		t.Fatalf("expected the disk buffer to hold its limit, got %d", buf.Len())
+	}
+	var first string
+	_ = buf.Drain(func(rec Record) error {
+		first = rec.Subject
+		return errors.New("stop")
+	})
+	if first != "0" {
+		t.Fatalf("expected the oldest records to be kept, got %q first", first)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+type memoryPublisher struct {
+	mu       sync.Mutex
+	subjects []string
+}
+
+func (p *memoryPublisher) Publish(ctx context.Context, subject string, payload []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.subjects = append(p.subjects, subject)
+	return nil
+}
+
+func (p *memoryPublisher) Close() error { return nil }
+
+func TestForwarder_ShutdownFlushes(t *testing.T) {
+	buf, err := NewDiskBuffer(filepath.Join(t.TempDir(), "buffer.jsonl"), 0)
+	if err != nil {
+		t.Fatalf("open buffer: %v", err)
+	}
+	if _, err := buf.Append(Record{Subject: "old", Payload: []byte("{}")}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	pub := &memoryPublisher{}
+	d := broker.NewDispatcher()
+	f := NewForwarder(d, pub, buf, zap.NewNop(), ForwarderOptions{
+		SubjectTemplate: "{session_id}",
+		RetryInterval:   time.Hour,
+	})
+
+	runDone := make(chan struct{})
+	go func() {
+		defer close(runDone)
+		f.Run(context.Background())
+	}()
+	waitFor(t, func() bool { return buf.Len() == 0 })
+
+	d.Publish("s1", &broker.Message{ID: 1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	f.Shutdown(ctx)
+
+	select {
+	case <-runDone:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after Shutdown")
+	}
+
+	pub.mu.Lock()
+	defer pub.mu.Unlock()
+	if len(pub.subjects) != 2 || pub.subjects[0] != "old" || pub.subjects[1] != "s1" {
+		t.Fatalf("unexpected published subjects %v", pub.subjects)
+	}
+}
+
+// slowPublisher blocks every publish until release is closed.
+type slowPublisher struct {
+	memoryPublisher
+	release chan struct{}
+}
+
+func (p *slowPublisher) Publish(ctx context.Context, subject string, payload []byte) error {
+	select {
+	case <-p.release:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return p.memoryPublisher.Publish(ctx, subject, payload)
+}
+
+func TestForwarder_SlowBrokerDropsNothing(t *testing.T) {
+	pub := &slowPublisher{release: make(chan struct{})}
+	d := broker.NewDispatcher()
+	startForwarder(t, d, pub, ForwarderOptions{
+		SubjectTemplate: "{session_id}",
+		RetryInterval:   20 * time.Millisecond,
+		PublishTimeout:  time.Minute,
+	})
+
+	// far more than a SubscribeAll channel buffers, while the
+	// forwarder is stuck in a publish
+	const n = 500
+	for i := range n {
+		d.Publish(fmt.Sprint(i), &broker.Message{ID: int64(i)})
+	}
+	close(pub.release)
+
+	waitFor(t, func() bool {
+		pub.mu.Lock()
+		defer pub.mu.Unlock()
+		return len(pub.subjects) == n
+	})
+
+	pub.mu.Lock()
+	defer pub.mu.Unlock()
+	for i, subject := range pub.subjects {
+		if subject != fmt.Sprint(i) {
+			t.Fatalf("expected records in order, got %q at %d", subject, i)
+		}
+	}
+	if d.Stats().Dropped != 0 {
+		t.Fatalf("expected no dropped messages, got %d", d.Stats().Dropped)
+	}
+}
@@ -3,22 +3,66 @@ package telegram
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
-	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/gotd/td/bin"
+	"github.com/gotd/td/tdp"
 	tdtelegram "github.com/gotd/td/telegram"
+	"github.com/gotd/td/telegram/auth"
 	"github.com/gotd/td/telegram/auth/qrlogin"
+	"github.com/gotd/td/telegram/updates"
 	"github.com/gotd/td/tgerr"
 
 	"github.com/gotd/td/session"
 	"github.com/gotd/td/tg"
 	"github.com/zen-flo/telegram-service/internal/broker"
+	"github.com/zen-flo/telegram-service/internal/metrics"
+	"github.com/zen-flo/telegram-service/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+var (
+	ErrNoopMode     = errors.New("telegram client is in noop mode")
+	ErrNotStarted   = errors.New("client not started")
+	ErrInvalidPeer  = errors.New("invalid peer")
+	ErrPeerNotFound = errors.New("peer not found")
+
+	ErrPasswordNotRequired = errors.New("no login is waiting for a password")
+	// ErrPasswordInvalid is returned by SubmitPassword for a wrong
+	// password. The login keeps waiting for the right one.
+	ErrPasswordInvalid = auth.ErrPasswordInvalid
+)
+
+// revokedErrors are Telegram errors meaning the authorization of the
+// account is no longer valid.
+var revokedErrors = []string{
+	"AUTH_KEY_UNREGISTERED",
+	"AUTH_KEY_INVALID",
+	"SESSION_REVOKED",
+	"SESSION_EXPIRED",
+	"USER_DEACTIVATED",
+	"USER_DEACTIVATED_BAN",
+}
+
+// IsRevoked reports whether err means that the account was logged out
+// remotely, e.g. the session was terminated from another device.
+func IsRevoked(err error) bool {
+	return tgerr.Is(err, revokedErrors...)
+}
+
+func revokedReason(err error) string {
+	if rpcErr, ok := tgerr.As(err); ok {
+		return rpcErr.Type
+	}
+	return err.Error()
+}
+
 type Client struct {
 	appID   int
 	appHash string
@@ -39,6 +83,17 @@ type Client struct {
 
 	runCancel context.CancelFunc // cancels the context passed to client.Run
 	stopCh    chan struct{}      // closed by LogOut to signal the run loop to exit
+
+	updatesWG sync.WaitGroup // the gap recovery manager and the logins that start it
+
+	passwordCh       chan passwordReq // passwords for a login waiting for one
+	passwordRequired atomic.Bool      // set while a login waits for the 2FA password
+
+	connected    atomic.Bool         // set while connected to Telegram
+	wasConnected atomic.Bool         // set after the first connect, to count reconnects
+	authorized   atomic.Bool         // set once the account is logged in
+	onAuthorized func()              // called when a stored authorization is restored
+	onRevoked    func(reason string) // called when the authorization is revoked remotely
 }
 
 type qrReq struct {
@@ -51,6 +106,11 @@ type qrResp struct {
 	err error
 }
 
+type passwordReq struct {
+	password string
+	resp     chan error
+}
+
 func NewClient(appID int, appHash string, logger *zap.Logger, dispatcher *broker.Dispatcher, sessionID string) *Client {
 	c := &Client{
 		appID:        appID,
@@ -62,6 +122,7 @@ func NewClient(appID int, appHash string, logger *zap.Logger, dispatcher *broker
 		peerCache:    make(map[string]tg.InputPeerClass),
 		loginTokenCh: make(chan struct{}, 1),
 		stopCh:       make(chan struct{}),
+		passwordCh:   make(chan passwordReq),
 	}
 
 	if appID == 0 || appHash == "" {
@@ -70,6 +131,9 @@ func NewClient(appID int, appHash string, logger *zap.Logger, dispatcher *broker
 	return c
 }
 
+// Start runs the client until ctx is done or the client is stopped.
+// It returns nil after LogOut or a remote logout, and an error if the
+// client failed. The update state is persisted when Start returns.
 func (c *Client) Start(ctx context.Context) error {
 	if c.noop {
 		c.logger.Debug("telegram client noop mode (no appID/appHash provided)")
@@ -77,8 +141,30 @@ func (c *Client) Start(ctx context.Context) error {
 		return ctx.Err()
 	}
 
+	stateStorage, err := NewFileStateStorage(StatePath(c.sessionID))
+	if err != nil {
+		return fmt.Errorf("load update state: %w", err)
+	}
+
 	runCtx, runCancel := context.WithCancel(ctx)
 
+	// set while the gap recovery manager of this run is running; kept
+	// per run so a manager left over from a previous run cannot make
+	// this one skip gap recovery
+	var updatesRunning atomic.Bool
+
+	// gaps tracks pts/qts/seq and fetches missed updates with
+	// updates.getDifference / updates.getChannelDifference.
+	gaps := updates.New(updates.Config{
+		Handler: tdtelegram.UpdateHandlerFunc(func(ctx context.Context, u tg.UpdatesClass) error {
+			c.handleUpdate(u)
+			return nil
+		}),
+		Storage:      stateStorage,
+		AccessHasher: stateStorage,
+		Logger:       c.logger.Named("updates"),
+	})
+
 	c.mu.Lock()
 	c.runCancel = runCancel
 	c.client = tdtelegram.NewClient(
@@ -86,18 +172,54 @@ func (c *Client) Start(ctx context.Context) error {
 		c.appHash,
 		tdtelegram.Options{
 			SessionStorage: &session.FileStorage{
-				Path: "sessions/" + c.sessionID + ".json",
+				Path: SessionPath(c.sessionID),
+			},
+			UpdateHandler: gaps,
+			Middlewares: []tdtelegram.Middleware{
+				tdtelegram.MiddlewareFunc(c.traceInvoke),
+				tdtelegram.MiddlewareFunc(c.detectRevoked),
+				tdtelegram.MiddlewareFunc(countErrors),
 			},
-			UpdateHandler: tdtelegram.UpdateHandlerFunc(func(ctx context.Context, u tg.UpdatesClass) error {
-				c.handleUpdate(u)
+			OnSelfError: func(ctx context.Context, err error) error {
+				if c.authorized.Load() && IsRevoked(err) {
+					c.revoke(revokedReason(err))
+					return err
+				}
 				return nil
-			}),
+			},
+			OnSelfSuccess: func(*tg.User) {
+				c.setConnected(true, nil)
+
+				// Called on every (re)connect. The first connect is covered
+				// by the initial getDifference in gaps.Run.
+				if updatesRunning.Load() {
+					go c.recoverGap(runCtx, gaps)
+				}
+			},
+			OnDead: func(err error) {
+				c.setConnected(false, err)
+			},
 		},
 	)
 	c.mu.Unlock()
 
-	return c.client.Run(runCtx, func(innerCtx context.Context) error {
+	err = c.client.Run(runCtx, func(innerCtx context.Context) error {
 		c.logger.Info("gotd run callback started")
+		c.setConnected(true, nil)
+
+		status, err := c.client.Auth().Status(innerCtx)
+		if err != nil {
+			c.logger.Warn("failed to get auth status", zap.Error(err))
+		} else if status.Authorized && status.User != nil {
+			// restored from the stored auth key, e.g. a resumed session
+			if c.authorized.CompareAndSwap(false, true) {
+				if c.onAuthorized != nil {
+					c.onAuthorized()
+				}
+				c.publishSystem("authorized")
+			}
+			c.runUpdates(innerCtx, gaps, &updatesRunning, status.User.ID)
+		}
 
 		for {
 			select {
@@ -111,7 +233,10 @@ func (c *Client) Start(ctx context.Context) error {
 
 			case req := <-c.qrReqCh:
 
+				// tracked so the manager it may start is waited for too
+				c.updatesWG.Add(1)
 				go func(r qrReq) {
+					defer c.updatesWG.Done()
 
 					pwd := os.Getenv("TG_2FA_PASSWORD")
 
@@ -139,11 +264,7 @@ func (c *Client) Start(ctx context.Context) error {
 
 					if err != nil {
 						if tgerr.Is(err, "SESSION_PASSWORD_NEEDED") {
-							if pwd == "" {
-								c.logger.Error("2FA password required but TG_2FA_PASSWORD is not set")
-								return
-							}
-							if _, err := c.client.Auth().Password(innerCtx, pwd); err != nil {
+							if err := c.checkPassword(innerCtx, pwd); err != nil {
 								c.logger.Error("2FA password auth failed", zap.Error(err))
 								return
 							}
@@ -159,24 +280,198 @@ func (c *Client) Start(ctx context.Context) error {
 
 					c.logger.Info("telegram auth success",
 						zap.String("session", c.sessionID))
+					c.authorized.Store(true)
+
+					if self, err := c.client.Self(innerCtx); err != nil {
+						c.logger.Warn("failed to get self after auth", zap.Error(err))
+					} else {
+						c.runUpdates(innerCtx, gaps, &updatesRunning, self.ID)
+					}
 
 					if r.onAuthDone != nil {
 						r.onAuthDone()
 					}
 
-					if c.dispatcher != nil {
-						c.dispatcher.Publish(c.sessionID, &broker.Message{
-							ID:        time.Now().UnixNano(),
-							From:      "system",
-							Text:      "authorized",
-							Timestamp: time.Now().Unix(),
-						})
-					}
+					c.publishSystem("authorized")
 
 				}(req)
 			}
 		}
 	})
+
+	// the update state is written by the gap recovery manager, wait
+	// for it and any login still starting it, so the state is persisted
+	// when Start returns
+	c.updatesWG.Wait()
+
+	c.setConnected(false, err)
+	return err
+}
+
+// Connected reports whether the client has a working connection.
+func (c *Client) Connected() bool {
+	return c.connected.Load()
+}
+
+// setConnected publishes "connected" and "disconnected" session events
+// when the connection state changes.
+func (c *Client) setConnected(connected bool, err error) {
+	if c.connected.Swap(connected) == connected {
+		return
+	}
+
+	if connected {
+		if c.wasConnected.Swap(true) {
+			metrics.Reconnects.WithLabelValues(c.sessionID).Inc()
+		}
+		c.publishSystem("connected")
+		return
+	}
+
+	text := "disconnected"
+	if err != nil && !errors.Is(err, context.Canceled) {
+		text += ": " + err.Error()
+	}
+	c.publishSystem(text)
+}
+
+// runUpdates starts the gap recovery manager for the authorized user.
+// The stored state is loaded and updates.getDifference is called, so
+// updates received while the session was offline are dispatched too.
+// running guards against starting a second manager in the same run.
+func (c *Client) runUpdates(
+	ctx context.Context,
+	gaps *updates.Manager,
+	running *atomic.Bool,
+	userID int64,
+) {
+
+	if !running.CompareAndSwap(false, true) {
+		return
+	}
+
+	c.mu.RLock()
+	client := c.client
+	c.mu.RUnlock()
+
+	c.updatesWG.Add(1)
+	go func() {
+		defer c.updatesWG.Done()
+		defer running.Store(false)
+
+		err := gaps.Run(ctx, client.API(), userID, updates.AuthOptions{})
+		if err != nil && !errors.Is(err, context.Canceled) {
+			c.logger.Warn("updates manager stopped", zap.Error(err))
+		}
+	}()
+}
+
+// recoverGap forces updates.getDifference after a reconnect.
+func (c *Client) recoverGap(ctx context.Context, gaps *updates.Manager) {
+	c.logger.Info("connection restored, fetching missed updates",
+		zap.String("session", c.sessionID))
+
+	if err := gaps.Handle(ctx, &tg.UpdatesTooLong{}); err != nil && !errors.Is(err, context.Canceled) {
+		c.logger.Warn("failed to recover updates gap", zap.Error(err))
+	}
+}
+
+// OnAuthorized sets fn to be called when Start finds a valid stored
+// authorization, so no QR login is needed. Must be called before Start.
+func (c *Client) OnAuthorized(fn func()) {
+	c.onAuthorized = fn
+}
+
+// Noop reports whether the client runs without Telegram credentials
+// and never connects.
+func (c *Client) Noop() bool {
+	return c.noop
+}
+
+// OnRevoked sets fn to be called once when Telegram reports that the
+// authorization of the account was revoked, e.g. the session was
+// terminated from another device. Must be called before Start.
+func (c *Client) OnRevoked(fn func(reason string)) {
+	c.onRevoked = fn
+}
+
+// detectRevoked is a middleware watching RPC errors for a revoked
+// authorization.
+func (c *Client) detectRevoked(next tg.Invoker) tdtelegram.InvokeFunc {
+	return func(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+		err := next.Invoke(ctx, input, output)
+		if err != nil && c.authorized.Load() && IsRevoked(err) {
+			go c.revoke(revokedReason(err))
+		}
+		return err
+	}
+}
+
+// traceInvoke is a middleware wrapping every MTProto call in a span
+// named after the TL method, e.g. "messages.sendMessage".
+func (c *Client) traceInvoke(next tg.Invoker) tdtelegram.InvokeFunc {
+	return func(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+		method := fmt.Sprintf("%T", input)
+		if t, ok := input.(interface{ TypeInfo() tdp.Type }); ok {
+			method = t.TypeInfo().Name
+		}
+
+		ctx, span := tracing.Tracer().Start(ctx, "mtproto "+method,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				tracing.AttrSessionID.String(c.sessionID),
+				tracing.AttrMethod.String(method),
+			),
+		)
+		defer span.End()
+
+		err := next.Invoke(ctx, input, output)
+
+		var rpcErr *tgerr.Error
+		if errors.As(err, &rpcErr) {
+			span.SetAttributes(tracing.AttrError.String(rpcErr.Type))
+		}
+		return tracing.Fail(span, err)
+	}
+}
+
+// countErrors is a middleware counting RPC errors by type and the
+// FLOOD_WAIT seconds requested by Telegram.
+func countErrors(next tg.Invoker) tdtelegram.InvokeFunc {
+	return func(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+		err := next.Invoke(ctx, input, output)
+
+		var rpcErr *tgerr.Error
+		if errors.As(err, &rpcErr) {
+			metrics.RPCErrors.WithLabelValues(rpcErr.Type).Inc()
+		}
+		if d, ok := tgerr.AsFloodWait(err); ok {
+			metrics.FloodWaitSeconds.Add(d.Seconds())
+		}
+		return err
+	}
+}
+
+// revoke marks the client logged out, stops it and publishes a
+// "logged_out: <reason>" session event. The stored auth key is useless
+// after that, but is kept unless the OnRevoked handler deletes it.
+func (c *Client) revoke(reason string) {
+	if !c.authorized.CompareAndSwap(true, false) {
+		return
+	}
+
+	c.logger.Warn("telegram authorization revoked",
+		zap.String("session", c.sessionID),
+		zap.String("reason", reason),
+	)
+
+	c.stop()
+
+	if c.onRevoked != nil {
+		c.onRevoked(reason)
+	}
+
+	c.publishSystem("logged_out: " + reason)
 }
 
 // LogOut performs auth.logOut while the connection is still alive,
@@ -184,9 +479,11 @@ func (c *Client) Start(ctx context.Context) error {
 func (c *Client) LogOut() {
 	c.mu.RLock()
 	client := c.client
-	cancel := c.runCancel
 	c.mu.RUnlock()
 
+	// a failing auth.logOut must not be reported as a remote logout
+	c.authorized.Store(false)
+
 	if client != nil {
 		// Use a fresh context — the run context is still active at this point.
 		logoutCtx, logoutCancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -199,6 +496,14 @@ func (c *Client) LogOut() {
 		}
 	}
 
+	c.stop()
+}
+
+// stop shuts down the client without logging out.
+func (c *Client) stop() {
+	c.mu.Lock()
+	cancel := c.runCancel
+
 	// Signal the run‑loop goroutine to return, which lets client.Run finish.
 	select {
 	case <-c.stopCh:
@@ -206,6 +511,7 @@ func (c *Client) LogOut() {
 	default:
 		close(c.stopCh)
 	}
+	c.mu.Unlock()
 
 	// Cancel the run context so gotd tears down the transport.
 	if cancel != nil {
@@ -253,34 +559,51 @@ func (c *Client) processSingleUpdate(update tg.UpdateClass) {
 		default:
 		}
 
-	case *tg.UpdateNewMessage:
-		msg, ok := u.Message.(*tg.Message)
-		if !ok {
-			return
+	case *tg.UpdateServiceNotification:
+		// AUTH_KEY_DROP_* asks the client to forget its authorization.
+		if strings.HasPrefix(u.Type, "AUTH_KEY_DROP_") {
+			go c.revoke(u.Type)
 		}
 
-		if msg.Message == "" {
-			return
-		}
+	case *tg.UpdateNewMessage:
+		c.processMessage(u.Message)
 
-		from := "unknown"
+	case *tg.UpdateNewChannelMessage:
+		c.processMessage(u.Message)
+	}
+}
 
-		switch f := msg.FromID.(type) {
-		case *tg.PeerUser:
-			from = "user:" + strconv.FormatInt(f.UserID, 10)
-		case *tg.PeerChat:
-			from = "chat:" + strconv.FormatInt(f.ChatID, 10)
-		case *tg.PeerChannel:
-			from = "channel:" + strconv.FormatInt(f.ChannelID, 10)
-		}
+func (c *Client) processMessage(m tg.MessageClass) {
+	msg, ok := m.(*tg.Message)
+	if !ok {
+		return
+	}
 
-		c.publishMessage(
-			int64(msg.ID),
-			from,
-			msg.Message,
-			int64(msg.Date),
-		)
+	if msg.Message == "" {
+		return
+	}
+
+	// channel posts have no sender, fall back to the chat itself
+	fromID := msg.FromID
+	if fromID == nil {
+		fromID = msg.PeerID
+	}
+
+	c.publishMessage(
+		int64(msg.ID),
+		peerString(fromID),
+		msg.Message,
+		int64(msg.Date),
+	)
+}
+
+// publishSystem publishes a session event to the dispatcher.
+func (c *Client) publishSystem(text string) {
+	if c.dispatcher == nil {
+		return
 	}
+
+	c.dispatcher.Publish(c.sessionID, broker.SystemMessage(text))
 }
 
 func (c *Client) publishMessage(id int64, from, text string, ts int64) {
@@ -288,17 +611,31 @@ func (c *Client) publishMessage(id int64, from, text string, ts int64) {
 		return
 	}
 
+	metrics.MessagesReceived.WithLabelValues(c.sessionID).Inc()
+
+	// Consumers link their spans to the span of the update, since
+	// their work outlives it.
+	_, span := tracing.Tracer().Start(context.Background(), "telegram.update",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			tracing.AttrSessionID.String(c.sessionID),
+			tracing.AttrMessageFrom.String(from),
+		),
+	)
+	defer span.End()
+
 	c.dispatcher.Publish(c.sessionID, &broker.Message{
-		ID:        id,
-		From:      from,
-		Text:      text,
-		Timestamp: ts,
+		ID:          id,
+		From:        from,
+		Text:        text,
+		Timestamp:   ts,
+		SpanContext: span.SpanContext(),
 	})
 }
 
 func (c *Client) StartQR(ctx context.Context, onAuthorized func()) (string, error) {
 	if c.noop {
-		return "", errors.New("telegram client is in noop mode")
+		return "", ErrNoopMode
 	}
 
 	req := qrReq{
@@ -320,56 +657,184 @@ func (c *Client) StartQR(ctx context.Context, onAuthorized func()) (string, erro
 	}
 }
 
+// checkPassword completes a login of an account with two-step
+// verification. Without a password from TG_2FA_PASSWORD it waits for
+// one from SubmitPassword until ctx is done; wrong passwords are
+// reported to the caller of SubmitPassword and the wait goes on.
+func (c *Client) checkPassword(ctx context.Context, password string) error {
+	if password != "" {
+		_, err := c.client.Auth().Password(ctx, password)
+		return err
+	}
+
+	c.passwordRequired.Store(true)
+	defer c.passwordRequired.Store(false)
+
+	c.logger.Info("waiting for the 2FA password", zap.String("session", c.sessionID))
+	c.publishSystem("password_required")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case req := <-c.passwordCh:
+			_, err := c.client.Auth().Password(ctx, req.password)
+			req.resp <- err
+			if !errors.Is(err, ErrPasswordInvalid) {
+				return err
+			}
+		}
+	}
+}
+
+// PasswordRequired reports whether a login waits for SubmitPassword.
+func (c *Client) PasswordRequired() bool {
+	return c.passwordRequired.Load()
+}
+
+// SubmitPassword passes the 2FA password to a login waiting for it and
+// returns once it was checked.
+func (c *Client) SubmitPassword(ctx context.Context, password string) error {
+	if c.noop {
+		return ErrNoopMode
+	}
+	if !c.passwordRequired.Load() {
+		return ErrPasswordNotRequired
+	}
+
+	req := passwordReq{password: password, resp: make(chan error, 1)}
+	select {
+	case c.passwordCh <- req:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-req.resp:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SendMessage sends a text message. randomID is the Telegram random_id
+// used by the server to drop duplicate sends; zero generates a new one.
+// A send dropped as a duplicate returns the message sent before, found
+// in the chat history among the messages sent since the first attempt
+// with randomID was made.
+//
+// If Telegram accepts the message but the response does not identify
+// it, the error is an *UnknownSendResultError.
 func (c *Client) SendMessage(
 	ctx context.Context,
 	peer string,
 	text string,
-) (int64, error) {
+	randomID int64,
+	since time.Time,
+) (_ SentMessage, err error) {
 
 	if c.noop {
-		return 0, errors.New("client is in noop mode")
+		return SentMessage{}, ErrNoopMode
 	}
 
+	ctx, span := tracing.Tracer().Start(ctx, "telegram.SendMessage",
+		trace.WithAttributes(tracing.AttrSessionID.String(c.sessionID)),
+	)
+	defer func() {
+		tracing.Fail(span, err)
+		span.End()
+	}()
+
 	c.mu.RLock()
 	client := c.client
 	c.mu.RUnlock()
 
 	if client == nil {
-		return 0, errors.New("client not started")
+		return SentMessage{}, ErrNotStarted
 	}
 
 	inputPeer, err := c.resolvePeer(ctx, peer)
 	if err != nil {
-		return 0, err
+		return SentMessage{}, err
+	}
+	span.SetAttributes(tracing.AttrPeerType.String(inputPeerType(inputPeer)))
+
+	if randomID == 0 {
+		randomID = time.Now().UnixNano()
 	}
 
 	api := client.API()
 
+	start := time.Now()
 	res, err := api.MessagesSendMessage(ctx, &tg.MessagesSendMessageRequest{
 		Peer:     inputPeer,
 		Message:  text,
-		RandomID: time.Now().UnixNano(),
+		RandomID: randomID,
 	})
 
 	if err != nil {
-		return 0, err
-	}
+		metrics.SendDuration.WithLabelValues("error").Observe(time.Since(start).Seconds())
 
-	switch v := res.(type) {
-	case *tg.Updates:
-		for _, upd := range v.Updates {
-			if m, ok := upd.(*tg.UpdateMessageID); ok {
-				return int64(m.ID), nil
-			}
+		if tgerr.Is(err, "RANDOM_ID_DUPLICATE") {
+			// a retry of a send whose response was lost
+			return c.findDuplicate(ctx, api, inputPeer, text, since, err)
 		}
+		return SentMessage{}, err
+	}
+
+	metrics.SendDuration.WithLabelValues("ok").Observe(time.Since(start).Seconds())
+	metrics.MessagesSent.WithLabelValues(c.sessionID).Inc()
+
+	return sentMessage(res, randomID, inputPeer)
+}
+
+// findDuplicate returns the message an earlier attempt of a send was
+// delivered as, for a retry rejected with RANDOM_ID_DUPLICATE. If it
+// cannot be found, dupErr is returned.
+func (c *Client) findDuplicate(
+	ctx context.Context,
+	api *tg.Client,
+	peer tg.InputPeerClass,
+	text string,
+	since time.Time,
+	dupErr error,
+) (SentMessage, error) {
+
+	res, err := api.MessagesGetHistory(ctx, &tg.MessagesGetHistoryRequest{
+		Peer:  peer,
+		Limit: duplicateLookupLimit,
+	})
+	if err != nil {
+		c.logger.Warn("failed to look up duplicate message",
+			zap.String("session_id", c.sessionID),
+			zap.Error(err),
+		)
+		return SentMessage{}, dupErr
+	}
+
+	sent, ok := sentFromHistory(res, text, since)
+	if !ok {
+		return SentMessage{}, dupErr
 	}
 
-	return 0, nil
+	c.logger.Info("send was already delivered",
+		zap.String("session_id", c.sessionID),
+		zap.Int64("message_id", sent.ID),
+	)
+	return sent, nil
 }
 
-func (c *Client) resolvePeer(ctx context.Context, peer string) (tg.InputPeerClass, error) {
+func (c *Client) resolvePeer(ctx context.Context, peer string) (_ tg.InputPeerClass, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "telegram.resolvePeer",
+		trace.WithAttributes(tracing.AttrSessionID.String(c.sessionID)),
+	)
+	defer func() {
+		tracing.Fail(span, err)
+		span.End()
+	}()
+
 	if peer == "" {
-		return nil, errors.New("peer is empty")
+		return nil, fmt.Errorf("%w: peer is empty", ErrInvalidPeer)
 	}
 
 	normalized := strings.TrimPrefix(peer, "@")
@@ -378,9 +843,14 @@ func (c *Client) resolvePeer(ctx context.Context, peer string) (tg.InputPeerClas
 	c.peerMu.RLock()
 	if p, ok := c.peerCache[normalized]; ok {
 		c.peerMu.RUnlock()
+		span.SetAttributes(
+			tracing.AttrPeerCached.Bool(true),
+			tracing.AttrPeerType.String(inputPeerType(p)),
+		)
 		return p, nil
 	}
 	c.peerMu.RUnlock()
+	span.SetAttributes(tracing.AttrPeerCached.Bool(false))
 
 	// resolve via the Telegram API
 	c.mu.RLock()
@@ -388,7 +858,7 @@ func (c *Client) resolvePeer(ctx context.Context, peer string) (tg.InputPeerClas
 	c.mu.RUnlock()
 
 	if client == nil {
-		return nil, errors.New("client not started")
+		return nil, ErrNotStarted
 	}
 
 	api := client.API()
@@ -401,12 +871,12 @@ func (c *Client) resolvePeer(ctx context.Context, peer string) (tg.InputPeerClas
 	}
 
 	if len(res.Users) == 0 {
-		return nil, errors.New("user not found")
+		return nil, ErrPeerNotFound
 	}
 
 	u, ok := res.Users[0].(*tg.User)
 	if !ok {
-		return nil, errors.New("unexpected user type")
+		return nil, fmt.Errorf("%w: unexpected user type", ErrPeerNotFound)
 	}
 
 	inputPeer := &tg.InputPeerUser{
@@ -414,6 +884,8 @@ func (c *Client) resolvePeer(ctx context.Context, peer string) (tg.InputPeerClas
 		AccessHash: u.AccessHash,
 	}
 
+	span.SetAttributes(tracing.AttrPeerType.String(inputPeerType(inputPeer)))
+
 	// caching
 	c.peerMu.Lock()
 	c.peerCache[normalized] = inputPeer
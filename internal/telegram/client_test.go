@@ -0,0 +1,151 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotd/td/bin"
+	tdtelegram "github.com/gotd/td/telegram"
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+	"github.com/zen-flo/telegram-service/internal/broker"
+	"github.com/zen-flo/telegram-service/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
+)
+
+func TestIsRevoked(t *testing.T) {
+	if !IsRevoked(fmt.Errorf("send: %w", tgerr.New(401, "AUTH_KEY_UNREGISTERED"))) {
+		t.Fatal("AUTH_KEY_UNREGISTERED must be a revoked authorization")
+	}
+	if IsRevoked(tgerr.New(401, "SESSION_PASSWORD_NEEDED")) {
+		t.Fatal("SESSION_PASSWORD_NEEDED is not a revoked authorization")
+	}
+	if IsRevoked(errors.New("connection reset")) {
+		t.Fatal("network errors are not a revoked authorization")
+	}
+}
+
+func TestClient_RevokedByServiceNotification(t *testing.T) {
+	d := broker.NewDispatcher()
+	events := d.Subscribe("s1")
+
+	c := NewClient(0, "", zap.NewNop(), d, "s1")
+
+	revoked := make(chan string, 2)
+	c.OnRevoked(func(reason string) { revoked <- reason })
+
+	// not authorized yet: ignored
+	c.processSingleUpdate(&tg.UpdateServiceNotification{Type: "AUTH_KEY_DROP_DUPLICATE"})
+
+	c.authorized.Store(true)
+	c.processSingleUpdate(&tg.UpdateServiceNotification{Type: "AUTH_KEY_DROP_DUPLICATE"})
+	c.processSingleUpdate(&tg.UpdateServiceNotification{Type: "AUTH_KEY_DROP_DUPLICATE"})
+
+	select {
+	case reason := <-revoked:
+		if reason != "AUTH_KEY_DROP_DUPLICATE" {
+			t.Fatalf("unexpected reason %q", reason)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("revocation not reported")
+	}
+
+	select {
+	case msg := <-events:
+		if msg.From != broker.SystemSender || msg.Text != "logged_out: AUTH_KEY_DROP_DUPLICATE" {
+			t.Fatalf("unexpected event %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("logged_out event not published")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if len(revoked) != 0 {
+		t.Fatal("revocation must be reported once")
+	}
+}
+
+func TestClient_Tracing(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	var buf bytes.Buffer
+	shutdown, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    tracing.ExporterStdout,
+		SampleRatio: 1,
+		Writer:      &buf,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := broker.NewDispatcher()
+	events := d.Subscribe("s1")
+	c := NewClient(0, "", zap.NewNop(), d, "s1")
+
+	invoke := c.traceInvoke(tdtelegram.InvokeFunc(func(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+		return tgerr.New(420, "FLOOD_WAIT_3")
+	}))
+	if err := invoke.Invoke(context.Background(), &tg.MessagesSendMessageRequest{}, nil); err == nil {
+		t.Fatal("expected the error of the invoker")
+	}
+
+	c.publishMessage(1, "user:1", "hi", 0)
+	select {
+	case msg := <-events:
+		if !msg.SpanContext.IsValid() {
+			t.Fatal("expected the message to carry the span of the update")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("message not published")
+	}
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"Name":"mtproto messages.sendMessage"`,
+		`"Key":"telegram.error","Value":{"Type":"STRING","Value":"FLOOD_WAIT"}`,
+		`"Name":"telegram.update"`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %s in the exported spans", want)
+		}
+	}
+}
+
+func TestClient_SubmitPassword(t *testing.T) {
+	c := NewClient(12345, "hash", zap.NewNop(), nil, "s1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := c.SubmitPassword(ctx, "secret"); !errors.Is(err, ErrPasswordNotRequired) {
+		t.Fatalf("expected ErrPasswordNotRequired without a login, got %v", err)
+	}
+
+	// a login waiting for the password
+	c.passwordRequired.Store(true)
+	got := make(chan string, 1)
+	go func() {
+		req := <-c.passwordCh
+		got <- req.password
+		req.resp <- ErrPasswordInvalid
+	}()
+
+	if !c.PasswordRequired() {
+		t.Fatal("expected the password to be required")
+	}
+	if err := c.SubmitPassword(ctx, "wrong"); !errors.Is(err, ErrPasswordInvalid) {
+		t.Fatalf("expected the error of the check, got %v", err)
+	}
+	if pw := <-got; pw != "wrong" {
+		t.Fatalf("expected the submitted password, got %q", pw)
+	}
+}
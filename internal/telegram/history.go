@@ -0,0 +1,120 @@
+package telegram
+
+import (
+	"context"
+
+	"github.com/gotd/td/tg"
+	"github.com/zen-flo/telegram-service/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Page sizes of History. Telegram returns at most 100 messages a call.
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 100
+)
+
+// HistoryMessage is a message of a chat history.
+type HistoryMessage struct {
+	ID int64
+	// From is the sender, e.g. "user:123". It is empty for outgoing
+	// messages of private chats, which name no sender.
+	From string
+	Text string
+	// Date is the server time of the message, unix seconds.
+	Date int64
+	// Out is set for messages sent by the account of the session.
+	Out bool
+}
+
+// History returns messages of the chat with peer older than offsetID,
+// newest first. offsetID 0 starts with the newest message; limit 0
+// means 50 and is capped at 100.
+func (c *Client) History(
+	ctx context.Context,
+	peer string,
+	offsetID int64,
+	limit int,
+) (_ []HistoryMessage, err error) {
+
+	if c.noop {
+		return nil, ErrNoopMode
+	}
+
+	ctx, span := tracing.Tracer().Start(ctx, "telegram.History",
+		trace.WithAttributes(tracing.AttrSessionID.String(c.sessionID)),
+	)
+	defer func() {
+		tracing.Fail(span, err)
+		span.End()
+	}()
+
+	c.mu.RLock()
+	client := c.client
+	c.mu.RUnlock()
+
+	if client == nil {
+		return nil, ErrNotStarted
+	}
+
+	inputPeer, err := c.resolvePeer(ctx, peer)
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(tracing.AttrPeerType.String(inputPeerType(inputPeer)))
+
+	switch {
+	case limit <= 0:
+		limit = defaultHistoryLimit
+	case limit > maxHistoryLimit:
+		limit = maxHistoryLimit
+	}
+
+	res, err := client.API().MessagesGetHistory(ctx, &tg.MessagesGetHistoryRequest{
+		Peer:     inputPeer,
+		OffsetID: int(offsetID),
+		Limit:    limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return historyMessages(res), nil
+}
+
+// historyMessages converts the result of messages.getHistory. Service
+// messages, such as joins, are skipped.
+func historyMessages(res tg.MessagesMessagesClass) []HistoryMessage {
+	modified, ok := res.AsModified()
+	if !ok {
+		return nil
+	}
+
+	messages := make([]HistoryMessage, 0, len(modified.GetMessages()))
+	for _, mc := range modified.GetMessages() {
+		msg, ok := mc.(*tg.Message)
+		if !ok {
+			continue
+		}
+
+		// private chats name the other side as the peer only
+		fromID := msg.FromID
+		if fromID == nil && !msg.Out {
+			fromID = msg.PeerID
+		}
+
+		var from string
+		if fromID != nil {
+			from = peerString(fromID)
+		}
+
+		messages = append(messages, HistoryMessage{
+			ID:   int64(msg.ID),
+			From: from,
+			Text: msg.Message,
+			Date: int64(msg.Date),
+			Out:  msg.Out,
+		})
+	}
+	return messages
+}
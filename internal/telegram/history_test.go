@@ -0,0 +1,32 @@
+package telegram
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/gotd/td/tg"
+)
+
+func TestHistoryMessages(t *testing.T) {
+	res := &tg.MessagesChannelMessages{
+		Messages: []tg.MessageClass{
+			&tg.Message{ID: 3, Message: "hi", Date: 300, PeerID: &tg.PeerUser{UserID: 9}},
+			&tg.MessageService{ID: 2, PeerID: &tg.PeerUser{UserID: 9}},
+			&tg.Message{ID: 1, Out: true, Message: "hello", Date: 100, PeerID: &tg.PeerUser{UserID: 9}},
+			&tg.Message{ID: 0, Message: "post", PeerID: &tg.PeerChannel{ChannelID: 5}, FromID: &tg.PeerUser{UserID: 7}},
+		},
+	}
+
+	want := []HistoryMessage{
+		{ID: 3, From: "user:9", Text: "hi", Date: 300},
+		{ID: 1, Text: "hello", Date: 100, Out: true},
+		{ID: 0, From: "user:7", Text: "post"},
+	}
+	if got := historyMessages(res); !slices.Equal(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+
+	if got := historyMessages(&tg.MessagesMessagesNotModified{}); len(got) != 0 {
+		t.Fatalf("expected no messages for an unmodified history, got %+v", got)
+	}
+}
@@ -0,0 +1,217 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gotd/td/tg"
+)
+
+// ErrMessageIDUnknown is matched by *UnknownSendResultError.
+var ErrMessageIDUnknown = errors.New("message id could not be determined")
+
+// SentMessage describes a message accepted by Telegram.
+type SentMessage struct {
+	ID int64
+	// Peer is the chat the message was sent to, e.g. "user:123".
+	Peer string
+	// Date is the server time of the message, unix seconds.
+	Date int64
+}
+
+// UnknownSendResultError is returned when Telegram accepted a send but
+// the response carries no message ID. The message may have been sent.
+type UnknownSendResultError struct {
+	Result string
+}
+
+func (e *UnknownSendResultError) Error() string {
+	return fmt.Sprintf("%s: unexpected send result %s", ErrMessageIDUnknown, e.Result)
+}
+
+func (e *UnknownSendResultError) Is(target error) bool {
+	return target == ErrMessageIDUnknown
+}
+
+// sentMessage extracts the message sent with randomID from the result
+// of messages.sendMessage. to is the peer the message was sent to, used
+// when the result itself does not name it.
+func sentMessage(res tg.UpdatesClass, randomID int64, to tg.InputPeerClass) (SentMessage, error) {
+	switch v := res.(type) {
+	case *tg.UpdateShortSentMessage:
+		return SentMessage{
+			ID:   int64(v.ID),
+			Peer: inputPeerString(to),
+			Date: int64(v.Date),
+		}, nil
+
+	case *tg.UpdateShortMessage:
+		return SentMessage{
+			ID:   int64(v.ID),
+			Peer: "user:" + strconv.FormatInt(v.UserID, 10),
+			Date: int64(v.Date),
+		}, nil
+
+	case *tg.UpdateShortChatMessage:
+		return SentMessage{
+			ID:   int64(v.ID),
+			Peer: "chat:" + strconv.FormatInt(v.ChatID, 10),
+			Date: int64(v.Date),
+		}, nil
+
+	case *tg.Updates:
+		return sentFromUpdates(v.Updates, v.Date, randomID, to)
+
+	case *tg.UpdatesCombined:
+		return sentFromUpdates(v.Updates, v.Date, randomID, to)
+
+	case *tg.UpdateShort:
+		return sentFromUpdates([]tg.UpdateClass{v.Update}, v.Date, randomID, to)
+	}
+
+	return SentMessage{}, &UnknownSendResultError{Result: fmt.Sprintf("%T", res)}
+}
+
+// sentFromUpdates finds the sent message in a list of updates. The
+// message ID comes from UpdateMessageID matching randomID; the peer and
+// date come from the new message update with that ID. Without
+// UpdateMessageID the only outgoing new message is used.
+func sentFromUpdates(list []tg.UpdateClass, date int, randomID int64, to tg.InputPeerClass) (SentMessage, error) {
+	id := 0
+	for _, upd := range list {
+		if m, ok := upd.(*tg.UpdateMessageID); ok && m.RandomID == randomID {
+			id = m.ID
+			break
+		}
+	}
+
+	var (
+		found    *tg.Message
+		outgoing []*tg.Message
+	)
+	for _, upd := range list {
+		var mc tg.MessageClass
+		switch u := upd.(type) {
+		case *tg.UpdateNewMessage:
+			mc = u.Message
+		case *tg.UpdateNewChannelMessage:
+			mc = u.Message
+		default:
+			continue
+		}
+
+		msg, ok := mc.(*tg.Message)
+		if !ok {
+			continue
+		}
+		if id != 0 && msg.ID == id {
+			found = msg
+			break
+		}
+		if msg.Out {
+			outgoing = append(outgoing, msg)
+		}
+	}
+
+	if found == nil && id == 0 && len(outgoing) == 1 {
+		found = outgoing[0]
+	}
+
+	switch {
+	case found != nil:
+		return SentMessage{
+			ID:   int64(found.ID),
+			Peer: peerString(found.PeerID),
+			Date: int64(found.Date),
+		}, nil
+	case id != 0:
+		return SentMessage{
+			ID:   int64(id),
+			Peer: inputPeerString(to),
+			Date: int64(date),
+		}, nil
+	}
+
+	return SentMessage{}, &UnknownSendResultError{Result: "updates without the sent message"}
+}
+
+// duplicateLookupLimit is how many recent messages of a chat are
+// searched for the message of a send rejected as RANDOM_ID_DUPLICATE.
+const duplicateLookupLimit = 50
+
+// duplicateClockSkew is how much earlier than the first attempt of a
+// send, by the local clock, Telegram may date its message.
+const duplicateClockSkew = time.Minute
+
+// sentFromHistory finds the message an earlier attempt of a send was
+// delivered as. Telegram does not return it with RANDOM_ID_DUPLICATE
+// and history does not carry random IDs, so it is the oldest outgoing
+// message with the same text sent since the first attempt. A message
+// with the same text sent later by other means is not mistaken for it,
+// unless both were sent after since. res lists the newest messages
+// first.
+func sentFromHistory(res tg.MessagesMessagesClass, text string, since time.Time) (SentMessage, bool) {
+	modified, ok := res.AsModified()
+	if !ok {
+		return SentMessage{}, false
+	}
+
+	notBefore := since.Add(-duplicateClockSkew).Unix()
+
+	var found *tg.Message
+	for _, mc := range modified.GetMessages() {
+		msg, ok := mc.(*tg.Message)
+		if !ok || !msg.Out || msg.Message != text {
+			continue
+		}
+		if int64(msg.Date) < notBefore {
+			// older than the send, so are the rest
+			break
+		}
+		found = msg
+	}
+	if found == nil {
+		return SentMessage{}, false
+	}
+
+	return SentMessage{
+		ID:   int64(found.ID),
+		Peer: peerString(found.PeerID),
+		Date: int64(found.Date),
+	}, true
+}
+
+func peerString(p tg.PeerClass) string {
+	switch v := p.(type) {
+	case *tg.PeerUser:
+		return "user:" + strconv.FormatInt(v.UserID, 10)
+	case *tg.PeerChat:
+		return "chat:" + strconv.FormatInt(v.ChatID, 10)
+	case *tg.PeerChannel:
+		return "channel:" + strconv.FormatInt(v.ChannelID, 10)
+	}
+	return "unknown"
+}
+
+func inputPeerString(p tg.InputPeerClass) string {
+	switch v := p.(type) {
+	case *tg.InputPeerUser:
+		return "user:" + strconv.FormatInt(v.UserID, 10)
+	case *tg.InputPeerChat:
+		return "chat:" + strconv.FormatInt(v.ChatID, 10)
+	case *tg.InputPeerChannel:
+		return "channel:" + strconv.FormatInt(v.ChannelID, 10)
+	case *tg.InputPeerSelf:
+		return "self"
+	}
+	return "unknown"
+}
+
+// inputPeerType returns the kind of peer: user, chat, channel or self.
+func inputPeerType(p tg.InputPeerClass) string {
+	kind, _, _ := strings.Cut(inputPeerString(p), ":")
+	return kind
+}
@@ -0,0 +1,133 @@
+package telegram
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gotd/td/tg"
+)
+
+func TestSentMessage(t *testing.T) {
+	const randomID = 777
+	to := &tg.InputPeerChannel{ChannelID: 5, AccessHash: 1}
+
+	tests := []struct {
+		name string
+		res  tg.UpdatesClass
+		want SentMessage
+	}{
+		{
+			name: "short sent message",
+			res:  &tg.UpdateShortSentMessage{ID: 10, Date: 1000},
+			want: SentMessage{ID: 10, Peer: "channel:5", Date: 1000},
+		},
+		{
+			name: "channel updates",
+			res: &tg.Updates{
+				Date: 999,
+				Updates: []tg.UpdateClass{
+					&tg.UpdateMessageID{ID: 11, RandomID: randomID},
+					&tg.UpdateNewChannelMessage{Message: &tg.Message{
+						ID: 11, Out: true, Date: 1001, PeerID: &tg.PeerChannel{ChannelID: 5},
+					}},
+				},
+			},
+			want: SentMessage{ID: 11, Peer: "channel:5", Date: 1001},
+		},
+		{
+			name: "combined updates with other messages",
+			res: &tg.UpdatesCombined{
+				Date: 999,
+				Updates: []tg.UpdateClass{
+					&tg.UpdateNewMessage{Message: &tg.Message{
+						ID: 3, Out: true, Date: 500, PeerID: &tg.PeerUser{UserID: 9},
+					}},
+					&tg.UpdateMessageID{ID: 12, RandomID: 1},
+					&tg.UpdateMessageID{ID: 13, RandomID: randomID},
+					&tg.UpdateNewMessage{Message: &tg.Message{
+						ID: 13, Out: true, Date: 1002, PeerID: &tg.PeerUser{UserID: 8},
+					}},
+				},
+			},
+			want: SentMessage{ID: 13, Peer: "user:8", Date: 1002},
+		},
+		{
+			name: "message id without new message",
+			res: &tg.Updates{
+				Date:    1003,
+				Updates: []tg.UpdateClass{&tg.UpdateMessageID{ID: 14, RandomID: randomID}},
+			},
+			want: SentMessage{ID: 14, Peer: "channel:5", Date: 1003},
+		},
+		{
+			name: "outgoing message without message id",
+			res: &tg.Updates{Updates: []tg.UpdateClass{
+				&tg.UpdateNewMessage{Message: &tg.Message{
+					ID: 15, Out: true, Date: 1004, PeerID: &tg.PeerChat{ChatID: 4},
+				}},
+			}},
+			want: SentMessage{ID: 15, Peer: "chat:4", Date: 1004},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sentMessage(tt.res, randomID, to)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSentMessage_Unknown(t *testing.T) {
+	for _, res := range []tg.UpdatesClass{
+		&tg.UpdatesTooLong{},
+		&tg.Updates{Updates: []tg.UpdateClass{&tg.UpdateMessageID{ID: 1, RandomID: 2}}},
+	} {
+		_, err := sentMessage(res, 777, &tg.InputPeerSelf{})
+
+		var unknown *UnknownSendResultError
+		if !errors.As(err, &unknown) || !errors.Is(err, ErrMessageIDUnknown) {
+			t.Fatalf("expected UnknownSendResultError for %T, got %v", res, err)
+		}
+	}
+}
+
+func TestSentFromHistory(t *testing.T) {
+	since := time.Unix(10000, 0)
+
+	res := &tg.MessagesMessagesSlice{
+		Messages: []tg.MessageClass{
+			&tg.Message{ID: 31, Message: "hello", Date: 10030, PeerID: &tg.PeerUser{UserID: 9}},
+			&tg.Message{ID: 30, Out: true, Message: "hello", Date: 10020, PeerID: &tg.PeerUser{UserID: 9}},
+			&tg.Message{ID: 29, Out: true, Message: "other", Date: 10010, PeerID: &tg.PeerUser{UserID: 9}},
+			&tg.Message{ID: 28, Out: true, Message: "hello", Date: 9990, PeerID: &tg.PeerUser{UserID: 9}},
+			&tg.Message{ID: 27, Out: true, Message: "hello", Date: 5000, PeerID: &tg.PeerUser{UserID: 9}},
+		},
+	}
+
+	got, ok := sentFromHistory(res, "hello", since)
+	if !ok {
+		t.Fatal("expected the outgoing message to be found")
+	}
+	// 28 is dated within the clock skew of the first attempt, 27 was
+	// sent before it
+	if want := (SentMessage{ID: 28, Peer: "user:9", Date: 9990}); got != want {
+		t.Fatalf("expected the first outgoing message since the send %+v, got %+v", want, got)
+	}
+
+	if _, ok := sentFromHistory(res, "hello", time.Unix(20000, 0)); ok {
+		t.Fatal("expected no message sent before the first attempt")
+	}
+	if _, ok := sentFromHistory(res, "missing", since); ok {
+		t.Fatal("expected no message for another text")
+	}
+	if _, ok := sentFromHistory(&tg.MessagesMessagesNotModified{}, "hello", since); ok {
+		t.Fatal("expected no message for an unmodified history")
+	}
+}
@@ -0,0 +1,254 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/gotd/td/telegram/updates"
+)
+
+var errStateNotFound = errors.New("update state not found")
+
+const sessionDir = "sessions"
+
+// SessionPath returns the path of the gotd session (auth key) file.
+func SessionPath(sessionID string) string {
+	return sessionDir + "/" + sessionID + ".json"
+}
+
+// StatePath returns the path of the persisted update state file.
+func StatePath(sessionID string) string {
+	return sessionDir + "/" + sessionID + ".state.json"
+}
+
+// RemoveSessionFiles deletes the stored auth key, update state,
+// metadata and outbound queue of a session. Missing files are ignored.
+func RemoveSessionFiles(sessionID string) error {
+	var errs []error
+	for _, path := range []string{SessionPath(sessionID), StatePath(sessionID), MetaPath(sessionID), OutboxPath(sessionID)} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CheckSessionDir reports whether session files can be written, by
+// creating and removing a probe file.
+func CheckSessionDir() error {
+	if err := os.MkdirAll(sessionDir, 0o700); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(sessionDir, ".probe-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	if err := f.Close(); err != nil {
+		_ = os.Remove(name)
+		return err
+	}
+	return os.Remove(name)
+}
+
+// MetaPath returns the path of the session metadata file.
+func MetaPath(sessionID string) string {
+	return sessionDir + "/" + sessionID + ".meta.json"
+}
+
+var (
+	_ updates.StateStorage        = (*FileStateStorage)(nil)
+	_ updates.ChannelAccessHasher = (*FileStateStorage)(nil)
+)
+
+// FileStateStorage persists pts/qts/seq and channel access hashes of a
+// session in a JSON file, so that updates.getDifference can recover
+// updates missed while the client was offline.
+type FileStateStorage struct {
+	path string
+
+	mu   sync.Mutex
+	data stateFile
+}
+
+type stateFile struct {
+	States       map[int64]updates.State   `json:"states"`
+	Channels     map[int64]map[int64]int   `json:"channels"`
+	AccessHashes map[int64]map[int64]int64 `json:"access_hashes"`
+}
+
+func NewFileStateStorage(path string) (*FileStateStorage, error) {
+	s := &FileStateStorage{
+		path: path,
+		data: stateFile{
+			States:       make(map[int64]updates.State),
+			Channels:     make(map[int64]map[int64]int),
+			AccessHashes: make(map[int64]map[int64]int64),
+		},
+	}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(raw, &s.data); err != nil {
+		return nil, err
+	}
+	if s.data.States == nil {
+		s.data.States = make(map[int64]updates.State)
+	}
+	if s.data.Channels == nil {
+		s.data.Channels = make(map[int64]map[int64]int)
+	}
+	if s.data.AccessHashes == nil {
+		s.data.AccessHashes = make(map[int64]map[int64]int64)
+	}
+
+	return s, nil
+}
+
+func (s *FileStateStorage) GetState(_ context.Context, userID int64) (updates.State, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.data.States[userID]
+	return state, ok, nil
+}
+
+func (s *FileStateStorage) SetState(_ context.Context, userID int64, state updates.State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.States[userID] = state
+	s.data.Channels[userID] = make(map[int64]int)
+	return s.flush()
+}
+
+func (s *FileStateStorage) SetPts(_ context.Context, userID int64, pts int) error {
+	return s.update(userID, func(state *updates.State) { state.Pts = pts })
+}
+
+func (s *FileStateStorage) SetQts(_ context.Context, userID int64, qts int) error {
+	return s.update(userID, func(state *updates.State) { state.Qts = qts })
+}
+
+func (s *FileStateStorage) SetDate(_ context.Context, userID int64, date int) error {
+	return s.update(userID, func(state *updates.State) { state.Date = date })
+}
+
+func (s *FileStateStorage) SetSeq(_ context.Context, userID int64, seq int) error {
+	return s.update(userID, func(state *updates.State) { state.Seq = seq })
+}
+
+func (s *FileStateStorage) SetDateSeq(_ context.Context, userID int64, date, seq int) error {
+	return s.update(userID, func(state *updates.State) {
+		state.Date = date
+		state.Seq = seq
+	})
+}
+
+func (s *FileStateStorage) GetChannelPts(_ context.Context, userID, channelID int64) (int, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pts, ok := s.data.Channels[userID][channelID]
+	return pts, ok, nil
+}
+
+func (s *FileStateStorage) SetChannelPts(_ context.Context, userID, channelID int64, pts int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	channels, ok := s.data.Channels[userID]
+	if !ok {
+		return errStateNotFound
+	}
+	channels[channelID] = pts
+	return s.flush()
+}
+
+func (s *FileStateStorage) ForEachChannels(
+	ctx context.Context,
+	userID int64,
+	f func(ctx context.Context, channelID int64, pts int) error,
+) error {
+	s.mu.Lock()
+	channels := make(map[int64]int, len(s.data.Channels[userID]))
+	for id, pts := range s.data.Channels[userID] {
+		channels[id] = pts
+	}
+	s.mu.Unlock()
+
+	for id, pts := range channels {
+		if err := f(ctx, id, pts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *FileStateStorage) SetChannelAccessHash(_ context.Context, userID, channelID, accessHash int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hashes, ok := s.data.AccessHashes[userID]
+	if !ok {
+		hashes = make(map[int64]int64)
+		s.data.AccessHashes[userID] = hashes
+	}
+	hashes[channelID] = accessHash
+	return s.flush()
+}
+
+func (s *FileStateStorage) GetChannelAccessHash(_ context.Context, userID, channelID int64) (int64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hash, ok := s.data.AccessHashes[userID][channelID]
+	return hash, ok, nil
+}
+
+func (s *FileStateStorage) update(userID int64, fn func(state *updates.State)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.data.States[userID]
+	if !ok {
+		return errStateNotFound
+	}
+	fn(&state)
+	s.data.States[userID] = state
+	return s.flush()
+}
+
+// flush writes the state atomically. Must be called with mu held.
+func (s *FileStateStorage) flush() error {
+	raw, err := json.Marshal(s.data)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// OutboxPath returns the path of the persisted outbound queue.
+func OutboxPath(sessionID string) string {
+	return sessionDir + "/" + sessionID + ".outbox.jsonl"
+}
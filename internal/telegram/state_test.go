@@ -0,0 +1,59 @@
+package telegram
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/gotd/td/telegram/updates"
+)
+
+func TestFileStateStorage_Persist(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "s.state.json")
+
+	s, err := NewFileStateStorage(path)
+	if err != nil {
+		t.Fatalf("open storage: %v", err)
+	}
+
+	if err := s.SetPts(ctx, 1, 10); err == nil {
+		t.Fatal("expected error when state does not exist")
+	}
+
+	if err := s.SetState(ctx, 1, updates.State{Pts: 1, Qts: 2, Date: 3, Seq: 4}); err != nil {
+		t.Fatalf("set state: %v", err)
+	}
+	if err := s.SetPts(ctx, 1, 42); err != nil {
+		t.Fatalf("set pts: %v", err)
+	}
+	if err := s.SetChannelPts(ctx, 1, 100, 7); err != nil {
+		t.Fatalf("set channel pts: %v", err)
+	}
+	if err := s.SetChannelAccessHash(ctx, 1, 100, 555); err != nil {
+		t.Fatalf("set access hash: %v", err)
+	}
+
+	reopened, err := NewFileStateStorage(path)
+	if err != nil {
+		t.Fatalf("reopen storage: %v", err)
+	}
+
+	state, ok, err := reopened.GetState(ctx, 1)
+	if err != nil || !ok {
+		t.Fatalf("expected stored state, ok=%v err=%v", ok, err)
+	}
+	if state.Pts != 42 || state.Seq != 4 {
+		t.Fatalf("unexpected state: %+v", state)
+	}
+
+	pts, ok, _ := reopened.GetChannelPts(ctx, 1, 100)
+	if !ok || pts != 7 {
+		t.Fatalf("unexpected channel pts: %d (found=%v)", pts, ok)
+	}
+
+	hash, ok, _ := reopened.GetChannelAccessHash(ctx, 1, 100)
+	if !ok || hash != 555 {
+		t.Fatalf("unexpected access hash: %d (found=%v)", hash, ok)
+	}
+}
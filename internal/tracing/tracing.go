@@ -0,0 +1,152 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var ErrUnknownExporter = errors.New("unknown trace exporter")
+
+// Exporters.
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// ServiceName is the default service.name of exported spans, see
+// OTEL_SERVICE_NAME.
+const ServiceName = "telegram-service"
+
+// Span attributes.
+const (
+	AttrSessionID    = attribute.Key("telegram.session_id")
+	AttrPeerType     = attribute.Key("telegram.peer_type")
+	AttrMethod       = attribute.Key("telegram.method")
+	AttrError        = attribute.Key("telegram.error")
+	AttrPeerCached   = attribute.Key("telegram.peer_cached")
+	AttrMessageFrom  = attribute.Key("telegram.message.from")
+	AttrWebhookID    = attribute.Key("webhook.id")
+	AttrWebhookTries = attribute.Key("webhook.attempts")
+	AttrSinkSubject  = attribute.Key("sink.subject")
+)
+
+const instrumentationName = "github.com/zen-flo/telegram-service"
+
+type Options struct {
+	// Exporter is "none", "otlp" or "stdout". The OTLP exporter is
+	// configured by the standard OTEL_EXPORTER_OTLP_* variables.
+	Exporter string
+	// SampleRatio is the fraction of new traces recorded. Traces
+	// started by a sampled caller are always recorded.
+	SampleRatio float64
+	// Writer receives the spans of the stdout exporter. Defaults to
+	// os.Stdout.
+	Writer io.Writer
+}
+
+// Setup installs the global tracer provider and the W3C trace context
+// propagator. The returned function flushes and stops the exporter.
+func Setup(ctx context.Context, opts Options) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	switch opts.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		exporter, err = otlptracegrpc.New(ctx)
+	case ExporterStdout:
+		w := opts.Writer
+		if w == nil {
+			w = os.Stdout
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(w))
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownExporter, opts.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create %s exporter: %w", opts.Exporter, err)
+	}
+
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewSchemaless(semconv.ServiceName(ServiceName)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES take precedence.
+	if env, err := resource.New(ctx, resource.WithFromEnv()); err == nil {
+		if merged, err := resource.Merge(res, env); err == nil {
+			res = merged
+		}
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer of the service. It uses the global provider
+// at the time of each call, so spans are dropped until Setup is called.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Fail records err on span and sets the error status. It returns err,
+// so it can wrap a return statement.
+func Fail(span trace.Span, err error) error {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// LinkTo returns a start option linking the new span to sc, e.g. to the
+// span of the update a message came from. Invalid contexts are ignored.
+func LinkTo(sc trace.SpanContext) trace.SpanStartOption {
+	if !sc.IsValid() {
+		return trace.WithLinks()
+	}
+	return trace.WithLinks(trace.Link{SpanContext: sc})
+}
+
+// Traceparent encodes sc as a W3C traceparent value, so that a span
+// context can be stored with a record and linked to after a restart.
+// Invalid contexts encode as "".
+func Traceparent(sc trace.SpanContext) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(trace.ContextWithSpanContext(context.Background(), sc), carrier)
+	return carrier.Get("traceparent")
+}
+
+// ParseTraceparent decodes a value of Traceparent. Malformed values
+// give an invalid span context, which LinkTo ignores.
+func ParseTraceparent(s string) trace.SpanContext {
+	carrier := propagation.MapCarrier{"traceparent": s}
+	return trace.SpanContextFromContext(propagation.TraceContext{}.Extract(context.Background(), carrier))
+}
@@ -0,0 +1,117 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// setupStdout routes spans to a buffer until the test ends.
+func setupStdout(t *testing.T) (*bytes.Buffer, func()) {
+	t.Helper()
+
+	prev := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	var buf bytes.Buffer
+	shutdown, err := Setup(context.Background(), Options{
+		Exporter:    ExporterStdout,
+		SampleRatio: 1,
+		Writer:      &buf,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &buf, func() {
+		if err := shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+type exportedSpan struct {
+	Name        string
+	SpanContext struct{ TraceID, SpanID string }
+	Parent      struct{ SpanID string }
+	Links       []struct {
+		SpanContext struct{ SpanID string }
+	}
+	Status struct{ Code string }
+}
+
+func decodeSpans(t *testing.T, buf *bytes.Buffer) map[string]exportedSpan {
+	t.Helper()
+
+	spans := make(map[string]exportedSpan)
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var s exportedSpan
+		if err := dec.Decode(&s); err != nil {
+			t.Fatal(err)
+		}
+		spans[s.Name] = s
+	}
+	return spans
+}
+
+func TestSetup_Stdout(t *testing.T) {
+	buf, flush := setupStdout(t)
+
+	_, update := Tracer().Start(context.Background(), "update")
+	update.End()
+
+	ctx, parent := Tracer().Start(context.Background(), "parent")
+	_, child := Tracer().Start(ctx, "child", LinkTo(update.SpanContext()))
+	_ = Fail(child, errors.New("boom"))
+	child.End()
+	parent.End()
+
+	flush()
+	spans := decodeSpans(t, buf)
+
+	got, ok := spans["child"]
+	if !ok {
+		t.Fatalf("child span not exported, got %v", spans)
+	}
+	if got.Parent.SpanID != spans["parent"].SpanContext.SpanID {
+		t.Fatal("expected child to be a child of parent")
+	}
+	if len(got.Links) != 1 || got.Links[0].SpanContext.SpanID != spans["update"].SpanContext.SpanID {
+		t.Fatalf("expected a link to the update span, got %+v", got.Links)
+	}
+	if got.Status.Code != "Error" {
+		t.Fatalf("expected error status, got %q", got.Status.Code)
+	}
+}
+
+func TestSetup_UnknownExporter(t *testing.T) {
+	if _, err := Setup(context.Background(), Options{Exporter: "zipkin"}); !errors.Is(err, ErrUnknownExporter) {
+		t.Fatalf("expected ErrUnknownExporter, got %v", err)
+	}
+}
+
+func TestTraceparent(t *testing.T) {
+	_, flush := setupStdout(t)
+	defer flush()
+
+	_, span := Tracer().Start(context.Background(), "update")
+	defer span.End()
+
+	sc := span.SpanContext()
+	got := ParseTraceparent(Traceparent(sc))
+	if got.TraceID() != sc.TraceID() || got.SpanID() != sc.SpanID() {
+		t.Fatalf("expected %v after a round trip, got %v", sc, got)
+	}
+
+	if Traceparent(trace.SpanContext{}) != "" {
+		t.Fatal("expected an invalid context to encode as empty")
+	}
+	if ParseTraceparent("garbage").IsValid() {
+		t.Fatal("expected a malformed value to give an invalid context")
+	}
+}
@@ -0,0 +1,80 @@
+package webhook
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DeadLetter is a delivery that failed after all retry attempts.
+type DeadLetter struct {
+	WebhookID string          `json:"webhook_id"`
+	URL       string          `json:"url"`
+	Payload   json.RawMessage `json:"payload"`
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"last_error"`
+	FailedAt  time.Time       `json:"failed_at"`
+}
+
+type DeadLetterStore interface {
+	Put(letter DeadLetter) error
+}
+
+// FileDeadLetterStore appends dead letters to a JSON Lines file.
+type FileDeadLetterStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileDeadLetterStore(path string) *FileDeadLetterStore {
+	return &FileDeadLetterStore{path: path}
+}
+
+func (s *FileDeadLetterStore) Put(letter DeadLetter) error {
+	raw, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(raw, '\n'))
+	return err
+}
+
+// MemoryDeadLetterStore keeps dead letters in memory.
+type MemoryDeadLetterStore struct {
+	mu      sync.Mutex
+	letters []DeadLetter
+}
+
+func NewMemoryDeadLetterStore() *MemoryDeadLetterStore {
+	return &MemoryDeadLetterStore{}
+}
+
+func (s *MemoryDeadLetterStore) Put(letter DeadLetter) error {
+	s.mu.Lock()
+	s.letters = append(s.letters, letter)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemoryDeadLetterStore) Letters() []DeadLetter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]DeadLetter(nil), s.letters...)
+}
@@ -0,0 +1,71 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrAddressNotAllowed is returned for deliveries to internal addresses
+// outside of Options.AllowedNetworks.
+var ErrAddressNotAllowed = errors.New("webhook address not allowed")
+
+// sharedAddressSpace is the carrier-grade NAT range of RFC 6598.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// newTransport returns the transport of deliveries. Every connection,
+// redirects included, is checked by the dialer after the host name was
+// resolved, so neither a URL nor its DNS records can point a webhook at
+// the internal network. Proxies of the environment are not used, the
+// dialer would check the proxy instead of the webhook.
+func newTransport(allowed []netip.Prefix) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			return checkAddress(address, allowed)
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// checkAddress rejects the "ip:port" address if it is internal and not
+// in allowed.
+func checkAddress(address string, allowed []netip.Prefix) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrAddressNotAllowed, address)
+	}
+	ip := addrPort.Addr().Unmap().WithZone("")
+
+	for _, prefix := range allowed {
+		if prefix.Contains(ip) {
+			return nil
+		}
+	}
+	if internal(ip) {
+		return fmt.Errorf("%w: %s is an internal address", ErrAddressNotAllowed, ip)
+	}
+	return nil
+}
+
+// internal reports whether ip is a loopback, link-local, private
+// (RFC 1918, fc00::/7), shared (RFC 6598), unspecified or multicast
+// address.
+func internal(ip netip.Addr) bool {
+	return ip.IsLoopback() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsPrivate() ||
+		ip.IsUnspecified() ||
+		ip.IsMulticast() ||
+		sharedAddressSpace.Contains(ip)
+}
@@ -0,0 +1,541 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zen-flo/telegram-service/internal/broker"
+	"github.com/zen-flo/telegram-service/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	// SignatureHeader carries "sha256=<hex HMAC-SHA256 of the body>".
+	SignatureHeader = "X-Webhook-Signature-256"
+	IDHeader        = "X-Webhook-ID"
+)
+
+type Options struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Timeout        time.Duration
+	QueueSize      int
+	// AllowedNetworks are trusted internal networks webhooks may be
+	// delivered to. Loopback, link-local, private and other internal
+	// addresses outside of them are rejected when dialing.
+	AllowedNetworks []netip.Prefix
+}
+
+func (o *Options) setDefaults() {
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 5
+	}
+	if o.InitialBackoff <= 0 {
+		o.InitialBackoff = time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = time.Minute
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = 256
+	}
+}
+
+// LabelSource returns the labels of a session, if it exists.
+type LabelSource func(sessionID string) (map[string]string, bool)
+
+// Event is the JSON body posted to webhooks.
+type Event struct {
+	SessionID string `json:"session_id"`
+	MessageID int64  `json:"message_id"`
+	From      string `json:"from"`
+	Text      string `json:"text"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// Service delivers dispatcher messages to registered webhooks.
+// Every webhook has its own queue, so a slow endpoint does not
+// delay deliveries to the others.
+type Service struct {
+	dispatcher  *broker.Dispatcher
+	labels      LabelSource
+	deadLetters DeadLetterStore
+	logger      *zap.Logger
+	opts        Options
+	client      *http.Client
+
+	registry *registry
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	workers map[string]*worker
+	wg      sync.WaitGroup
+
+	// configured are the webhooks of the last Configure, as given.
+	configMu   sync.Mutex
+	configured map[string]Webhook
+}
+
+type worker struct {
+	hook   *Webhook
+	queue  chan delivery
+	cancel context.CancelFunc
+	busy   atomic.Bool // set while a delivery is in progress
+}
+
+// delivery is a queued event with the span of the update it came from.
+type delivery struct {
+	body []byte
+	link trace.SpanContext
+}
+
+func NewService(
+	dispatcher *broker.Dispatcher,
+	labels LabelSource,
+	deadLetters DeadLetterStore,
+	logger *zap.Logger,
+	opts Options,
+) *Service {
+	opts.setDefaults()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Service{
+		dispatcher:  dispatcher,
+		labels:      labels,
+		deadLetters: deadLetters,
+		logger:      logger,
+		opts:        opts,
+		client: &http.Client{
+			Timeout:   opts.Timeout,
+			Transport: newTransport(opts.AllowedNetworks),
+		},
+		registry:   newRegistry(),
+		ctx:        ctx,
+		cancel:     cancel,
+		workers:    make(map[string]*worker),
+		configured: make(map[string]Webhook),
+	}
+}
+
+// Register validates the webhook, assigns it an ID (and a secret
+// when none is given) and starts delivering to it.
+func (s *Service) Register(w Webhook) (*Webhook, error) {
+	if err := Validate(&w); err != nil {
+		return nil, err
+	}
+	w.ID = generateID()
+
+	return s.start(w)
+}
+
+// Configure replaces the webhooks set by the configuration, leaving
+// those registered by RPC alone. The webhooks must have IDs. Those that
+// did not change keep their queues, and their secrets when generated.
+// A configuration that is rejected leaves the webhooks unchanged.
+func (s *Service) Configure(hooks []Webhook) error {
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+
+	next := make(map[string]Webhook, len(hooks))
+	for _, w := range hooks {
+		if w.ID == "" {
+			return fmt.Errorf("%w: configured webhooks need an id", ErrInvalidWebhook)
+		}
+		if _, ok := next[w.ID]; ok {
+			return fmt.Errorf("%w: duplicate id %q", ErrInvalidWebhook, w.ID)
+		}
+		if err := Validate(&w); err != nil {
+			return fmt.Errorf("webhook %q: %w", w.ID, err)
+		}
+		if _, ok := s.configured[w.ID]; !ok {
+			if _, ok := s.registry.get(w.ID); ok {
+				return fmt.Errorf("%w: id %q is already registered", ErrInvalidWebhook, w.ID)
+			}
+		}
+		next[w.ID] = w
+	}
+
+	var stale []string
+	for id, w := range s.configured {
+		if n, ok := next[id]; ok && equal(n, w) {
+			if _, ok := s.registry.get(id); ok {
+				delete(next, id)
+				continue
+			}
+		}
+		stale = append(stale, id)
+	}
+
+	// everything that can fail is done before the first change
+	started := make(map[string]Webhook, len(next))
+	for id, w := range next {
+		w, err := withSecret(w)
+		if err != nil {
+			return err
+		}
+		started[id] = w
+	}
+
+	for _, id := range stale {
+		_ = s.Delete(id)
+		delete(s.configured, id)
+	}
+	for id, w := range next {
+		s.run(started[id])
+		s.configured[id] = w
+	}
+	return nil
+}
+
+// start generates a secret when w has none and starts delivering to it.
+func (s *Service) start(w Webhook) (*Webhook, error) {
+	w, err := withSecret(w)
+	if err != nil {
+		return nil, err
+	}
+	return s.run(w), nil
+}
+
+// withSecret returns w with a generated secret when it has none.
+func withSecret(w Webhook) (Webhook, error) {
+	if w.Secret != "" {
+		return w, nil
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return Webhook{}, err
+	}
+	w.Secret = secret
+	return w, nil
+}
+
+// run starts delivering to w.
+func (s *Service) run(w Webhook) *Webhook {
+	hook := &w
+	ctx, cancel := context.WithCancel(s.ctx)
+	wk := &worker{
+		hook:   hook,
+		queue:  make(chan delivery, s.opts.QueueSize),
+		cancel: cancel,
+	}
+
+	s.mu.Lock()
+	s.workers[hook.ID] = wk
+	s.mu.Unlock()
+	s.registry.add(hook)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.runWorker(ctx, wk)
+	}()
+
+	return hook
+}
+
+func (s *Service) Delete(id string) error {
+	if _, ok := s.registry.remove(id); !ok {
+		return ErrWebhookNotFound
+	}
+
+	s.mu.Lock()
+	wk, ok := s.workers[id]
+	delete(s.workers, id)
+	s.mu.Unlock()
+
+	if ok {
+		wk.cancel()
+	}
+	return nil
+}
+
+func (s *Service) Get(id string) (*Webhook, error) {
+	w, ok := s.registry.get(id)
+	if !ok {
+		return nil, ErrWebhookNotFound
+	}
+	return w, nil
+}
+
+func (s *Service) List() []*Webhook {
+	return s.registry.list()
+}
+
+// Run fans messages of all sessions out to the matching webhooks until
+// ctx is cancelled. Messages are routed as they are published, so none
+// is dropped: an event that does not fit the queue of a webhook goes to
+// the dead-letter store.
+func (s *Service) Run(ctx context.Context) {
+	remove := s.dispatcher.HandleAll(s.route)
+	defer remove()
+
+	select {
+	case <-ctx.Done():
+	case <-s.ctx.Done():
+	}
+}
+
+// Close stops all workers and waits for in-flight deliveries to return.
+func (s *Service) Close() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// Shutdown waits for queued deliveries to finish until ctx is done,
+// then closes the service. Events still queued at that point go to the
+// dead-letter store.
+func (s *Service) Shutdown(ctx context.Context) {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for s.pending() > 0 && ctx.Err() == nil {
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+		}
+	}
+
+	s.Close()
+}
+
+// pending returns the number of queued and in-flight deliveries.
+func (s *Service) pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for _, wk := range s.workers {
+		n += len(wk.queue)
+		if wk.busy.Load() {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *Service) route(msg *broker.Message) {
+	var body []byte
+
+	for _, hook := range s.registry.list() {
+		var labels map[string]string
+		if hook.SessionID == "" {
+			switch {
+			case msg.Scope != nil:
+				// the session was removed, e.g. by expiry
+				labels = msg.Scope.Labels
+			case s.labels != nil:
+				labels, _ = s.labels(msg.SessionID)
+			}
+		}
+
+		if !hook.matches(msg.SessionID, labels) {
+			continue
+		}
+
+		if body == nil {
+			var err error
+			body, err = json.Marshal(Event{
+				SessionID: msg.SessionID,
+				MessageID: msg.ID,
+				From:      msg.From,
+				Text:      msg.Text,
+				Timestamp: msg.Timestamp,
+			})
+			if err != nil {
+				s.logger.Error("failed to encode webhook event", zap.Error(err))
+				return
+			}
+		}
+
+		s.mu.Lock()
+		wk, ok := s.workers[hook.ID]
+		s.mu.Unlock()
+		if !ok {
+			continue
+		}
+
+		select {
+		case wk.queue <- delivery{body: body, link: msg.SpanContext}:
+		default:
+			s.deadLetter(hook, body, 0, fmt.Errorf("delivery queue is full"))
+		}
+	}
+}
+
+func (s *Service) runWorker(ctx context.Context, wk *worker) {
+	for {
+		select {
+		case <-ctx.Done():
+			s.deadLetterQueued(wk)
+			return
+		case d := <-wk.queue:
+			wk.busy.Store(true)
+			s.deliver(ctx, wk.hook, d)
+			wk.busy.Store(false)
+		}
+	}
+}
+
+// deadLetterQueued moves events left in the queue of a stopped worker
+// to the dead-letter store.
+func (s *Service) deadLetterQueued(wk *worker) {
+	for {
+		select {
+		case d := <-wk.queue:
+			s.deadLetter(wk.hook, d.body, 0, errors.New("webhook stopped"))
+		default:
+			return
+		}
+	}
+}
+
+// deliver posts the body, retrying transient failures with exponential
+// backoff. Permanently failed deliveries go to the dead-letter store.
+func (s *Service) deliver(ctx context.Context, hook *Webhook, d delivery) {
+	body := d.body
+	backoff := s.opts.InitialBackoff
+
+	ctx, span := tracing.Tracer().Start(ctx, "webhook.deliver",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		tracing.LinkTo(d.link),
+		trace.WithAttributes(tracing.AttrWebhookID.String(hook.ID)),
+	)
+	defer span.End()
+
+	var lastErr error
+	attempt := 0
+	defer func() {
+		span.SetAttributes(tracing.AttrWebhookTries.Int(attempt))
+	}()
+
+	for attempt < s.opts.MaxAttempts {
+		attempt++
+
+		retryable, err := s.post(ctx, hook, body)
+		if err == nil {
+			return
+		}
+		span.AddEvent("attempt failed", trace.WithAttributes(attribute.String("error", err.Error())))
+		lastErr = err
+
+		if !retryable || attempt == s.opts.MaxAttempts {
+			break
+		}
+
+		s.logger.Debug("webhook delivery failed, retrying",
+			zap.String("webhook_id", hook.ID),
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", backoff),
+			zap.Error(err),
+		)
+
+		select {
+		case <-ctx.Done():
+			// Shutdown or webhook removed: keep the event for later inspection.
+			tracing.Fail(span, lastErr)
+			s.deadLetter(hook, body, attempt, lastErr)
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > s.opts.MaxBackoff {
+			backoff = s.opts.MaxBackoff
+		}
+	}
+
+	tracing.Fail(span, lastErr)
+	s.deadLetter(hook, body, attempt, lastErr)
+}
+
+func (s *Service) post(ctx context.Context, hook *Webhook, body []byte) (retryable bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IDHeader, hook.ID)
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, body))
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return !errors.Is(err, ErrAddressNotAllowed), err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	err = fmt.Errorf("unexpected status code %d", resp.StatusCode)
+
+	switch {
+	case resp.StatusCode >= 500,
+		resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode == http.StatusRequestTimeout:
+		return true, err
+	default:
+		return false, err
+	}
+}
+
+func (s *Service) deadLetter(hook *Webhook, body []byte, attempts int, cause error) {
+	s.logger.Warn("webhook delivery failed permanently",
+		zap.String("webhook_id", hook.ID),
+		zap.Int("attempts", attempts),
+		zap.Error(cause),
+	)
+
+	if s.deadLetters == nil {
+		return
+	}
+
+	letter := DeadLetter{
+		WebhookID: hook.ID,
+		URL:       hook.URL,
+		Payload:   body,
+		Attempts:  attempts,
+		FailedAt:  time.Now().UTC(),
+	}
+	if cause != nil {
+		letter.LastError = cause.Error()
+	}
+
+	if err := s.deadLetters.Put(letter); err != nil {
+		s.logger.Error("failed to store dead letter", zap.Error(err))
+	}
+}
+
+// Sign returns the value of SignatureHeader for the body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
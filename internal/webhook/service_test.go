@@ -0,0 +1,339 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zen-flo/telegram-service/internal/broker"
+	"go.uber.org/zap"
+)
+
+// testNetworks allow deliveries to the httptest servers.
+var testNetworks = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}
+
+func newTestService(t *testing.T, d *broker.Dispatcher, dl DeadLetterStore) *Service {
+	t.Helper()
+
+	s := NewService(d, func(sessionID string) (map[string]string, bool) {
+		if sessionID == "labeled" {
+			return map[string]string{"team": "sales"}, true
+		}
+		return nil, true
+	}, dl, zap.NewNop(), Options{
+		MaxAttempts:     3,
+		InitialBackoff:  10 * time.Millisecond,
+		MaxBackoff:      20 * time.Millisecond,
+		AllowedNetworks: testNetworks,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.Run(ctx)
+	t.Cleanup(func() {
+		cancel()
+		s.Close()
+	})
+
+	waitForRun(t, d)
+	return s
+}
+
+// waitForRun waits until Run has registered with the dispatcher, so
+// that no message published by the test is missed.
+func waitForRun(t *testing.T, d *broker.Dispatcher) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for d.Stats().Subscribers == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Run did not subscribe in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestService_DeliverSignedWithRetry(t *testing.T) {
+	var calls atomic.Int32
+	received := make(chan Event, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		if r.Header.Get(SignatureHeader) != Sign("secret", body) {
+			t.Errorf("bad signature: %q", r.Header.Get(SignatureHeader))
+		}
+
+		// fail the first attempt to exercise the retry
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		var ev Event
+		if err := json.Unmarshal(body, &ev); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		received <- ev
+	}))
+	defer srv.Close()
+
+	d := broker.NewDispatcher()
+	s := newTestService(t, d, NewMemoryDeadLetterStore())
+
+	if _, err := s.Register(Webhook{
+		URL:           srv.URL,
+		Secret:        "secret",
+		LabelSelector: map[string]string{"team": "sales"},
+	}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	d.Publish("unlabeled", &broker.Message{ID: 1, Text: "skip"})
+	d.Publish("labeled", &broker.Message{ID: 2, Text: "hello"})
+
+	select {
+	case ev := <-received:
+		if ev.SessionID != "labeled" || ev.MessageID != 2 {
+			t.Fatalf("unexpected event: %+v", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("expected 2 attempts, got %d", got)
+	}
+
+	// an event of a removed session is routed by the labels it carries
+	expired := broker.SystemMessage("expired: ttl")
+	expired.Scope = &broker.Scope{Labels: map[string]string{"team": "sales"}}
+	d.Publish("removed", expired)
+
+	select {
+	case ev := <-received:
+		if ev.SessionID != "removed" || ev.Text != "expired: ttl" {
+			t.Fatalf("unexpected event: %+v", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("event of a removed session was not delivered")
+	}
+}
+
+func TestService_DeadLetter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	d := broker.NewDispatcher()
+	dl := NewMemoryDeadLetterStore()
+	s := newTestService(t, d, dl)
+
+	hook, err := s.Register(Webhook{URL: srv.URL, SessionID: "s1"})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if hook.Secret == "" {
+		t.Fatal("expected generated secret")
+	}
+
+	d.Publish("s1", &broker.Message{ID: 1, Text: "hello"})
+
+	deadline := time.After(2 * time.Second)
+	for len(dl.Letters()) == 0 {
+		select {
+		case <-deadline:
+			t.Fatal("expected dead letter")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	letter := dl.Letters()[0]
+	if letter.WebhookID != hook.ID || letter.Attempts != 3 {
+		t.Fatalf("unexpected dead letter: %+v", letter)
+	}
+}
+
+func TestService_RejectsInternalAddress(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer srv.Close()
+
+	d := broker.NewDispatcher()
+	dl := NewMemoryDeadLetterStore()
+	s := NewService(d, nil, dl, zap.NewNop(), Options{MaxAttempts: 3})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.Run(ctx)
+	t.Cleanup(func() {
+		cancel()
+		s.Close()
+	})
+	waitForRun(t, d)
+
+	if _, err := s.Register(Webhook{URL: srv.URL, SessionID: "s1"}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	d.Publish("s1", &broker.Message{ID: 1, Text: "hello"})
+
+	deadline := time.After(2 * time.Second)
+	for len(dl.Letters()) == 0 {
+		select {
+		case <-deadline:
+			t.Fatal("expected dead letter")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	// rejected without retries, the endpoint is never reached
+	letter := dl.Letters()[0]
+	if letter.Attempts != 1 || !strings.Contains(letter.LastError, ErrAddressNotAllowed.Error()) || calls.Load() != 0 {
+		t.Fatalf("expected the loopback address to be rejected, got %+v", letter)
+	}
+}
+
+func TestCheckAddress(t *testing.T) {
+	allowed := []netip.Prefix{netip.MustParsePrefix("10.1.0.0/16")}
+
+	for address, ok := range map[string]bool{
+		"93.184.216.34:443":       true,
+		"[2606:2800:220:1::]:443": true,
+		"10.1.2.3:80":             true,
+		"127.0.0.1:80":            false,
+		"[::1]:80":                false,
+		"[::ffff:127.0.0.1]:80":   false,
+		"169.254.169.254:80":      false,
+		"[fe80::1%eth0]:80":       false,
+		"10.2.0.1:80":             false,
+		"172.16.0.1:80":           false,
+		"192.168.1.1:80":          false,
+		"[fd00::1]:80":            false,
+		"100.64.0.1:80":           false,
+		"0.0.0.0:80":              false,
+	} {
+		err := checkAddress(address, allowed)
+		if ok && err != nil {
+			t.Errorf("%s: expected to be allowed, got %v", address, err)
+		}
+		if !ok && !errors.Is(err, ErrAddressNotAllowed) {
+			t.Errorf("%s: expected ErrAddressNotAllowed, got %v", address, err)
+		}
+	}
+}
+
+func TestService_OverflowIsDeadLettered(t *testing.T) {
+	release := make(chan struct{})
+	var delivered atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		delivered.Add(1)
+	}))
+	defer srv.Close()
+
+	d := broker.NewDispatcher()
+	dl := NewMemoryDeadLetterStore()
+	s := NewService(d, nil, dl, zap.NewNop(), Options{QueueSize: 1, AllowedNetworks: testNetworks})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.Run(ctx)
+	t.Cleanup(func() {
+		cancel()
+		s.Close()
+	})
+	waitForRun(t, d)
+
+	if _, err := s.Register(Webhook{URL: srv.URL, SessionID: "s1"}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	// far more than the queue and a SubscribeAll channel hold
+	const n = 200
+	for i := range n {
+		d.Publish("s1", &broker.Message{ID: int64(i)})
+	}
+	close(release)
+
+	deadline := time.After(2 * time.Second)
+	for int(delivered.Load())+len(dl.Letters()) != n {
+		select {
+		case <-deadline:
+			t.Fatalf("expected every event to be delivered or dead-lettered, got %d delivered, %d dead letters",
+				delivered.Load(), len(dl.Letters()))
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestService_RegisterValidation(t *testing.T) {
+	s := NewService(broker.NewDispatcher(), nil, nil, zap.NewNop(), Options{})
+	defer s.Close()
+
+	if _, err := s.Register(Webhook{URL: "ftp://example.com", SessionID: "s1"}); err == nil {
+		t.Fatal("expected error for non-http url")
+	}
+	if _, err := s.Register(Webhook{URL: "https://example.com"}); err == nil {
+		t.Fatal("expected error without selector")
+	}
+}
+
+func TestService_Configure(t *testing.T) {
+	s := NewService(broker.NewDispatcher(), nil, nil, zap.NewNop(), Options{})
+	defer s.Close()
+
+	registered, err := s.Register(Webhook{URL: "https://example.com/rpc", SessionID: "s1"})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	crm := Webhook{ID: "crm", URL: "https://example.com/crm", LabelSelector: map[string]string{"team": "sales"}}
+	if err := s.Configure([]Webhook{crm, {ID: "audit", URL: "https://example.com/audit", SessionID: "s2"}}); err != nil {
+		t.Fatalf("configure: %v", err)
+	}
+	if got := len(s.List()); got != 3 {
+		t.Fatalf("expected 3 webhooks, got %d", got)
+	}
+	hook, err := s.Get("crm")
+	if err != nil || hook.Secret == "" {
+		t.Fatalf("expected the configured webhook with a generated secret, got %+v, %v", hook, err)
+	}
+	secret := hook.Secret
+
+	// unchanged webhooks are kept, removed ones are deleted
+	if err := s.Configure([]Webhook{crm}); err != nil {
+		t.Fatalf("configure: %v", err)
+	}
+	if hook, _ := s.Get("crm"); hook == nil || hook.Secret != secret {
+		t.Fatalf("expected the unchanged webhook to keep its secret, got %+v", hook)
+	}
+	if _, err := s.Get("audit"); err != ErrWebhookNotFound {
+		t.Fatalf("expected the removed webhook to be deleted, got %v", err)
+	}
+	if _, err := s.Get(registered.ID); err != nil {
+		t.Fatalf("expected the registered webhook to be kept, got %v", err)
+	}
+
+	// invalid configurations are rejected as a whole
+	if err := s.Configure([]Webhook{{URL: "https://example.com", SessionID: "s1"}}); err == nil {
+		t.Fatal("expected error without an id")
+	}
+	if err := s.Configure([]Webhook{crm, crm}); err == nil {
+		t.Fatal("expected error for duplicate ids")
+	}
+	if err := s.Configure([]Webhook{{ID: registered.ID, URL: "https://example.com", SessionID: "s1"}}); err == nil {
+		t.Fatal("expected error for the id of a registered webhook")
+	}
+	if _, err := s.Get("crm"); err != nil {
+		t.Fatalf("expected the failed configuration to keep the webhooks, got %v", err)
+	}
+}
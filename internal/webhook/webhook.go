@@ -0,0 +1,136 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"sort"
+	"sync"
+
+	"github.com/oklog/ulid/v2"
+)
+
+var (
+	ErrWebhookNotFound = errors.New("webhook not found")
+	ErrInvalidWebhook  = errors.New("invalid webhook")
+)
+
+// Webhook is an HTTP endpoint receiving events of the selected sessions.
+type Webhook struct {
+	ID     string
+	URL    string
+	Secret string
+
+	// SessionID selects a single session. When empty, LabelSelector
+	// selects every session carrying all of its labels.
+	SessionID     string
+	LabelSelector map[string]string
+
+	// Tenant that registered the webhook, empty for administrators.
+	Tenant string
+}
+
+func (w *Webhook) matches(sessionID string, labels map[string]string) bool {
+	if w.SessionID != "" {
+		return w.SessionID == sessionID
+	}
+
+	for k, v := range w.LabelSelector {
+		if got, ok := labels[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks the URL and the session selection of w.
+func Validate(w *Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidWebhook)
+	}
+
+	if w.SessionID == "" && len(w.LabelSelector) == 0 {
+		return fmt.Errorf("%w: session_id or label_selector is required", ErrInvalidWebhook)
+	}
+
+	if w.SessionID != "" && len(w.LabelSelector) > 0 {
+		return fmt.Errorf("%w: session_id and label_selector are mutually exclusive", ErrInvalidWebhook)
+	}
+
+	return nil
+}
+
+// equal reports whether a and b deliver the same events to the same
+// endpoint.
+func equal(a, b Webhook) bool {
+	return a.ID == b.ID && a.URL == b.URL && a.Secret == b.Secret &&
+		a.SessionID == b.SessionID && a.Tenant == b.Tenant &&
+		maps.Equal(a.LabelSelector, b.LabelSelector)
+}
+
+type registry struct {
+	mu    sync.RWMutex
+	hooks map[string]*Webhook
+}
+
+func newRegistry() *registry {
+	return &registry{
+		hooks: make(map[string]*Webhook),
+	}
+}
+
+func (r *registry) add(w *Webhook) {
+	r.mu.Lock()
+	r.hooks[w.ID] = w
+	r.mu.Unlock()
+}
+
+func (r *registry) remove(id string) (*Webhook, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.hooks[id]
+	if ok {
+		delete(r.hooks, id)
+	}
+	return w, ok
+}
+
+func (r *registry) get(id string) (*Webhook, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	w, ok := r.hooks[id]
+	return w, ok
+}
+
+func (r *registry) list() []*Webhook {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	hooks := make([]*Webhook, 0, len(r.hooks))
+	for _, w := range r.hooks {
+		hooks = append(hooks, w)
+	}
+
+	sort.Slice(hooks, func(i, j int) bool {
+		return hooks[i].ID < hooks[j].ID
+	})
+	return hooks
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func generateID() string {
+	return ulid.Make().String()
+}
@@ -21,8 +21,175 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type DeleteMode int32
+
+const (
+	// Same as DELETE_MODE_LOGOUT.
+	DeleteMode_DELETE_MODE_UNSPECIFIED DeleteMode = 0
+	// Log out on Telegram and wipe the stored session.
+	DeleteMode_DELETE_MODE_LOGOUT DeleteMode = 1
+	// Stop the connection but keep the stored auth key for ResumeSession.
+	DeleteMode_DELETE_MODE_DETACH DeleteMode = 2
+	// Wipe the stored session without contacting Telegram.
+	DeleteMode_DELETE_MODE_PURGE_LOCAL DeleteMode = 3
+)
+
+// Enum value maps for DeleteMode.
+var (
+	DeleteMode_name = map[int32]string{
+		0: "DELETE_MODE_UNSPECIFIED",
+		1: "DELETE_MODE_LOGOUT",
+		2: "DELETE_MODE_DETACH",
+		3: "DELETE_MODE_PURGE_LOCAL",
+	}
+	DeleteMode_value = map[string]int32{
+		"DELETE_MODE_UNSPECIFIED": 0,
+		"DELETE_MODE_LOGOUT":      1,
+		"DELETE_MODE_DETACH":      2,
+		"DELETE_MODE_PURGE_LOCAL": 3,
+	}
+)
+
+func (x DeleteMode) Enum() *DeleteMode {
+	p := new(DeleteMode)
+	*p = x
+	return p
+}
+
+func (x DeleteMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DeleteMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_telegram_proto_enumTypes[0].Descriptor()
+}
+
+func (DeleteMode) Type() protoreflect.EnumType {
+	return &file_proto_telegram_proto_enumTypes[0]
+}
+
+func (x DeleteMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DeleteMode.Descriptor instead.
+func (DeleteMode) EnumDescriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{0}
+}
+
+type SendJobStatus int32
+
+const (
+	SendJobStatus_SEND_JOB_STATUS_UNSPECIFIED SendJobStatus = 0
+	SendJobStatus_SEND_JOB_STATUS_QUEUED      SendJobStatus = 1
+	SendJobStatus_SEND_JOB_STATUS_SENDING     SendJobStatus = 2
+	SendJobStatus_SEND_JOB_STATUS_SENT        SendJobStatus = 3
+	SendJobStatus_SEND_JOB_STATUS_FAILED      SendJobStatus = 4
+)
+
+// Enum value maps for SendJobStatus.
+var (
+	SendJobStatus_name = map[int32]string{
+		0: "SEND_JOB_STATUS_UNSPECIFIED",
+		1: "SEND_JOB_STATUS_QUEUED",
+		2: "SEND_JOB_STATUS_SENDING",
+		3: "SEND_JOB_STATUS_SENT",
+		4: "SEND_JOB_STATUS_FAILED",
+	}
+	SendJobStatus_value = map[string]int32{
+		"SEND_JOB_STATUS_UNSPECIFIED": 0,
+		"SEND_JOB_STATUS_QUEUED":      1,
+		"SEND_JOB_STATUS_SENDING":     2,
+		"SEND_JOB_STATUS_SENT":        3,
+		"SEND_JOB_STATUS_FAILED":      4,
+	}
+)
+
+func (x SendJobStatus) Enum() *SendJobStatus {
+	p := new(SendJobStatus)
+	*p = x
+	return p
+}
+
+func (x SendJobStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SendJobStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_telegram_proto_enumTypes[1].Descriptor()
+}
+
+func (SendJobStatus) Type() protoreflect.EnumType {
+	return &file_proto_telegram_proto_enumTypes[1]
+}
+
+func (x SendJobStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SendJobStatus.Descriptor instead.
+func (SendJobStatus) EnumDescriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{1}
+}
+
+type SessionState int32
+
+const (
+	SessionState_SESSION_STATE_UNSPECIFIED SessionState = 0
+	SessionState_SESSION_STATE_PENDING     SessionState = 1 // waiting for the QR login
+	SessionState_SESSION_STATE_AUTHORIZED  SessionState = 2
+	SessionState_SESSION_STATE_LOGGED_OUT  SessionState = 3 // authorization revoked remotely
+	SessionState_SESSION_STATE_FAILED      SessionState = 4 // the client kept failing and is not restarted
+)
+
+// Enum value maps for SessionState.
+var (
+	SessionState_name = map[int32]string{
+		0: "SESSION_STATE_UNSPECIFIED",
+		1: "SESSION_STATE_PENDING",
+		2: "SESSION_STATE_AUTHORIZED",
+		3: "SESSION_STATE_LOGGED_OUT",
+		4: "SESSION_STATE_FAILED",
+	}
+	SessionState_value = map[string]int32{
+		"SESSION_STATE_UNSPECIFIED": 0,
+		"SESSION_STATE_PENDING":     1,
+		"SESSION_STATE_AUTHORIZED":  2,
+		"SESSION_STATE_LOGGED_OUT":  3,
+		"SESSION_STATE_FAILED":      4,
+	}
+)
+
+func (x SessionState) Enum() *SessionState {
+	p := new(SessionState)
+	*p = x
+	return p
+}
+
+func (x SessionState) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SessionState) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_telegram_proto_enumTypes[2].Descriptor()
+}
+
+func (SessionState) Type() protoreflect.EnumType {
+	return &file_proto_telegram_proto_enumTypes[2]
+}
+
+func (x SessionState) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SessionState.Descriptor instead.
+func (SessionState) EnumDescriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{2}
+}
+
 type CreateSessionRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	Labels        map[string]string      `protobuf:"bytes,1,rep,name=labels" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -57,6 +224,13 @@ func (*CreateSessionRequest) Descriptor() ([]byte, []int) {
 	return file_proto_telegram_proto_rawDescGZIP(), []int{0}
 }
 
+func (x *CreateSessionRequest) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
 type CreateSessionResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	SessionId     *string                `protobuf:"bytes,1,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
@@ -112,6 +286,7 @@ func (x *CreateSessionResponse) GetQrCode() string {
 type DeleteSessionRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	SessionId     *string                `protobuf:"bytes,1,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
+	Mode          *DeleteMode            `protobuf:"varint,2,opt,name=mode,enum=pact.telegram.DeleteMode" json:"mode,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -153,6 +328,13 @@ func (x *DeleteSessionRequest) GetSessionId() string {
 	return ""
 }
 
+func (x *DeleteSessionRequest) GetMode() DeleteMode {
+	if x != nil && x.Mode != nil {
+		return *x.Mode
+	}
+	return DeleteMode_DELETE_MODE_UNSPECIFIED
+}
+
 type DeleteSessionResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
@@ -189,29 +371,27 @@ func (*DeleteSessionResponse) Descriptor() ([]byte, []int) {
 	return file_proto_telegram_proto_rawDescGZIP(), []int{3}
 }
 
-type SendMessageRequest struct {
+type ResumeSessionRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	SessionId     *string                `protobuf:"bytes,1,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
-	Peer          *string                `protobuf:"bytes,2,opt,name=peer" json:"peer,omitempty"` // e.g. @durov
-	Text          *string                `protobuf:"bytes,3,opt,name=text" json:"text,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SendMessageRequest) Reset() {
-	*x = SendMessageRequest{}
+func (x *ResumeSessionRequest) Reset() {
+	*x = ResumeSessionRequest{}
 	mi := &file_proto_telegram_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SendMessageRequest) String() string {
+func (x *ResumeSessionRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SendMessageRequest) ProtoMessage() {}
+func (*ResumeSessionRequest) ProtoMessage() {}
 
-func (x *SendMessageRequest) ProtoReflect() protoreflect.Message {
+func (x *ResumeSessionRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_telegram_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -223,53 +403,39 @@ func (x *SendMessageRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SendMessageRequest.ProtoReflect.Descriptor instead.
-func (*SendMessageRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use ResumeSessionRequest.ProtoReflect.Descriptor instead.
+func (*ResumeSessionRequest) Descriptor() ([]byte, []int) {
 	return file_proto_telegram_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *SendMessageRequest) GetSessionId() string {
+func (x *ResumeSessionRequest) GetSessionId() string {
 	if x != nil && x.SessionId != nil {
 		return *x.SessionId
 	}
 	return ""
 }
 
-func (x *SendMessageRequest) GetPeer() string {
-	if x != nil && x.Peer != nil {
-		return *x.Peer
-	}
-	return ""
-}
-
-func (x *SendMessageRequest) GetText() string {
-	if x != nil && x.Text != nil {
-		return *x.Text
-	}
-	return ""
-}
-
-type SendMessageResponse struct {
+type ResumeSessionResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	MessageId     *int64                 `protobuf:"varint,1,opt,name=message_id,json=messageId" json:"message_id,omitempty"`
+	State         *SessionState          `protobuf:"varint,1,opt,name=state,enum=pact.telegram.SessionState" json:"state,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SendMessageResponse) Reset() {
-	*x = SendMessageResponse{}
+func (x *ResumeSessionResponse) Reset() {
+	*x = ResumeSessionResponse{}
 	mi := &file_proto_telegram_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SendMessageResponse) String() string {
+func (x *ResumeSessionResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SendMessageResponse) ProtoMessage() {}
+func (*ResumeSessionResponse) ProtoMessage() {}
 
-func (x *SendMessageResponse) ProtoReflect() protoreflect.Message {
+func (x *ResumeSessionResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_telegram_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -281,39 +447,42 @@ func (x *SendMessageResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SendMessageResponse.ProtoReflect.Descriptor instead.
-func (*SendMessageResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use ResumeSessionResponse.ProtoReflect.Descriptor instead.
+func (*ResumeSessionResponse) Descriptor() ([]byte, []int) {
 	return file_proto_telegram_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *SendMessageResponse) GetMessageId() int64 {
-	if x != nil && x.MessageId != nil {
-		return *x.MessageId
+func (x *ResumeSessionResponse) GetState() SessionState {
+	if x != nil && x.State != nil {
+		return *x.State
 	}
-	return 0
+	return SessionState_SESSION_STATE_UNSPECIFIED
 }
 
-type SubscribeMessagesRequest struct {
+// Completes the QR login of an account with two-step verification,
+// once GetSessionStatus reports password_required.
+type SubmitPasswordRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	SessionId     *string                `protobuf:"bytes,1,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
+	Password      *string                `protobuf:"bytes,2,opt,name=password" json:"password,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SubscribeMessagesRequest) Reset() {
-	*x = SubscribeMessagesRequest{}
+func (x *SubmitPasswordRequest) Reset() {
+	*x = SubmitPasswordRequest{}
 	mi := &file_proto_telegram_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SubscribeMessagesRequest) String() string {
+func (x *SubmitPasswordRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SubscribeMessagesRequest) ProtoMessage() {}
+func (*SubmitPasswordRequest) ProtoMessage() {}
 
-func (x *SubscribeMessagesRequest) ProtoReflect() protoreflect.Message {
+func (x *SubmitPasswordRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_telegram_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -325,42 +494,45 @@ func (x *SubscribeMessagesRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SubscribeMessagesRequest.ProtoReflect.Descriptor instead.
-func (*SubscribeMessagesRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use SubmitPasswordRequest.ProtoReflect.Descriptor instead.
+func (*SubmitPasswordRequest) Descriptor() ([]byte, []int) {
 	return file_proto_telegram_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *SubscribeMessagesRequest) GetSessionId() string {
+func (x *SubmitPasswordRequest) GetSessionId() string {
 	if x != nil && x.SessionId != nil {
 		return *x.SessionId
 	}
 	return ""
 }
 
-type MessageUpdate struct {
+func (x *SubmitPasswordRequest) GetPassword() string {
+	if x != nil && x.Password != nil {
+		return *x.Password
+	}
+	return ""
+}
+
+type SubmitPasswordResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	MessageId     *int64                 `protobuf:"varint,1,opt,name=message_id,json=messageId" json:"message_id,omitempty"`
-	From          *string                `protobuf:"bytes,2,opt,name=from" json:"from,omitempty"`
-	Text          *string                `protobuf:"bytes,3,opt,name=text" json:"text,omitempty"`
-	Timestamp     *int64                 `protobuf:"varint,4,opt,name=timestamp" json:"timestamp,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *MessageUpdate) Reset() {
-	*x = MessageUpdate{}
+func (x *SubmitPasswordResponse) Reset() {
+	*x = SubmitPasswordResponse{}
 	mi := &file_proto_telegram_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *MessageUpdate) String() string {
+func (x *SubmitPasswordResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MessageUpdate) ProtoMessage() {}
+func (*SubmitPasswordResponse) ProtoMessage() {}
 
-func (x *MessageUpdate) ProtoReflect() protoreflect.Message {
+func (x *SubmitPasswordResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_telegram_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -372,61 +544,105 @@ func (x *MessageUpdate) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use MessageUpdate.ProtoReflect.Descriptor instead.
-func (*MessageUpdate) Descriptor() ([]byte, []int) {
+// Deprecated: Use SubmitPasswordResponse.ProtoReflect.Descriptor instead.
+func (*SubmitPasswordResponse) Descriptor() ([]byte, []int) {
 	return file_proto_telegram_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *MessageUpdate) GetMessageId() int64 {
-	if x != nil && x.MessageId != nil {
-		return *x.MessageId
+type SendMessageRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId *string                `protobuf:"bytes,1,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
+	Peer      *string                `protobuf:"bytes,2,opt,name=peer" json:"peer,omitempty"` // e.g. @durov
+	Text      *string                `protobuf:"bytes,3,opt,name=text" json:"text,omitempty"`
+	// Repeating a send with the same key returns the original message_id
+	// instead of sending the message again.
+	IdempotencyKey *string `protobuf:"bytes,4,opt,name=idempotency_key,json=idempotencyKey" json:"idempotency_key,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SendMessageRequest) Reset() {
+	*x = SendMessageRequest{}
+	mi := &file_proto_telegram_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendMessageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendMessageRequest) ProtoMessage() {}
+
+func (x *SendMessageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-func (x *MessageUpdate) GetFrom() string {
-	if x != nil && x.From != nil {
-		return *x.From
+// Deprecated: Use SendMessageRequest.ProtoReflect.Descriptor instead.
+func (*SendMessageRequest) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SendMessageRequest) GetSessionId() string {
+	if x != nil && x.SessionId != nil {
+		return *x.SessionId
 	}
 	return ""
 }
 
-func (x *MessageUpdate) GetText() string {
+func (x *SendMessageRequest) GetPeer() string {
+	if x != nil && x.Peer != nil {
+		return *x.Peer
+	}
+	return ""
+}
+
+func (x *SendMessageRequest) GetText() string {
 	if x != nil && x.Text != nil {
 		return *x.Text
 	}
 	return ""
 }
 
-func (x *MessageUpdate) GetTimestamp() int64 {
-	if x != nil && x.Timestamp != nil {
-		return *x.Timestamp
+func (x *SendMessageRequest) GetIdempotencyKey() string {
+	if x != nil && x.IdempotencyKey != nil {
+		return *x.IdempotencyKey
 	}
-	return 0
+	return ""
 }
 
-type GetSessionStatusRequest struct {
+type SendMessageResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	SessionId     *string                `protobuf:"bytes,1,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
+	MessageId     *int64                 `protobuf:"varint,1,opt,name=message_id,json=messageId" json:"message_id,omitempty"`
+	Peer          *string                `protobuf:"bytes,2,opt,name=peer" json:"peer,omitempty"`  // chat the message was sent to, e.g. user:123
+	Date          *int64                 `protobuf:"varint,3,opt,name=date" json:"date,omitempty"` // server time, unix seconds
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetSessionStatusRequest) Reset() {
-	*x = GetSessionStatusRequest{}
-	mi := &file_proto_telegram_proto_msgTypes[8]
+func (x *SendMessageResponse) Reset() {
+	*x = SendMessageResponse{}
+	mi := &file_proto_telegram_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetSessionStatusRequest) String() string {
+func (x *SendMessageResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetSessionStatusRequest) ProtoMessage() {}
+func (*SendMessageResponse) ProtoMessage() {}
 
-func (x *GetSessionStatusRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_telegram_proto_msgTypes[8]
+func (x *SendMessageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -437,40 +653,58 @@ func (x *GetSessionStatusRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetSessionStatusRequest.ProtoReflect.Descriptor instead.
-func (*GetSessionStatusRequest) Descriptor() ([]byte, []int) {
-	return file_proto_telegram_proto_rawDescGZIP(), []int{8}
+// Deprecated: Use SendMessageResponse.ProtoReflect.Descriptor instead.
+func (*SendMessageResponse) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *GetSessionStatusRequest) GetSessionId() string {
-	if x != nil && x.SessionId != nil {
-		return *x.SessionId
+func (x *SendMessageResponse) GetMessageId() int64 {
+	if x != nil && x.MessageId != nil {
+		return *x.MessageId
+	}
+	return 0
+}
+
+func (x *SendMessageResponse) GetPeer() string {
+	if x != nil && x.Peer != nil {
+		return *x.Peer
 	}
 	return ""
 }
 
-type GetSessionStatusResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Ready         *bool                  `protobuf:"varint,1,opt,name=ready" json:"ready,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *SendMessageResponse) GetDate() int64 {
+	if x != nil && x.Date != nil {
+		return *x.Date
+	}
+	return 0
 }
 
-func (x *GetSessionStatusResponse) Reset() {
-	*x = GetSessionStatusResponse{}
-	mi := &file_proto_telegram_proto_msgTypes[9]
+type EnqueueMessageRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId *string                `protobuf:"bytes,1,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
+	Peer      *string                `protobuf:"bytes,2,opt,name=peer" json:"peer,omitempty"`
+	Text      *string                `protobuf:"bytes,3,opt,name=text" json:"text,omitempty"`
+	// Repeating an enqueue with the same key returns the original job.
+	IdempotencyKey *string `protobuf:"bytes,4,opt,name=idempotency_key,json=idempotencyKey" json:"idempotency_key,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *EnqueueMessageRequest) Reset() {
+	*x = EnqueueMessageRequest{}
+	mi := &file_proto_telegram_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetSessionStatusResponse) String() string {
+func (x *EnqueueMessageRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetSessionStatusResponse) ProtoMessage() {}
+func (*EnqueueMessageRequest) ProtoMessage() {}
 
-func (x *GetSessionStatusResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_telegram_proto_msgTypes[9]
+func (x *EnqueueMessageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -481,60 +715,1735 @@ func (x *GetSessionStatusResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetSessionStatusResponse.ProtoReflect.Descriptor instead.
-func (*GetSessionStatusResponse) Descriptor() ([]byte, []int) {
-	return file_proto_telegram_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use EnqueueMessageRequest.ProtoReflect.Descriptor instead.
+func (*EnqueueMessageRequest) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *GetSessionStatusResponse) GetReady() bool {
-	if x != nil && x.Ready != nil {
-		return *x.Ready
+func (x *EnqueueMessageRequest) GetSessionId() string {
+	if x != nil && x.SessionId != nil {
+		return *x.SessionId
 	}
-	return false
+	return ""
 }
 
-var File_proto_telegram_proto protoreflect.FileDescriptor
+func (x *EnqueueMessageRequest) GetPeer() string {
+	if x != nil && x.Peer != nil {
+		return *x.Peer
+	}
+	return ""
+}
+
+func (x *EnqueueMessageRequest) GetText() string {
+	if x != nil && x.Text != nil {
+		return *x.Text
+	}
+	return ""
+}
+
+func (x *EnqueueMessageRequest) GetIdempotencyKey() string {
+	if x != nil && x.IdempotencyKey != nil {
+		return *x.IdempotencyKey
+	}
+	return ""
+}
+
+type EnqueueMessageResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         *string                `protobuf:"bytes,1,opt,name=job_id,json=jobId" json:"job_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnqueueMessageResponse) Reset() {
+	*x = EnqueueMessageResponse{}
+	mi := &file_proto_telegram_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnqueueMessageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnqueueMessageResponse) ProtoMessage() {}
+
+func (x *EnqueueMessageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnqueueMessageResponse.ProtoReflect.Descriptor instead.
+func (*EnqueueMessageResponse) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *EnqueueMessageResponse) GetJobId() string {
+	if x != nil && x.JobId != nil {
+		return *x.JobId
+	}
+	return ""
+}
+
+type SendJob struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	JobId     *string                `protobuf:"bytes,1,opt,name=job_id,json=jobId" json:"job_id,omitempty"`
+	SessionId *string                `protobuf:"bytes,2,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
+	Peer      *string                `protobuf:"bytes,3,opt,name=peer" json:"peer,omitempty"`
+	Status    *SendJobStatus         `protobuf:"varint,4,opt,name=status,enum=pact.telegram.SendJobStatus" json:"status,omitempty"`
+	// Set once the job is sent.
+	MessageId *int64  `protobuf:"varint,5,opt,name=message_id,json=messageId" json:"message_id,omitempty"`
+	Attempts  *int32  `protobuf:"varint,6,opt,name=attempts" json:"attempts,omitempty"`
+	LastError *string `protobuf:"bytes,7,opt,name=last_error,json=lastError" json:"last_error,omitempty"`
+	// Unix time of the next retry, 0 when none is scheduled.
+	NextAttemptAt *int64 `protobuf:"varint,8,opt,name=next_attempt_at,json=nextAttemptAt" json:"next_attempt_at,omitempty"`
+	CreatedAt     *int64 `protobuf:"varint,9,opt,name=created_at,json=createdAt" json:"created_at,omitempty"`
+	UpdatedAt     *int64 `protobuf:"varint,10,opt,name=updated_at,json=updatedAt" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendJob) Reset() {
+	*x = SendJob{}
+	mi := &file_proto_telegram_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendJob) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendJob) ProtoMessage() {}
+
+func (x *SendJob) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendJob.ProtoReflect.Descriptor instead.
+func (*SendJob) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SendJob) GetJobId() string {
+	if x != nil && x.JobId != nil {
+		return *x.JobId
+	}
+	return ""
+}
+
+func (x *SendJob) GetSessionId() string {
+	if x != nil && x.SessionId != nil {
+		return *x.SessionId
+	}
+	return ""
+}
+
+func (x *SendJob) GetPeer() string {
+	if x != nil && x.Peer != nil {
+		return *x.Peer
+	}
+	return ""
+}
+
+func (x *SendJob) GetStatus() SendJobStatus {
+	if x != nil && x.Status != nil {
+		return *x.Status
+	}
+	return SendJobStatus_SEND_JOB_STATUS_UNSPECIFIED
+}
+
+func (x *SendJob) GetMessageId() int64 {
+	if x != nil && x.MessageId != nil {
+		return *x.MessageId
+	}
+	return 0
+}
+
+func (x *SendJob) GetAttempts() int32 {
+	if x != nil && x.Attempts != nil {
+		return *x.Attempts
+	}
+	return 0
+}
+
+func (x *SendJob) GetLastError() string {
+	if x != nil && x.LastError != nil {
+		return *x.LastError
+	}
+	return ""
+}
+
+func (x *SendJob) GetNextAttemptAt() int64 {
+	if x != nil && x.NextAttemptAt != nil {
+		return *x.NextAttemptAt
+	}
+	return 0
+}
+
+func (x *SendJob) GetCreatedAt() int64 {
+	if x != nil && x.CreatedAt != nil {
+		return *x.CreatedAt
+	}
+	return 0
+}
+
+func (x *SendJob) GetUpdatedAt() int64 {
+	if x != nil && x.UpdatedAt != nil {
+		return *x.UpdatedAt
+	}
+	return 0
+}
+
+type GetSendStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     *string                `protobuf:"bytes,1,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
+	JobId         *string                `protobuf:"bytes,2,opt,name=job_id,json=jobId" json:"job_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSendStatusRequest) Reset() {
+	*x = GetSendStatusRequest{}
+	mi := &file_proto_telegram_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSendStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSendStatusRequest) ProtoMessage() {}
+
+func (x *GetSendStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSendStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetSendStatusRequest) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetSendStatusRequest) GetSessionId() string {
+	if x != nil && x.SessionId != nil {
+		return *x.SessionId
+	}
+	return ""
+}
+
+func (x *GetSendStatusRequest) GetJobId() string {
+	if x != nil && x.JobId != nil {
+		return *x.JobId
+	}
+	return ""
+}
+
+type GetSendStatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Job           *SendJob               `protobuf:"bytes,1,opt,name=job" json:"job,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSendStatusResponse) Reset() {
+	*x = GetSendStatusResponse{}
+	mi := &file_proto_telegram_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSendStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSendStatusResponse) ProtoMessage() {}
+
+func (x *GetSendStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSendStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetSendStatusResponse) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetSendStatusResponse) GetJob() *SendJob {
+	if x != nil {
+		return x.Job
+	}
+	return nil
+}
+
+type SubscribeDeliveriesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     *string                `protobuf:"bytes,1,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeDeliveriesRequest) Reset() {
+	*x = SubscribeDeliveriesRequest{}
+	mi := &file_proto_telegram_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeDeliveriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeDeliveriesRequest) ProtoMessage() {}
+
+func (x *SubscribeDeliveriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeDeliveriesRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeDeliveriesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *SubscribeDeliveriesRequest) GetSessionId() string {
+	if x != nil && x.SessionId != nil {
+		return *x.SessionId
+	}
+	return ""
+}
+
+type SubscribeMessagesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     *string                `protobuf:"bytes,1,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeMessagesRequest) Reset() {
+	*x = SubscribeMessagesRequest{}
+	mi := &file_proto_telegram_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeMessagesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeMessagesRequest) ProtoMessage() {}
+
+func (x *SubscribeMessagesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeMessagesRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeMessagesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *SubscribeMessagesRequest) GetSessionId() string {
+	if x != nil && x.SessionId != nil {
+		return *x.SessionId
+	}
+	return ""
+}
+
+type SubscribeAllRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Sessions to subscribe to. Empty means every session.
+	SessionIds []string `protobuf:"bytes,1,rep,name=session_ids,json=sessionIds" json:"session_ids,omitempty"`
+	// Only sessions carrying all of these labels are delivered.
+	LabelSelector map[string]string `protobuf:"bytes,2,rep,name=label_selector,json=labelSelector" json:"label_selector,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeAllRequest) Reset() {
+	*x = SubscribeAllRequest{}
+	mi := &file_proto_telegram_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeAllRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeAllRequest) ProtoMessage() {}
+
+func (x *SubscribeAllRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeAllRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeAllRequest) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *SubscribeAllRequest) GetSessionIds() []string {
+	if x != nil {
+		return x.SessionIds
+	}
+	return nil
+}
+
+func (x *SubscribeAllRequest) GetLabelSelector() map[string]string {
+	if x != nil {
+		return x.LabelSelector
+	}
+	return nil
+}
+
+type MessageUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MessageId     *int64                 `protobuf:"varint,1,opt,name=message_id,json=messageId" json:"message_id,omitempty"`
+	From          *string                `protobuf:"bytes,2,opt,name=from" json:"from,omitempty"`
+	Text          *string                `protobuf:"bytes,3,opt,name=text" json:"text,omitempty"`
+	Timestamp     *int64                 `protobuf:"varint,4,opt,name=timestamp" json:"timestamp,omitempty"`
+	SessionId     *string                `protobuf:"bytes,5,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MessageUpdate) Reset() {
+	*x = MessageUpdate{}
+	mi := &file_proto_telegram_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageUpdate) ProtoMessage() {}
+
+func (x *MessageUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageUpdate.ProtoReflect.Descriptor instead.
+func (*MessageUpdate) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *MessageUpdate) GetMessageId() int64 {
+	if x != nil && x.MessageId != nil {
+		return *x.MessageId
+	}
+	return 0
+}
+
+func (x *MessageUpdate) GetFrom() string {
+	if x != nil && x.From != nil {
+		return *x.From
+	}
+	return ""
+}
+
+func (x *MessageUpdate) GetText() string {
+	if x != nil && x.Text != nil {
+		return *x.Text
+	}
+	return ""
+}
+
+func (x *MessageUpdate) GetTimestamp() int64 {
+	if x != nil && x.Timestamp != nil {
+		return *x.Timestamp
+	}
+	return 0
+}
+
+func (x *MessageUpdate) GetSessionId() string {
+	if x != nil && x.SessionId != nil {
+		return *x.SessionId
+	}
+	return ""
+}
+
+type GetHistoryRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId *string                `protobuf:"bytes,1,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
+	Peer      *string                `protobuf:"bytes,2,opt,name=peer" json:"peer,omitempty"`
+	// Only messages older than this one. 0 starts with the newest.
+	OffsetId *int64 `protobuf:"varint,3,opt,name=offset_id,json=offsetId" json:"offset_id,omitempty"`
+	// At most this many messages, up to 100. 0 means 50.
+	Limit         *int32 `protobuf:"varint,4,opt,name=limit" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetHistoryRequest) Reset() {
+	*x = GetHistoryRequest{}
+	mi := &file_proto_telegram_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetHistoryRequest) ProtoMessage() {}
+
+func (x *GetHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetHistoryRequest.ProtoReflect.Descriptor instead.
+func (*GetHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *GetHistoryRequest) GetSessionId() string {
+	if x != nil && x.SessionId != nil {
+		return *x.SessionId
+	}
+	return ""
+}
+
+func (x *GetHistoryRequest) GetPeer() string {
+	if x != nil && x.Peer != nil {
+		return *x.Peer
+	}
+	return ""
+}
+
+func (x *GetHistoryRequest) GetOffsetId() int64 {
+	if x != nil && x.OffsetId != nil {
+		return *x.OffsetId
+	}
+	return 0
+}
+
+func (x *GetHistoryRequest) GetLimit() int32 {
+	if x != nil && x.Limit != nil {
+		return *x.Limit
+	}
+	return 0
+}
+
+type HistoryMessage struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	MessageId *int64                 `protobuf:"varint,1,opt,name=message_id,json=messageId" json:"message_id,omitempty"`
+	// Sender, empty for outgoing messages of private chats.
+	From          *string `protobuf:"bytes,2,opt,name=from" json:"from,omitempty"`
+	Text          *string `protobuf:"bytes,3,opt,name=text" json:"text,omitempty"`
+	Date          *int64  `protobuf:"varint,4,opt,name=date" json:"date,omitempty"` // unix seconds
+	Out           *bool   `protobuf:"varint,5,opt,name=out" json:"out,omitempty"`   // sent by the session's account
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryMessage) Reset() {
+	*x = HistoryMessage{}
+	mi := &file_proto_telegram_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryMessage) ProtoMessage() {}
+
+func (x *HistoryMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryMessage.ProtoReflect.Descriptor instead.
+func (*HistoryMessage) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *HistoryMessage) GetMessageId() int64 {
+	if x != nil && x.MessageId != nil {
+		return *x.MessageId
+	}
+	return 0
+}
+
+func (x *HistoryMessage) GetFrom() string {
+	if x != nil && x.From != nil {
+		return *x.From
+	}
+	return ""
+}
+
+func (x *HistoryMessage) GetText() string {
+	if x != nil && x.Text != nil {
+		return *x.Text
+	}
+	return ""
+}
+
+func (x *HistoryMessage) GetDate() int64 {
+	if x != nil && x.Date != nil {
+		return *x.Date
+	}
+	return 0
+}
+
+func (x *HistoryMessage) GetOut() bool {
+	if x != nil && x.Out != nil {
+		return *x.Out
+	}
+	return false
+}
+
+type GetHistoryResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Newest first. Pass the last message_id as offset_id for the next page.
+	Messages      []*HistoryMessage `protobuf:"bytes,1,rep,name=messages" json:"messages,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetHistoryResponse) Reset() {
+	*x = GetHistoryResponse{}
+	mi := &file_proto_telegram_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetHistoryResponse) ProtoMessage() {}
+
+func (x *GetHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetHistoryResponse.ProtoReflect.Descriptor instead.
+func (*GetHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *GetHistoryResponse) GetMessages() []*HistoryMessage {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+type GetSessionStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     *string                `protobuf:"bytes,1,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSessionStatusRequest) Reset() {
+	*x = GetSessionStatusRequest{}
+	mi := &file_proto_telegram_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSessionStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSessionStatusRequest) ProtoMessage() {}
+
+func (x *GetSessionStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSessionStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetSessionStatusRequest) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *GetSessionStatusRequest) GetSessionId() string {
+	if x != nil && x.SessionId != nil {
+		return *x.SessionId
+	}
+	return ""
+}
+
+type GetSessionStatusResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Ready *bool                  `protobuf:"varint,1,opt,name=ready" json:"ready,omitempty"`
+	State *SessionState          `protobuf:"varint,2,opt,name=state,enum=pact.telegram.SessionState" json:"state,omitempty"`
+	// Telegram error that revoked the session, e.g. AUTH_KEY_UNREGISTERED.
+	LogoutReason *string `protobuf:"bytes,3,opt,name=logout_reason,json=logoutReason" json:"logout_reason,omitempty"`
+	Connected    *bool   `protobuf:"varint,4,opt,name=connected" json:"connected,omitempty"`
+	RestartCount *int32  `protobuf:"varint,5,opt,name=restart_count,json=restartCount" json:"restart_count,omitempty"` // client restarts after failures
+	LastError    *string `protobuf:"bytes,6,opt,name=last_error,json=lastError" json:"last_error,omitempty"`
+	LastErrorAt  *int64  `protobuf:"varint,7,opt,name=last_error_at,json=lastErrorAt" json:"last_error_at,omitempty"`
+	CreatedAt    *int64  `protobuf:"varint,8,opt,name=created_at,json=createdAt" json:"created_at,omitempty"`
+	// Last RPC using the session, for the idle timeout.
+	LastActivityAt *int64 `protobuf:"varint,9,opt,name=last_activity_at,json=lastActivityAt" json:"last_activity_at,omitempty"`
+	// The QR code was scanned, the login waits for SubmitPassword.
+	PasswordRequired *bool `protobuf:"varint,10,opt,name=password_required,json=passwordRequired" json:"password_required,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *GetSessionStatusResponse) Reset() {
+	*x = GetSessionStatusResponse{}
+	mi := &file_proto_telegram_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSessionStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSessionStatusResponse) ProtoMessage() {}
+
+func (x *GetSessionStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSessionStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetSessionStatusResponse) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *GetSessionStatusResponse) GetReady() bool {
+	if x != nil && x.Ready != nil {
+		return *x.Ready
+	}
+	return false
+}
+
+func (x *GetSessionStatusResponse) GetState() SessionState {
+	if x != nil && x.State != nil {
+		return *x.State
+	}
+	return SessionState_SESSION_STATE_UNSPECIFIED
+}
+
+func (x *GetSessionStatusResponse) GetLogoutReason() string {
+	if x != nil && x.LogoutReason != nil {
+		return *x.LogoutReason
+	}
+	return ""
+}
+
+func (x *GetSessionStatusResponse) GetConnected() bool {
+	if x != nil && x.Connected != nil {
+		return *x.Connected
+	}
+	return false
+}
+
+func (x *GetSessionStatusResponse) GetRestartCount() int32 {
+	if x != nil && x.RestartCount != nil {
+		return *x.RestartCount
+	}
+	return 0
+}
+
+func (x *GetSessionStatusResponse) GetLastError() string {
+	if x != nil && x.LastError != nil {
+		return *x.LastError
+	}
+	return ""
+}
+
+func (x *GetSessionStatusResponse) GetLastErrorAt() int64 {
+	if x != nil && x.LastErrorAt != nil {
+		return *x.LastErrorAt
+	}
+	return 0
+}
+
+func (x *GetSessionStatusResponse) GetCreatedAt() int64 {
+	if x != nil && x.CreatedAt != nil {
+		return *x.CreatedAt
+	}
+	return 0
+}
+
+func (x *GetSessionStatusResponse) GetLastActivityAt() int64 {
+	if x != nil && x.LastActivityAt != nil {
+		return *x.LastActivityAt
+	}
+	return 0
+}
+
+func (x *GetSessionStatusResponse) GetPasswordRequired() bool {
+	if x != nil && x.PasswordRequired != nil {
+		return *x.PasswordRequired
+	}
+	return false
+}
+
+// Sessions of the caller's tenant, or of every tenant for keys with
+// the "*" scope.
+type ListSessionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LabelSelector map[string]string      `protobuf:"bytes,1,rep,name=label_selector,json=labelSelector" json:"label_selector,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSessionsRequest) Reset() {
+	*x = ListSessionsRequest{}
+	mi := &file_proto_telegram_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSessionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSessionsRequest) ProtoMessage() {}
+
+func (x *ListSessionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSessionsRequest.ProtoReflect.Descriptor instead.
+func (*ListSessionsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ListSessionsRequest) GetLabelSelector() map[string]string {
+	if x != nil {
+		return x.LabelSelector
+	}
+	return nil
+}
+
+type SessionInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     *string                `protobuf:"bytes,1,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
+	Tenant        *string                `protobuf:"bytes,2,opt,name=tenant" json:"tenant,omitempty"`
+	State         *SessionState          `protobuf:"varint,3,opt,name=state,enum=pact.telegram.SessionState" json:"state,omitempty"`
+	Labels        map[string]string      `protobuf:"bytes,4,rep,name=labels" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Connected     *bool                  `protobuf:"varint,5,opt,name=connected" json:"connected,omitempty"`
+	CreatedAt     *int64                 `protobuf:"varint,6,opt,name=created_at,json=createdAt" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionInfo) Reset() {
+	*x = SessionInfo{}
+	mi := &file_proto_telegram_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionInfo) ProtoMessage() {}
+
+func (x *SessionInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionInfo.ProtoReflect.Descriptor instead.
+func (*SessionInfo) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *SessionInfo) GetSessionId() string {
+	if x != nil && x.SessionId != nil {
+		return *x.SessionId
+	}
+	return ""
+}
+
+func (x *SessionInfo) GetTenant() string {
+	if x != nil && x.Tenant != nil {
+		return *x.Tenant
+	}
+	return ""
+}
+
+func (x *SessionInfo) GetState() SessionState {
+	if x != nil && x.State != nil {
+		return *x.State
+	}
+	return SessionState_SESSION_STATE_UNSPECIFIED
+}
+
+func (x *SessionInfo) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *SessionInfo) GetConnected() bool {
+	if x != nil && x.Connected != nil {
+		return *x.Connected
+	}
+	return false
+}
+
+func (x *SessionInfo) GetCreatedAt() int64 {
+	if x != nil && x.CreatedAt != nil {
+		return *x.CreatedAt
+	}
+	return 0
+}
+
+type ListSessionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sessions      []*SessionInfo         `protobuf:"bytes,1,rep,name=sessions" json:"sessions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSessionsResponse) Reset() {
+	*x = ListSessionsResponse{}
+	mi := &file_proto_telegram_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSessionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSessionsResponse) ProtoMessage() {}
+
+func (x *ListSessionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSessionsResponse.ProtoReflect.Descriptor instead.
+func (*ListSessionsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *ListSessionsResponse) GetSessions() []*SessionInfo {
+	if x != nil {
+		return x.Sessions
+	}
+	return nil
+}
+
+type GetServiceStatusRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Tenant to report usage for. Ignored for callers bound to a tenant,
+	// which always get their own usage.
+	Tenant        *string `protobuf:"bytes,1,opt,name=tenant" json:"tenant,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetServiceStatusRequest) Reset() {
+	*x = GetServiceStatusRequest{}
+	mi := &file_proto_telegram_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetServiceStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetServiceStatusRequest) ProtoMessage() {}
+
+func (x *GetServiceStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetServiceStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetServiceStatusRequest) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *GetServiceStatusRequest) GetTenant() string {
+	if x != nil && x.Tenant != nil {
+		return *x.Tenant
+	}
+	return ""
+}
+
+// Session counts against the limits. A zero limit means no limit.
+type GetServiceStatusResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Sessions          *int32                 `protobuf:"varint,1,opt,name=sessions" json:"sessions,omitempty"`
+	MaxSessions       *int32                 `protobuf:"varint,2,opt,name=max_sessions,json=maxSessions" json:"max_sessions,omitempty"`
+	Tenant            *string                `protobuf:"bytes,3,opt,name=tenant" json:"tenant,omitempty"`
+	TenantSessions    *int32                 `protobuf:"varint,4,opt,name=tenant_sessions,json=tenantSessions" json:"tenant_sessions,omitempty"`
+	MaxTenantSessions *int32                 `protobuf:"varint,5,opt,name=max_tenant_sessions,json=maxTenantSessions" json:"max_tenant_sessions,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *GetServiceStatusResponse) Reset() {
+	*x = GetServiceStatusResponse{}
+	mi := &file_proto_telegram_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetServiceStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetServiceStatusResponse) ProtoMessage() {}
+
+func (x *GetServiceStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetServiceStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetServiceStatusResponse) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *GetServiceStatusResponse) GetSessions() int32 {
+	if x != nil && x.Sessions != nil {
+		return *x.Sessions
+	}
+	return 0
+}
+
+func (x *GetServiceStatusResponse) GetMaxSessions() int32 {
+	if x != nil && x.MaxSessions != nil {
+		return *x.MaxSessions
+	}
+	return 0
+}
+
+func (x *GetServiceStatusResponse) GetTenant() string {
+	if x != nil && x.Tenant != nil {
+		return *x.Tenant
+	}
+	return ""
+}
+
+func (x *GetServiceStatusResponse) GetTenantSessions() int32 {
+	if x != nil && x.TenantSessions != nil {
+		return *x.TenantSessions
+	}
+	return 0
+}
+
+func (x *GetServiceStatusResponse) GetMaxTenantSessions() int32 {
+	if x != nil && x.MaxTenantSessions != nil {
+		return *x.MaxTenantSessions
+	}
+	return 0
+}
+
+type Webhook struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	WebhookId *string                `protobuf:"bytes,1,opt,name=webhook_id,json=webhookId" json:"webhook_id,omitempty"`
+	Url       *string                `protobuf:"bytes,2,opt,name=url" json:"url,omitempty"`
+	// Either session_id or label_selector selects the sessions delivered.
+	SessionId     *string           `protobuf:"bytes,3,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
+	LabelSelector map[string]string `protobuf:"bytes,4,rep,name=label_selector,json=labelSelector" json:"label_selector,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Webhook) Reset() {
+	*x = Webhook{}
+	mi := &file_proto_telegram_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Webhook) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Webhook) ProtoMessage() {}
+
+func (x *Webhook) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Webhook.ProtoReflect.Descriptor instead.
+func (*Webhook) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *Webhook) GetWebhookId() string {
+	if x != nil && x.WebhookId != nil {
+		return *x.WebhookId
+	}
+	return ""
+}
+
+func (x *Webhook) GetUrl() string {
+	if x != nil && x.Url != nil {
+		return *x.Url
+	}
+	return ""
+}
+
+func (x *Webhook) GetSessionId() string {
+	if x != nil && x.SessionId != nil {
+		return *x.SessionId
+	}
+	return ""
+}
+
+func (x *Webhook) GetLabelSelector() map[string]string {
+	if x != nil {
+		return x.LabelSelector
+	}
+	return nil
+}
+
+type RegisterWebhookRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Url           *string                `protobuf:"bytes,1,opt,name=url" json:"url,omitempty"`
+	SessionId     *string                `protobuf:"bytes,2,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
+	LabelSelector map[string]string      `protobuf:"bytes,3,rep,name=label_selector,json=labelSelector" json:"label_selector,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// HMAC-SHA256 secret. Generated when empty.
+	Secret        *string `protobuf:"bytes,4,opt,name=secret" json:"secret,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterWebhookRequest) Reset() {
+	*x = RegisterWebhookRequest{}
+	mi := &file_proto_telegram_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterWebhookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterWebhookRequest) ProtoMessage() {}
+
+func (x *RegisterWebhookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterWebhookRequest.ProtoReflect.Descriptor instead.
+func (*RegisterWebhookRequest) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *RegisterWebhookRequest) GetUrl() string {
+	if x != nil && x.Url != nil {
+		return *x.Url
+	}
+	return ""
+}
+
+func (x *RegisterWebhookRequest) GetSessionId() string {
+	if x != nil && x.SessionId != nil {
+		return *x.SessionId
+	}
+	return ""
+}
+
+func (x *RegisterWebhookRequest) GetLabelSelector() map[string]string {
+	if x != nil {
+		return x.LabelSelector
+	}
+	return nil
+}
+
+func (x *RegisterWebhookRequest) GetSecret() string {
+	if x != nil && x.Secret != nil {
+		return *x.Secret
+	}
+	return ""
+}
+
+type RegisterWebhookResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WebhookId     *string                `protobuf:"bytes,1,opt,name=webhook_id,json=webhookId" json:"webhook_id,omitempty"`
+	Secret        *string                `protobuf:"bytes,2,opt,name=secret" json:"secret,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterWebhookResponse) Reset() {
+	*x = RegisterWebhookResponse{}
+	mi := &file_proto_telegram_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterWebhookResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterWebhookResponse) ProtoMessage() {}
+
+func (x *RegisterWebhookResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterWebhookResponse.ProtoReflect.Descriptor instead.
+func (*RegisterWebhookResponse) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *RegisterWebhookResponse) GetWebhookId() string {
+	if x != nil && x.WebhookId != nil {
+		return *x.WebhookId
+	}
+	return ""
+}
+
+func (x *RegisterWebhookResponse) GetSecret() string {
+	if x != nil && x.Secret != nil {
+		return *x.Secret
+	}
+	return ""
+}
+
+type DeleteWebhookRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WebhookId     *string                `protobuf:"bytes,1,opt,name=webhook_id,json=webhookId" json:"webhook_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteWebhookRequest) Reset() {
+	*x = DeleteWebhookRequest{}
+	mi := &file_proto_telegram_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteWebhookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteWebhookRequest) ProtoMessage() {}
+
+func (x *DeleteWebhookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteWebhookRequest.ProtoReflect.Descriptor instead.
+func (*DeleteWebhookRequest) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *DeleteWebhookRequest) GetWebhookId() string {
+	if x != nil && x.WebhookId != nil {
+		return *x.WebhookId
+	}
+	return ""
+}
+
+type DeleteWebhookResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteWebhookResponse) Reset() {
+	*x = DeleteWebhookResponse{}
+	mi := &file_proto_telegram_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteWebhookResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteWebhookResponse) ProtoMessage() {}
+
+func (x *DeleteWebhookResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteWebhookResponse.ProtoReflect.Descriptor instead.
+func (*DeleteWebhookResponse) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{33}
+}
+
+type ListWebhooksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWebhooksRequest) Reset() {
+	*x = ListWebhooksRequest{}
+	mi := &file_proto_telegram_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWebhooksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWebhooksRequest) ProtoMessage() {}
+
+func (x *ListWebhooksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWebhooksRequest.ProtoReflect.Descriptor instead.
+func (*ListWebhooksRequest) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{34}
+}
+
+type ListWebhooksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Webhooks      []*Webhook             `protobuf:"bytes,1,rep,name=webhooks" json:"webhooks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWebhooksResponse) Reset() {
+	*x = ListWebhooksResponse{}
+	mi := &file_proto_telegram_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWebhooksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWebhooksResponse) ProtoMessage() {}
+
+func (x *ListWebhooksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWebhooksResponse.ProtoReflect.Descriptor instead.
+func (*ListWebhooksResponse) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *ListWebhooksResponse) GetWebhooks() []*Webhook {
+	if x != nil {
+		return x.Webhooks
+	}
+	return nil
+}
+
+var File_proto_telegram_proto protoreflect.FileDescriptor
 
 const file_proto_telegram_proto_rawDesc = "" +
 	"\n" +
-	"\x14proto/telegram.proto\x12\rpact.telegram\"\x16\n" +
-	"\x14CreateSessionRequest\"O\n" +
+	"\x14proto/telegram.proto\x12\rpact.telegram\"\x9a\x01\n" +
+	"\x14CreateSessionRequest\x12G\n" +
+	"\x06labels\x18\x01 \x03(\v2/.pact.telegram.CreateSessionRequest.LabelsEntryR\x06labels\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"O\n" +
 	"\x15CreateSessionResponse\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x17\n" +
-	"\aqr_code\x18\x02 \x01(\tR\x06qrCode\"5\n" +
+	"\aqr_code\x18\x02 \x01(\tR\x06qrCode\"d\n" +
 	"\x14DeleteSessionRequest\x12\x1d\n" +
 	"\n" +
-	"session_id\x18\x01 \x01(\tR\tsessionId\"\x17\n" +
-	"\x15DeleteSessionResponse\"[\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12-\n" +
+	"\x04mode\x18\x02 \x01(\x0e2\x19.pact.telegram.DeleteModeR\x04mode\"\x17\n" +
+	"\x15DeleteSessionResponse\"5\n" +
+	"\x14ResumeSessionRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\"J\n" +
+	"\x15ResumeSessionResponse\x121\n" +
+	"\x05state\x18\x01 \x01(\x0e2\x1b.pact.telegram.SessionStateR\x05state\"R\n" +
+	"\x15SubmitPasswordRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\"\x18\n" +
+	"\x16SubmitPasswordResponse\"\x84\x01\n" +
 	"\x12SendMessageRequest\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x12\n" +
 	"\x04peer\x18\x02 \x01(\tR\x04peer\x12\x12\n" +
-	"\x04text\x18\x03 \x01(\tR\x04text\"4\n" +
+	"\x04text\x18\x03 \x01(\tR\x04text\x12'\n" +
+	"\x0fidempotency_key\x18\x04 \x01(\tR\x0eidempotencyKey\"\\\n" +
 	"\x13SendMessageResponse\x12\x1d\n" +
 	"\n" +
-	"message_id\x18\x01 \x01(\x03R\tmessageId\"9\n" +
+	"message_id\x18\x01 \x01(\x03R\tmessageId\x12\x12\n" +
+	"\x04peer\x18\x02 \x01(\tR\x04peer\x12\x12\n" +
+	"\x04date\x18\x03 \x01(\x03R\x04date\"\x87\x01\n" +
+	"\x15EnqueueMessageRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x12\n" +
+	"\x04peer\x18\x02 \x01(\tR\x04peer\x12\x12\n" +
+	"\x04text\x18\x03 \x01(\tR\x04text\x12'\n" +
+	"\x0fidempotency_key\x18\x04 \x01(\tR\x0eidempotencyKey\"/\n" +
+	"\x16EnqueueMessageResponse\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\"\xc9\x02\n" +
+	"\aSendJob\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x02 \x01(\tR\tsessionId\x12\x12\n" +
+	"\x04peer\x18\x03 \x01(\tR\x04peer\x124\n" +
+	"\x06status\x18\x04 \x01(\x0e2\x1c.pact.telegram.SendJobStatusR\x06status\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x05 \x01(\x03R\tmessageId\x12\x1a\n" +
+	"\battempts\x18\x06 \x01(\x05R\battempts\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\a \x01(\tR\tlastError\x12&\n" +
+	"\x0fnext_attempt_at\x18\b \x01(\x03R\rnextAttemptAt\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\t \x01(\x03R\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\n" +
+	" \x01(\x03R\tupdatedAt\"L\n" +
+	"\x14GetSendStatusRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x15\n" +
+	"\x06job_id\x18\x02 \x01(\tR\x05jobId\"A\n" +
+	"\x15GetSendStatusResponse\x12(\n" +
+	"\x03job\x18\x01 \x01(\v2\x16.pact.telegram.SendJobR\x03job\";\n" +
+	"\x1aSubscribeDeliveriesRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\"9\n" +
 	"\x18SubscribeMessagesRequest\x12\x1d\n" +
 	"\n" +
-	"session_id\x18\x01 \x01(\tR\tsessionId\"t\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\"\xd6\x01\n" +
+	"\x13SubscribeAllRequest\x12\x1f\n" +
+	"\vsession_ids\x18\x01 \x03(\tR\n" +
+	"sessionIds\x12\\\n" +
+	"\x0elabel_selector\x18\x02 \x03(\v25.pact.telegram.SubscribeAllRequest.LabelSelectorEntryR\rlabelSelector\x1a@\n" +
+	"\x12LabelSelectorEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x93\x01\n" +
 	"\rMessageUpdate\x12\x1d\n" +
 	"\n" +
 	"message_id\x18\x01 \x01(\x03R\tmessageId\x12\x12\n" +
 	"\x04from\x18\x02 \x01(\tR\x04from\x12\x12\n" +
 	"\x04text\x18\x03 \x01(\tR\x04text\x12\x1c\n" +
-	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\"8\n" +
+	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x05 \x01(\tR\tsessionId\"y\n" +
+	"\x11GetHistoryRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x12\n" +
+	"\x04peer\x18\x02 \x01(\tR\x04peer\x12\x1b\n" +
+	"\toffset_id\x18\x03 \x01(\x03R\boffsetId\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limit\"}\n" +
+	"\x0eHistoryMessage\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\x03R\tmessageId\x12\x12\n" +
+	"\x04from\x18\x02 \x01(\tR\x04from\x12\x12\n" +
+	"\x04text\x18\x03 \x01(\tR\x04text\x12\x12\n" +
+	"\x04date\x18\x04 \x01(\x03R\x04date\x12\x10\n" +
+	"\x03out\x18\x05 \x01(\bR\x03out\"O\n" +
+	"\x12GetHistoryResponse\x129\n" +
+	"\bmessages\x18\x01 \x03(\v2\x1d.pact.telegram.HistoryMessageR\bmessages\"8\n" +
 	"\x17GetSessionStatusRequest\x12\x1d\n" +
 	"\n" +
-	"session_id\x18\x01 \x01(\tR\tsessionId\"0\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\"\x84\x03\n" +
 	"\x18GetSessionStatusResponse\x12\x14\n" +
-	"\x05ready\x18\x01 \x01(\bR\x05ready2\xe2\x03\n" +
+	"\x05ready\x18\x01 \x01(\bR\x05ready\x121\n" +
+	"\x05state\x18\x02 \x01(\x0e2\x1b.pact.telegram.SessionStateR\x05state\x12#\n" +
+	"\rlogout_reason\x18\x03 \x01(\tR\flogoutReason\x12\x1c\n" +
+	"\tconnected\x18\x04 \x01(\bR\tconnected\x12#\n" +
+	"\rrestart_count\x18\x05 \x01(\x05R\frestartCount\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\x06 \x01(\tR\tlastError\x12\"\n" +
+	"\rlast_error_at\x18\a \x01(\x03R\vlastErrorAt\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\b \x01(\x03R\tcreatedAt\x12(\n" +
+	"\x10last_activity_at\x18\t \x01(\x03R\x0elastActivityAt\x12+\n" +
+	"\x11password_required\x18\n" +
+	" \x01(\bR\x10passwordRequired\"\xb5\x01\n" +
+	"\x13ListSessionsRequest\x12\\\n" +
+	"\x0elabel_selector\x18\x01 \x03(\v25.pact.telegram.ListSessionsRequest.LabelSelectorEntryR\rlabelSelector\x1a@\n" +
+	"\x12LabelSelectorEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xaf\x02\n" +
+	"\vSessionInfo\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x16\n" +
+	"\x06tenant\x18\x02 \x01(\tR\x06tenant\x121\n" +
+	"\x05state\x18\x03 \x01(\x0e2\x1b.pact.telegram.SessionStateR\x05state\x12>\n" +
+	"\x06labels\x18\x04 \x03(\v2&.pact.telegram.SessionInfo.LabelsEntryR\x06labels\x12\x1c\n" +
+	"\tconnected\x18\x05 \x01(\bR\tconnected\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\x03R\tcreatedAt\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"N\n" +
+	"\x14ListSessionsResponse\x126\n" +
+	"\bsessions\x18\x01 \x03(\v2\x1a.pact.telegram.SessionInfoR\bsessions\"1\n" +
+	"\x17GetServiceStatusRequest\x12\x16\n" +
+	"\x06tenant\x18\x01 \x01(\tR\x06tenant\"\xca\x01\n" +
+	"\x18GetServiceStatusResponse\x12\x1a\n" +
+	"\bsessions\x18\x01 \x01(\x05R\bsessions\x12!\n" +
+	"\fmax_sessions\x18\x02 \x01(\x05R\vmaxSessions\x12\x16\n" +
+	"\x06tenant\x18\x03 \x01(\tR\x06tenant\x12'\n" +
+	"\x0ftenant_sessions\x18\x04 \x01(\x05R\x0etenantSessions\x12.\n" +
+	"\x13max_tenant_sessions\x18\x05 \x01(\x05R\x11maxTenantSessions\"\xed\x01\n" +
+	"\aWebhook\x12\x1d\n" +
+	"\n" +
+	"webhook_id\x18\x01 \x01(\tR\twebhookId\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x03 \x01(\tR\tsessionId\x12P\n" +
+	"\x0elabel_selector\x18\x04 \x03(\v2).pact.telegram.Webhook.LabelSelectorEntryR\rlabelSelector\x1a@\n" +
+	"\x12LabelSelectorEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x84\x02\n" +
+	"\x16RegisterWebhookRequest\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x02 \x01(\tR\tsessionId\x12_\n" +
+	"\x0elabel_selector\x18\x03 \x03(\v28.pact.telegram.RegisterWebhookRequest.LabelSelectorEntryR\rlabelSelector\x12\x16\n" +
+	"\x06secret\x18\x04 \x01(\tR\x06secret\x1a@\n" +
+	"\x12LabelSelectorEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"P\n" +
+	"\x17RegisterWebhookResponse\x12\x1d\n" +
+	"\n" +
+	"webhook_id\x18\x01 \x01(\tR\twebhookId\x12\x16\n" +
+	"\x06secret\x18\x02 \x01(\tR\x06secret\"5\n" +
+	"\x14DeleteWebhookRequest\x12\x1d\n" +
+	"\n" +
+	"webhook_id\x18\x01 \x01(\tR\twebhookId\"\x17\n" +
+	"\x15DeleteWebhookResponse\"\x15\n" +
+	"\x13ListWebhooksRequest\"J\n" +
+	"\x14ListWebhooksResponse\x122\n" +
+	"\bwebhooks\x18\x01 \x03(\v2\x16.pact.telegram.WebhookR\bwebhooks*v\n" +
+	"\n" +
+	"DeleteMode\x12\x1b\n" +
+	"\x17DELETE_MODE_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12DELETE_MODE_LOGOUT\x10\x01\x12\x16\n" +
+	"\x12DELETE_MODE_DETACH\x10\x02\x12\x1b\n" +
+	"\x17DELETE_MODE_PURGE_LOCAL\x10\x03*\x9f\x01\n" +
+	"\rSendJobStatus\x12\x1f\n" +
+	"\x1bSEND_JOB_STATUS_UNSPECIFIED\x10\x00\x12\x1a\n" +
+	"\x16SEND_JOB_STATUS_QUEUED\x10\x01\x12\x1b\n" +
+	"\x17SEND_JOB_STATUS_SENDING\x10\x02\x12\x18\n" +
+	"\x14SEND_JOB_STATUS_SENT\x10\x03\x12\x1a\n" +
+	"\x16SEND_JOB_STATUS_FAILED\x10\x04*\x9e\x01\n" +
+	"\fSessionState\x12\x1d\n" +
+	"\x19SESSION_STATE_UNSPECIFIED\x10\x00\x12\x19\n" +
+	"\x15SESSION_STATE_PENDING\x10\x01\x12\x1c\n" +
+	"\x18SESSION_STATE_AUTHORIZED\x10\x02\x12\x1c\n" +
+	"\x18SESSION_STATE_LOGGED_OUT\x10\x03\x12\x18\n" +
+	"\x14SESSION_STATE_FAILED\x10\x042\xb0\f\n" +
 	"\x0fTelegramService\x12Z\n" +
 	"\rCreateSession\x12#.pact.telegram.CreateSessionRequest\x1a$.pact.telegram.CreateSessionResponse\x12Z\n" +
-	"\rDeleteSession\x12#.pact.telegram.DeleteSessionRequest\x1a$.pact.telegram.DeleteSessionResponse\x12T\n" +
-	"\vSendMessage\x12!.pact.telegram.SendMessageRequest\x1a\".pact.telegram.SendMessageResponse\x12\\\n" +
-	"\x11SubscribeMessages\x12'.pact.telegram.SubscribeMessagesRequest\x1a\x1c.pact.telegram.MessageUpdate0\x01\x12c\n" +
-	"\x10GetSessionStatus\x12&.pact.telegram.GetSessionStatusRequest\x1a'.pact.telegram.GetSessionStatusResponseB1Z/github.com/zen-flo/telegram-service/pkg/api;apib\beditionsp\xe8\a"
+	"\rDeleteSession\x12#.pact.telegram.DeleteSessionRequest\x1a$.pact.telegram.DeleteSessionResponse\x12Z\n" +
+	"\rResumeSession\x12#.pact.telegram.ResumeSessionRequest\x1a$.pact.telegram.ResumeSessionResponse\x12]\n" +
+	"\x0eSubmitPassword\x12$.pact.telegram.SubmitPasswordRequest\x1a%.pact.telegram.SubmitPasswordResponse\x12T\n" +
+	"\vSendMessage\x12!.pact.telegram.SendMessageRequest\x1a\".pact.telegram.SendMessageResponse\x12]\n" +
+	"\x0eEnqueueMessage\x12$.pact.telegram.EnqueueMessageRequest\x1a%.pact.telegram.EnqueueMessageResponse\x12Z\n" +
+	"\rGetSendStatus\x12#.pact.telegram.GetSendStatusRequest\x1a$.pact.telegram.GetSendStatusResponse\x12Z\n" +
+	"\x13SubscribeDeliveries\x12).pact.telegram.SubscribeDeliveriesRequest\x1a\x16.pact.telegram.SendJob0\x01\x12\\\n" +
+	"\x11SubscribeMessages\x12'.pact.telegram.SubscribeMessagesRequest\x1a\x1c.pact.telegram.MessageUpdate0\x01\x12R\n" +
+	"\fSubscribeAll\x12\".pact.telegram.SubscribeAllRequest\x1a\x1c.pact.telegram.MessageUpdate0\x01\x12Q\n" +
+	"\n" +
+	"GetHistory\x12 .pact.telegram.GetHistoryRequest\x1a!.pact.telegram.GetHistoryResponse\x12c\n" +
+	"\x10GetSessionStatus\x12&.pact.telegram.GetSessionStatusRequest\x1a'.pact.telegram.GetSessionStatusResponse\x12W\n" +
+	"\fListSessions\x12\".pact.telegram.ListSessionsRequest\x1a#.pact.telegram.ListSessionsResponse\x12c\n" +
+	"\x10GetServiceStatus\x12&.pact.telegram.GetServiceStatusRequest\x1a'.pact.telegram.GetServiceStatusResponse\x12`\n" +
+	"\x0fRegisterWebhook\x12%.pact.telegram.RegisterWebhookRequest\x1a&.pact.telegram.RegisterWebhookResponse\x12Z\n" +
+	"\rDeleteWebhook\x12#.pact.telegram.DeleteWebhookRequest\x1a$.pact.telegram.DeleteWebhookResponse\x12W\n" +
+	"\fListWebhooks\x12\".pact.telegram.ListWebhooksRequest\x1a#.pact.telegram.ListWebhooksResponseB1Z/github.com/zen-flo/telegram-service/pkg/api;apib\beditionsp\xe8\a"
 
 var (
 	file_proto_telegram_proto_rawDescOnce sync.Once
@@ -548,35 +2457,110 @@ func file_proto_telegram_proto_rawDescGZIP() []byte {
 	return file_proto_telegram_proto_rawDescData
 }
 
-var file_proto_telegram_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_proto_telegram_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_proto_telegram_proto_msgTypes = make([]protoimpl.MessageInfo, 42)
 var file_proto_telegram_proto_goTypes = []any{
-	(*CreateSessionRequest)(nil),     // 0: pact.telegram.CreateSessionRequest
-	(*CreateSessionResponse)(nil),    // 1: pact.telegram.CreateSessionResponse
-	(*DeleteSessionRequest)(nil),     // 2: pact.telegram.DeleteSessionRequest
-	(*DeleteSessionResponse)(nil),    // 3: pact.telegram.DeleteSessionResponse
-	(*SendMessageRequest)(nil),       // 4: pact.telegram.SendMessageRequest
-	(*SendMessageResponse)(nil),      // 5: pact.telegram.SendMessageResponse
-	(*SubscribeMessagesRequest)(nil), // 6: pact.telegram.SubscribeMessagesRequest
-	(*MessageUpdate)(nil),            // 7: pact.telegram.MessageUpdate
-	(*GetSessionStatusRequest)(nil),  // 8: pact.telegram.GetSessionStatusRequest
-	(*GetSessionStatusResponse)(nil), // 9: pact.telegram.GetSessionStatusResponse
+	(DeleteMode)(0),                    // 0: pact.telegram.DeleteMode
+	(SendJobStatus)(0),                 // 1: pact.telegram.SendJobStatus
+	(SessionState)(0),                  // 2: pact.telegram.SessionState
+	(*CreateSessionRequest)(nil),       // 3: pact.telegram.CreateSessionRequest
+	(*CreateSessionResponse)(nil),      // 4: pact.telegram.CreateSessionResponse
+	(*DeleteSessionRequest)(nil),       // 5: pact.telegram.DeleteSessionRequest
+	(*DeleteSessionResponse)(nil),      // 6: pact.telegram.DeleteSessionResponse
+	(*ResumeSessionRequest)(nil),       // 7: pact.telegram.ResumeSessionRequest
+	(*ResumeSessionResponse)(nil),      // 8: pact.telegram.ResumeSessionResponse
+	(*SubmitPasswordRequest)(nil),      // 9: pact.telegram.SubmitPasswordRequest
+	(*SubmitPasswordResponse)(nil),     // 10: pact.telegram.SubmitPasswordResponse
+	(*SendMessageRequest)(nil),         // 11: pact.telegram.SendMessageRequest
+	(*SendMessageResponse)(nil),        // 12: pact.telegram.SendMessageResponse
+	(*EnqueueMessageRequest)(nil),      // 13: pact.telegram.EnqueueMessageRequest
+	(*EnqueueMessageResponse)(nil),     // 14: pact.telegram.EnqueueMessageResponse
+	(*SendJob)(nil),                    // 15: pact.telegram.SendJob
+	(*GetSendStatusRequest)(nil),       // 16: pact.telegram.GetSendStatusRequest
+	(*GetSendStatusResponse)(nil),      // 17: pact.telegram.GetSendStatusResponse
+	(*SubscribeDeliveriesRequest)(nil), // 18: pact.telegram.SubscribeDeliveriesRequest
+	(*SubscribeMessagesRequest)(nil),   // 19: pact.telegram.SubscribeMessagesRequest
+	(*SubscribeAllRequest)(nil),        // 20: pact.telegram.SubscribeAllRequest
+	(*MessageUpdate)(nil),              // 21: pact.telegram.MessageUpdate
+	(*GetHistoryRequest)(nil),          // 22: pact.telegram.GetHistoryRequest
+	(*HistoryMessage)(nil),             // 23: pact.telegram.HistoryMessage
+	(*GetHistoryResponse)(nil),         // 24: pact.telegram.GetHistoryResponse
+	(*GetSessionStatusRequest)(nil),    // 25: pact.telegram.GetSessionStatusRequest
+	(*GetSessionStatusResponse)(nil),   // 26: pact.telegram.GetSessionStatusResponse
+	(*ListSessionsRequest)(nil),        // 27: pact.telegram.ListSessionsRequest
+	(*SessionInfo)(nil),                // 28: pact.telegram.SessionInfo
+	(*ListSessionsResponse)(nil),       // 29: pact.telegram.ListSessionsResponse
+	(*GetServiceStatusRequest)(nil),    // 30: pact.telegram.GetServiceStatusRequest
+	(*GetServiceStatusResponse)(nil),   // 31: pact.telegram.GetServiceStatusResponse
+	(*Webhook)(nil),                    // 32: pact.telegram.Webhook
+	(*RegisterWebhookRequest)(nil),     // 33: pact.telegram.RegisterWebhookRequest
+	(*RegisterWebhookResponse)(nil),    // 34: pact.telegram.RegisterWebhookResponse
+	(*DeleteWebhookRequest)(nil),       // 35: pact.telegram.DeleteWebhookRequest
+	(*DeleteWebhookResponse)(nil),      // 36: pact.telegram.DeleteWebhookResponse
+	(*ListWebhooksRequest)(nil),        // 37: pact.telegram.ListWebhooksRequest
+	(*ListWebhooksResponse)(nil),       // 38: pact.telegram.ListWebhooksResponse
+	nil,                                // 39: pact.telegram.CreateSessionRequest.LabelsEntry
+	nil,                                // 40: pact.telegram.SubscribeAllRequest.LabelSelectorEntry
+	nil,                                // 41: pact.telegram.ListSessionsRequest.LabelSelectorEntry
+	nil,                                // 42: pact.telegram.SessionInfo.LabelsEntry
+	nil,                                // 43: pact.telegram.Webhook.LabelSelectorEntry
+	nil,                                // 44: pact.telegram.RegisterWebhookRequest.LabelSelectorEntry
 }
 var file_proto_telegram_proto_depIdxs = []int32{
-	0, // 0: pact.telegram.TelegramService.CreateSession:input_type -> pact.telegram.CreateSessionRequest
-	2, // 1: pact.telegram.TelegramService.DeleteSession:input_type -> pact.telegram.DeleteSessionRequest
-	4, // 2: pact.telegram.TelegramService.SendMessage:input_type -> pact.telegram.SendMessageRequest
-	6, // 3: pact.telegram.TelegramService.SubscribeMessages:input_type -> pact.telegram.SubscribeMessagesRequest
-	8, // 4: pact.telegram.TelegramService.GetSessionStatus:input_type -> pact.telegram.GetSessionStatusRequest
-	1, // 5: pact.telegram.TelegramService.CreateSession:output_type -> pact.telegram.CreateSessionResponse
-	3, // 6: pact.telegram.TelegramService.DeleteSession:output_type -> pact.telegram.DeleteSessionResponse
-	5, // 7: pact.telegram.TelegramService.SendMessage:output_type -> pact.telegram.SendMessageResponse
-	7, // 8: pact.telegram.TelegramService.SubscribeMessages:output_type -> pact.telegram.MessageUpdate
-	9, // 9: pact.telegram.TelegramService.GetSessionStatus:output_type -> pact.telegram.GetSessionStatusResponse
-	5, // [5:10] is the sub-list for method output_type
-	0, // [0:5] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	39, // 0: pact.telegram.CreateSessionRequest.labels:type_name -> pact.telegram.CreateSessionRequest.LabelsEntry
+	0,  // 1: pact.telegram.DeleteSessionRequest.mode:type_name -> pact.telegram.DeleteMode
+	2,  // 2: pact.telegram.ResumeSessionResponse.state:type_name -> pact.telegram.SessionState
+	1,  // 3: pact.telegram.SendJob.status:type_name -> pact.telegram.SendJobStatus
+	15, // 4: pact.telegram.GetSendStatusResponse.job:type_name -> pact.telegram.SendJob
+	40, // 5: pact.telegram.SubscribeAllRequest.label_selector:type_name -> pact.telegram.SubscribeAllRequest.LabelSelectorEntry
+	23, // 6: pact.telegram.GetHistoryResponse.messages:type_name -> pact.telegram.HistoryMessage
+	2,  // 7: pact.telegram.GetSessionStatusResponse.state:type_name -> pact.telegram.SessionState
+	41, // 8: pact.telegram.ListSessionsRequest.label_selector:type_name -> pact.telegram.ListSessionsRequest.LabelSelectorEntry
+	2,  // 9: pact.telegram.SessionInfo.state:type_name -> pact.telegram.SessionState
+	42, // 10: pact.telegram.SessionInfo.labels:type_name -> pact.telegram.SessionInfo.LabelsEntry
+	28, // 11: pact.telegram.ListSessionsResponse.sessions:type_name -> pact.telegram.SessionInfo
+	43, // 12: pact.telegram.Webhook.label_selector:type_name -> pact.telegram.Webhook.LabelSelectorEntry
+	44, // 13: pact.telegram.RegisterWebhookRequest.label_selector:type_name -> pact.telegram.RegisterWebhookRequest.LabelSelectorEntry
+	32, // 14: pact.telegram.ListWebhooksResponse.webhooks:type_name -> pact.telegram.Webhook
+	3,  // 15: pact.telegram.TelegramService.CreateSession:input_type -> pact.telegram.CreateSessionRequest
+	5,  // 16: pact.telegram.TelegramService.DeleteSession:input_type -> pact.telegram.DeleteSessionRequest
+	7,  // 17: pact.telegram.TelegramService.ResumeSession:input_type -> pact.telegram.ResumeSessionRequest
+	9,  // 18: pact.telegram.TelegramService.SubmitPassword:input_type -> pact.telegram.SubmitPasswordRequest
+	11, // 19: pact.telegram.TelegramService.SendMessage:input_type -> pact.telegram.SendMessageRequest
+	13, // 20: pact.telegram.TelegramService.EnqueueMessage:input_type -> pact.telegram.EnqueueMessageRequest
+	16, // 21: pact.telegram.TelegramService.GetSendStatus:input_type -> pact.telegram.GetSendStatusRequest
+	18, // 22: pact.telegram.TelegramService.SubscribeDeliveries:input_type -> pact.telegram.SubscribeDeliveriesRequest
+	19, // 23: pact.telegram.TelegramService.SubscribeMessages:input_type -> pact.telegram.SubscribeMessagesRequest
+	20, // 24: pact.telegram.TelegramService.SubscribeAll:input_type -> pact.telegram.SubscribeAllRequest
+	22, // 25: pact.telegram.TelegramService.GetHistory:input_type -> pact.telegram.GetHistoryRequest
+	25, // 26: pact.telegram.TelegramService.GetSessionStatus:input_type -> pact.telegram.GetSessionStatusRequest
+	27, // 27: pact.telegram.TelegramService.ListSessions:input_type -> pact.telegram.ListSessionsRequest
+	30, // 28: pact.telegram.TelegramService.GetServiceStatus:input_type -> pact.telegram.GetServiceStatusRequest
+	33, // 29: pact.telegram.TelegramService.RegisterWebhook:input_type -> pact.telegram.RegisterWebhookRequest
+	35, // 30: pact.telegram.TelegramService.DeleteWebhook:input_type -> pact.telegram.DeleteWebhookRequest
+	37, // 31: pact.telegram.TelegramService.ListWebhooks:input_type -> pact.telegram.ListWebhooksRequest
+	4,  // 32: pact.telegram.TelegramService.CreateSession:output_type -> pact.telegram.CreateSessionResponse
+	6,  // 33: pact.telegram.TelegramService.DeleteSession:output_type -> pact.telegram.DeleteSessionResponse
+	8,  // 34: pact.telegram.TelegramService.ResumeSession:output_type -> pact.telegram.ResumeSessionResponse
+	10, // 35: pact.telegram.TelegramService.SubmitPassword:output_type -> pact.telegram.SubmitPasswordResponse
+	12, // 36: pact.telegram.TelegramService.SendMessage:output_type -> pact.telegram.SendMessageResponse
+	14, // 37: pact.telegram.TelegramService.EnqueueMessage:output_type -> pact.telegram.EnqueueMessageResponse
+	17, // 38: pact.telegram.TelegramService.GetSendStatus:output_type -> pact.telegram.GetSendStatusResponse
+	15, // 39: pact.telegram.TelegramService.SubscribeDeliveries:output_type -> pact.telegram.SendJob
+	21, // 40: pact.telegram.TelegramService.SubscribeMessages:output_type -> pact.telegram.MessageUpdate
+	21, // 41: pact.telegram.TelegramService.SubscribeAll:output_type -> pact.telegram.MessageUpdate
+	24, // 42: pact.telegram.TelegramService.GetHistory:output_type -> pact.telegram.GetHistoryResponse
+	26, // 43: pact.telegram.TelegramService.GetSessionStatus:output_type -> pact.telegram.GetSessionStatusResponse
+	29, // 44: pact.telegram.TelegramService.ListSessions:output_type -> pact.telegram.ListSessionsResponse
+	31, // 45: pact.telegram.TelegramService.GetServiceStatus:output_type -> pact.telegram.GetServiceStatusResponse
+	34, // 46: pact.telegram.TelegramService.RegisterWebhook:output_type -> pact.telegram.RegisterWebhookResponse
+	36, // 47: pact.telegram.TelegramService.DeleteWebhook:output_type -> pact.telegram.DeleteWebhookResponse
+	38, // 48: pact.telegram.TelegramService.ListWebhooks:output_type -> pact.telegram.ListWebhooksResponse
+	32, // [32:49] is the sub-list for method output_type
+	15, // [15:32] is the sub-list for method input_type
+	15, // [15:15] is the sub-list for extension type_name
+	15, // [15:15] is the sub-list for extension extendee
+	0,  // [0:15] is the sub-list for field type_name
 }
 
 func init() { file_proto_telegram_proto_init() }
@@ -589,13 +2573,14 @@ func file_proto_telegram_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_telegram_proto_rawDesc), len(file_proto_telegram_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   10,
+			NumEnums:      3,
+			NumMessages:   42,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_proto_telegram_proto_goTypes,
 		DependencyIndexes: file_proto_telegram_proto_depIdxs,
+		EnumInfos:         file_proto_telegram_proto_enumTypes,
 		MessageInfos:      file_proto_telegram_proto_msgTypes,
 	}.Build()
 	File_proto_telegram_proto = out.File
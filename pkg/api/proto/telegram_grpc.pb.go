@@ -19,11 +19,23 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	TelegramService_CreateSession_FullMethodName     = "/pact.telegram.TelegramService/CreateSession"
-	TelegramService_DeleteSession_FullMethodName     = "/pact.telegram.TelegramService/DeleteSession"
-	TelegramService_SendMessage_FullMethodName       = "/pact.telegram.TelegramService/SendMessage"
-	TelegramService_SubscribeMessages_FullMethodName = "/pact.telegram.TelegramService/SubscribeMessages"
-	TelegramService_GetSessionStatus_FullMethodName  = "/pact.telegram.TelegramService/GetSessionStatus"
+	TelegramService_CreateSession_FullMethodName       = "/pact.telegram.TelegramService/CreateSession"
+	TelegramService_DeleteSession_FullMethodName       = "/pact.telegram.TelegramService/DeleteSession"
+	TelegramService_ResumeSession_FullMethodName       = "/pact.telegram.TelegramService/ResumeSession"
+	TelegramService_SubmitPassword_FullMethodName      = "/pact.telegram.TelegramService/SubmitPassword"
+	TelegramService_SendMessage_FullMethodName         = "/pact.telegram.TelegramService/SendMessage"
+	TelegramService_EnqueueMessage_FullMethodName      = "/pact.telegram.TelegramService/EnqueueMessage"
+	TelegramService_GetSendStatus_FullMethodName       = "/pact.telegram.TelegramService/GetSendStatus"
+	TelegramService_SubscribeDeliveries_FullMethodName = "/pact.telegram.TelegramService/SubscribeDeliveries"
+	TelegramService_SubscribeMessages_FullMethodName   = "/pact.telegram.TelegramService/SubscribeMessages"
+	TelegramService_SubscribeAll_FullMethodName        = "/pact.telegram.TelegramService/SubscribeAll"
+	TelegramService_GetHistory_FullMethodName          = "/pact.telegram.TelegramService/GetHistory"
+	TelegramService_GetSessionStatus_FullMethodName    = "/pact.telegram.TelegramService/GetSessionStatus"
+	TelegramService_ListSessions_FullMethodName        = "/pact.telegram.TelegramService/ListSessions"
+	TelegramService_GetServiceStatus_FullMethodName    = "/pact.telegram.TelegramService/GetServiceStatus"
+	TelegramService_RegisterWebhook_FullMethodName     = "/pact.telegram.TelegramService/RegisterWebhook"
+	TelegramService_DeleteWebhook_FullMethodName       = "/pact.telegram.TelegramService/DeleteWebhook"
+	TelegramService_ListWebhooks_FullMethodName        = "/pact.telegram.TelegramService/ListWebhooks"
 )
 
 // TelegramServiceClient is the client API for TelegramService service.
@@ -32,9 +44,21 @@ const (
 type TelegramServiceClient interface {
 	CreateSession(ctx context.Context, in *CreateSessionRequest, opts ...grpc.CallOption) (*CreateSessionResponse, error)
 	DeleteSession(ctx context.Context, in *DeleteSessionRequest, opts ...grpc.CallOption) (*DeleteSessionResponse, error)
+	ResumeSession(ctx context.Context, in *ResumeSessionRequest, opts ...grpc.CallOption) (*ResumeSessionResponse, error)
+	SubmitPassword(ctx context.Context, in *SubmitPasswordRequest, opts ...grpc.CallOption) (*SubmitPasswordResponse, error)
 	SendMessage(ctx context.Context, in *SendMessageRequest, opts ...grpc.CallOption) (*SendMessageResponse, error)
+	EnqueueMessage(ctx context.Context, in *EnqueueMessageRequest, opts ...grpc.CallOption) (*EnqueueMessageResponse, error)
+	GetSendStatus(ctx context.Context, in *GetSendStatusRequest, opts ...grpc.CallOption) (*GetSendStatusResponse, error)
+	SubscribeDeliveries(ctx context.Context, in *SubscribeDeliveriesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SendJob], error)
 	SubscribeMessages(ctx context.Context, in *SubscribeMessagesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[MessageUpdate], error)
+	SubscribeAll(ctx context.Context, in *SubscribeAllRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[MessageUpdate], error)
+	GetHistory(ctx context.Context, in *GetHistoryRequest, opts ...grpc.CallOption) (*GetHistoryResponse, error)
 	GetSessionStatus(ctx context.Context, in *GetSessionStatusRequest, opts ...grpc.CallOption) (*GetSessionStatusResponse, error)
+	ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error)
+	GetServiceStatus(ctx context.Context, in *GetServiceStatusRequest, opts ...grpc.CallOption) (*GetServiceStatusResponse, error)
+	RegisterWebhook(ctx context.Context, in *RegisterWebhookRequest, opts ...grpc.CallOption) (*RegisterWebhookResponse, error)
+	DeleteWebhook(ctx context.Context, in *DeleteWebhookRequest, opts ...grpc.CallOption) (*DeleteWebhookResponse, error)
+	ListWebhooks(ctx context.Context, in *ListWebhooksRequest, opts ...grpc.CallOption) (*ListWebhooksResponse, error)
 }
 
 type telegramServiceClient struct {
@@ -65,6 +89,26 @@ func (c *telegramServiceClient) DeleteSession(ctx context.Context, in *DeleteSes
 	return out, nil
 }
 
+func (c *telegramServiceClient) ResumeSession(ctx context.Context, in *ResumeSessionRequest, opts ...grpc.CallOption) (*ResumeSessionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResumeSessionResponse)
+	err := c.cc.Invoke(ctx, TelegramService_ResumeSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *telegramServiceClient) SubmitPassword(ctx context.Context, in *SubmitPasswordRequest, opts ...grpc.CallOption) (*SubmitPasswordResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubmitPasswordResponse)
+	err := c.cc.Invoke(ctx, TelegramService_SubmitPassword_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *telegramServiceClient) SendMessage(ctx context.Context, in *SendMessageRequest, opts ...grpc.CallOption) (*SendMessageResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(SendMessageResponse)
@@ -75,9 +119,48 @@ func (c *telegramServiceClient) SendMessage(ctx context.Context, in *SendMessage
 	return out, nil
 }
 
+func (c *telegramServiceClient) EnqueueMessage(ctx context.Context, in *EnqueueMessageRequest, opts ...grpc.CallOption) (*EnqueueMessageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EnqueueMessageResponse)
+	err := c.cc.Invoke(ctx, TelegramService_EnqueueMessage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *telegramServiceClient) GetSendStatus(ctx context.Context, in *GetSendStatusRequest, opts ...grpc.CallOption) (*GetSendStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetSendStatusResponse)
+	err := c.cc.Invoke(ctx, TelegramService_GetSendStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *telegramServiceClient) SubscribeDeliveries(ctx context.Context, in *SubscribeDeliveriesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SendJob], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TelegramService_ServiceDesc.Streams[0], TelegramService_SubscribeDeliveries_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeDeliveriesRequest, SendJob]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TelegramService_SubscribeDeliveriesClient = grpc.ServerStreamingClient[SendJob]
+
 func (c *telegramServiceClient) SubscribeMessages(ctx context.Context, in *SubscribeMessagesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[MessageUpdate], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &TelegramService_ServiceDesc.Streams[0], TelegramService_SubscribeMessages_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &TelegramService_ServiceDesc.Streams[1], TelegramService_SubscribeMessages_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -94,6 +177,35 @@ func (c *telegramServiceClient) SubscribeMessages(ctx context.Context, in *Subsc
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type TelegramService_SubscribeMessagesClient = grpc.ServerStreamingClient[MessageUpdate]
 
+func (c *telegramServiceClient) SubscribeAll(ctx context.Context, in *SubscribeAllRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[MessageUpdate], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TelegramService_ServiceDesc.Streams[2], TelegramService_SubscribeAll_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeAllRequest, MessageUpdate]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TelegramService_SubscribeAllClient = grpc.ServerStreamingClient[MessageUpdate]
+
+func (c *telegramServiceClient) GetHistory(ctx context.Context, in *GetHistoryRequest, opts ...grpc.CallOption) (*GetHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetHistoryResponse)
+	err := c.cc.Invoke(ctx, TelegramService_GetHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *telegramServiceClient) GetSessionStatus(ctx context.Context, in *GetSessionStatusRequest, opts ...grpc.CallOption) (*GetSessionStatusResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(GetSessionStatusResponse)
@@ -104,15 +216,77 @@ func (c *telegramServiceClient) GetSessionStatus(ctx context.Context, in *GetSes
 	return out, nil
 }
 
+func (c *telegramServiceClient) ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSessionsResponse)
+	err := c.cc.Invoke(ctx, TelegramService_ListSessions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *telegramServiceClient) GetServiceStatus(ctx context.Context, in *GetServiceStatusRequest, opts ...grpc.CallOption) (*GetServiceStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetServiceStatusResponse)
+	err := c.cc.Invoke(ctx, TelegramService_GetServiceStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *telegramServiceClient) RegisterWebhook(ctx context.Context, in *RegisterWebhookRequest, opts ...grpc.CallOption) (*RegisterWebhookResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegisterWebhookResponse)
+	err := c.cc.Invoke(ctx, TelegramService_RegisterWebhook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *telegramServiceClient) DeleteWebhook(ctx context.Context, in *DeleteWebhookRequest, opts ...grpc.CallOption) (*DeleteWebhookResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteWebhookResponse)
+	err := c.cc.Invoke(ctx, TelegramService_DeleteWebhook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *telegramServiceClient) ListWebhooks(ctx context.Context, in *ListWebhooksRequest, opts ...grpc.CallOption) (*ListWebhooksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListWebhooksResponse)
+	err := c.cc.Invoke(ctx, TelegramService_ListWebhooks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // TelegramServiceServer is the server API for TelegramService service.
 // All implementations must embed UnimplementedTelegramServiceServer
 // for forward compatibility.
 type TelegramServiceServer interface {
 	CreateSession(context.Context, *CreateSessionRequest) (*CreateSessionResponse, error)
 	DeleteSession(context.Context, *DeleteSessionRequest) (*DeleteSessionResponse, error)
+	ResumeSession(context.Context, *ResumeSessionRequest) (*ResumeSessionResponse, error)
+	SubmitPassword(context.Context, *SubmitPasswordRequest) (*SubmitPasswordResponse, error)
 	SendMessage(context.Context, *SendMessageRequest) (*SendMessageResponse, error)
+	EnqueueMessage(context.Context, *EnqueueMessageRequest) (*EnqueueMessageResponse, error)
+	GetSendStatus(context.Context, *GetSendStatusRequest) (*GetSendStatusResponse, error)
+	SubscribeDeliveries(*SubscribeDeliveriesRequest, grpc.ServerStreamingServer[SendJob]) error
 	SubscribeMessages(*SubscribeMessagesRequest, grpc.ServerStreamingServer[MessageUpdate]) error
+	SubscribeAll(*SubscribeAllRequest, grpc.ServerStreamingServer[MessageUpdate]) error
+	GetHistory(context.Context, *GetHistoryRequest) (*GetHistoryResponse, error)
 	GetSessionStatus(context.Context, *GetSessionStatusRequest) (*GetSessionStatusResponse, error)
+	ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error)
+	GetServiceStatus(context.Context, *GetServiceStatusRequest) (*GetServiceStatusResponse, error)
+	RegisterWebhook(context.Context, *RegisterWebhookRequest) (*RegisterWebhookResponse, error)
+	DeleteWebhook(context.Context, *DeleteWebhookRequest) (*DeleteWebhookResponse, error)
+	ListWebhooks(context.Context, *ListWebhooksRequest) (*ListWebhooksResponse, error)
 	mustEmbedUnimplementedTelegramServiceServer()
 }
 
@@ -129,15 +303,51 @@ func (UnimplementedTelegramServiceServer) CreateSession(context.Context, *Create
 func (UnimplementedTelegramServiceServer) DeleteSession(context.Context, *DeleteSessionRequest) (*DeleteSessionResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method DeleteSession not implemented")
 }
+func (UnimplementedTelegramServiceServer) ResumeSession(context.Context, *ResumeSessionRequest) (*ResumeSessionResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ResumeSession not implemented")
+}
+func (UnimplementedTelegramServiceServer) SubmitPassword(context.Context, *SubmitPasswordRequest) (*SubmitPasswordResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SubmitPassword not implemented")
+}
 func (UnimplementedTelegramServiceServer) SendMessage(context.Context, *SendMessageRequest) (*SendMessageResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method SendMessage not implemented")
 }
+func (UnimplementedTelegramServiceServer) EnqueueMessage(context.Context, *EnqueueMessageRequest) (*EnqueueMessageResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method EnqueueMessage not implemented")
+}
+func (UnimplementedTelegramServiceServer) GetSendStatus(context.Context, *GetSendStatusRequest) (*GetSendStatusResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetSendStatus not implemented")
+}
+func (UnimplementedTelegramServiceServer) SubscribeDeliveries(*SubscribeDeliveriesRequest, grpc.ServerStreamingServer[SendJob]) error {
+	return status.Error(codes.Unimplemented, "method SubscribeDeliveries not implemented")
+}
 func (UnimplementedTelegramServiceServer) SubscribeMessages(*SubscribeMessagesRequest, grpc.ServerStreamingServer[MessageUpdate]) error {
 	return status.Error(codes.Unimplemented, "method SubscribeMessages not implemented")
 }
+func (UnimplementedTelegramServiceServer) SubscribeAll(*SubscribeAllRequest, grpc.ServerStreamingServer[MessageUpdate]) error {
+	return status.Error(codes.Unimplemented, "method SubscribeAll not implemented")
+}
+func (UnimplementedTelegramServiceServer) GetHistory(context.Context, *GetHistoryRequest) (*GetHistoryResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetHistory not implemented")
+}
 func (UnimplementedTelegramServiceServer) GetSessionStatus(context.Context, *GetSessionStatusRequest) (*GetSessionStatusResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method GetSessionStatus not implemented")
 }
+func (UnimplementedTelegramServiceServer) ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListSessions not implemented")
+}
+func (UnimplementedTelegramServiceServer) GetServiceStatus(context.Context, *GetServiceStatusRequest) (*GetServiceStatusResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetServiceStatus not implemented")
+}
+func (UnimplementedTelegramServiceServer) RegisterWebhook(context.Context, *RegisterWebhookRequest) (*RegisterWebhookResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RegisterWebhook not implemented")
+}
+func (UnimplementedTelegramServiceServer) DeleteWebhook(context.Context, *DeleteWebhookRequest) (*DeleteWebhookResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteWebhook not implemented")
+}
+func (UnimplementedTelegramServiceServer) ListWebhooks(context.Context, *ListWebhooksRequest) (*ListWebhooksResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListWebhooks not implemented")
+}
 func (UnimplementedTelegramServiceServer) mustEmbedUnimplementedTelegramServiceServer() {}
 func (UnimplementedTelegramServiceServer) testEmbeddedByValue()                         {}
 
@@ -195,6 +405,42 @@ func _TelegramService_DeleteSession_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TelegramService_ResumeSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResumeSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TelegramServiceServer).ResumeSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TelegramService_ResumeSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TelegramServiceServer).ResumeSession(ctx, req.(*ResumeSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TelegramService_SubmitPassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitPasswordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TelegramServiceServer).SubmitPassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TelegramService_SubmitPassword_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TelegramServiceServer).SubmitPassword(ctx, req.(*SubmitPasswordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _TelegramService_SendMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(SendMessageRequest)
 	if err := dec(in); err != nil {
@@ -213,6 +459,53 @@ func _TelegramService_SendMessage_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TelegramService_EnqueueMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EnqueueMessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TelegramServiceServer).EnqueueMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TelegramService_EnqueueMessage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TelegramServiceServer).EnqueueMessage(ctx, req.(*EnqueueMessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TelegramService_GetSendStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSendStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TelegramServiceServer).GetSendStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TelegramService_GetSendStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TelegramServiceServer).GetSendStatus(ctx, req.(*GetSendStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TelegramService_SubscribeDeliveries_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeDeliveriesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TelegramServiceServer).SubscribeDeliveries(m, &grpc.GenericServerStream[SubscribeDeliveriesRequest, SendJob]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TelegramService_SubscribeDeliveriesServer = grpc.ServerStreamingServer[SendJob]
+
 func _TelegramService_SubscribeMessages_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(SubscribeMessagesRequest)
 	if err := stream.RecvMsg(m); err != nil {
@@ -224,6 +517,35 @@ func _TelegramService_SubscribeMessages_Handler(srv interface{}, stream grpc.Ser
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type TelegramService_SubscribeMessagesServer = grpc.ServerStreamingServer[MessageUpdate]
 
+func _TelegramService_SubscribeAll_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeAllRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TelegramServiceServer).SubscribeAll(m, &grpc.GenericServerStream[SubscribeAllRequest, MessageUpdate]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TelegramService_SubscribeAllServer = grpc.ServerStreamingServer[MessageUpdate]
+
+func _TelegramService_GetHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TelegramServiceServer).GetHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TelegramService_GetHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TelegramServiceServer).GetHistory(ctx, req.(*GetHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _TelegramService_GetSessionStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetSessionStatusRequest)
 	if err := dec(in); err != nil {
@@ -242,6 +564,96 @@ func _TelegramService_GetSessionStatus_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TelegramService_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSessionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TelegramServiceServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TelegramService_ListSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TelegramServiceServer).ListSessions(ctx, req.(*ListSessionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TelegramService_GetServiceStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetServiceStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TelegramServiceServer).GetServiceStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TelegramService_GetServiceStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TelegramServiceServer).GetServiceStatus(ctx, req.(*GetServiceStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TelegramService_RegisterWebhook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterWebhookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TelegramServiceServer).RegisterWebhook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TelegramService_RegisterWebhook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TelegramServiceServer).RegisterWebhook(ctx, req.(*RegisterWebhookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TelegramService_DeleteWebhook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteWebhookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TelegramServiceServer).DeleteWebhook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TelegramService_DeleteWebhook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TelegramServiceServer).DeleteWebhook(ctx, req.(*DeleteWebhookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TelegramService_ListWebhooks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWebhooksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TelegramServiceServer).ListWebhooks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TelegramService_ListWebhooks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TelegramServiceServer).ListWebhooks(ctx, req.(*ListWebhooksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // TelegramService_ServiceDesc is the grpc.ServiceDesc for TelegramService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -257,21 +669,71 @@ var TelegramService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteSession",
 			Handler:    _TelegramService_DeleteSession_Handler,
 		},
+		{
+			MethodName: "ResumeSession",
+			Handler:    _TelegramService_ResumeSession_Handler,
+		},
+		{
+			MethodName: "SubmitPassword",
+			Handler:    _TelegramService_SubmitPassword_Handler,
+		},
 		{
 			MethodName: "SendMessage",
 			Handler:    _TelegramService_SendMessage_Handler,
 		},
+		{
+			MethodName: "EnqueueMessage",
+			Handler:    _TelegramService_EnqueueMessage_Handler,
+		},
+		{
+			MethodName: "GetSendStatus",
+			Handler:    _TelegramService_GetSendStatus_Handler,
+		},
+		{
+			MethodName: "GetHistory",
+			Handler:    _TelegramService_GetHistory_Handler,
+		},
 		{
 			MethodName: "GetSessionStatus",
 			Handler:    _TelegramService_GetSessionStatus_Handler,
 		},
+		{
+			MethodName: "ListSessions",
+			Handler:    _TelegramService_ListSessions_Handler,
+		},
+		{
+			MethodName: "GetServiceStatus",
+			Handler:    _TelegramService_GetServiceStatus_Handler,
+		},
+		{
+			MethodName: "RegisterWebhook",
+			Handler:    _TelegramService_RegisterWebhook_Handler,
+		},
+		{
+			MethodName: "DeleteWebhook",
+			Handler:    _TelegramService_DeleteWebhook_Handler,
+		},
+		{
+			MethodName: "ListWebhooks",
+			Handler:    _TelegramService_ListWebhooks_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeDeliveries",
+			Handler:       _TelegramService_SubscribeDeliveries_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "SubscribeMessages",
 			Handler:       _TelegramService_SubscribeMessages_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "SubscribeAll",
+			Handler:       _TelegramService_SubscribeAll_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "proto/telegram.proto",
 }